@@ -0,0 +1,224 @@
+package alert
+
+import (
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+// alertStore keeps the alerts fired, so a rule still firing is not notified again before repeat_minutes
+type alertStore interface {
+	GetFiringAlertByRuleName(ruleName string) (*models.Alert, error)
+	SaveAlert(alert *models.Alert) error
+	UpdateAlertNotified(id int64, value float64, message string) error
+	UpdateAlertResolved(id int64, value float64, message string) error
+}
+
+type dbAlertStore struct{}
+
+func (store *dbAlertStore) GetFiringAlertByRuleName(ruleName string) (*models.Alert, error) {
+	return models.GetFiringAlertByRuleName(ruleName)
+}
+
+func (store *dbAlertStore) SaveAlert(alert *models.Alert) error {
+	return database.SaveOne(alert)
+}
+
+func (store *dbAlertStore) UpdateAlertNotified(id int64, value float64, message string) error {
+	return models.UpdateAlertNotified(id, value, message)
+}
+
+func (store *dbAlertStore) UpdateAlertResolved(id int64, value float64, message string) error {
+	return models.UpdateAlertResolved(id, value, message)
+}
+
+// CheckAlerts evaluates the rules in [alert], a rule firing is notified once and then every repeat_minutes
+// until it recovers, and its recovery is notified too
+func CheckAlerts() error {
+	alertConfig := config.GetConfig().Alert
+	for _, rule := range alertConfig.Rules {
+		value, err := getMetricValue(rule)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		err = checkAlertRule(&dbAlertStore{}, rule, value, alertConfig.RepeatMinutes)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+func checkAlertRule(store alertStore, rule config.AlertRule, value float64, repeatMinutes int) error {
+	firing := value > rule.Threshold
+	if rule.Comparison == constants.ALERT_COMPARISON_BELOW {
+		firing = value < rule.Threshold
+	}
+
+	alert, err := store.GetFiringAlertByRuleName(rule.Name)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	message := getAlertMessage(rule, value, firing)
+	switch {
+	case firing && alert == nil:
+		alert = &models.Alert{
+			RuleName:   rule.Name,
+			Metric:     rule.Metric,
+			Status:     constants.ALERT_STATUS_FIRING,
+			Value:      value,
+			Threshold:  rule.Threshold,
+			Message:    message,
+			FireAt:     currentUtcMilliSec,
+			NotifiedAt: currentUtcMilliSec,
+			CreateAt:   currentUtcMilliSec,
+			UpdateAt:   currentUtcMilliSec,
+		}
+
+		err = store.SaveAlert(alert)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+	case firing:
+		if repeatMinutes <= 0 || currentUtcMilliSec-alert.NotifiedAt < int64(repeatMinutes)*60*1000 {
+			return nil
+		}
+
+		err = store.UpdateAlertNotified(alert.ID, value, message)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+	case alert != nil:
+		err = store.UpdateAlertResolved(alert.ID, value, message)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+		alert.Status = constants.ALERT_STATUS_RESOLVED
+	default:
+		return nil
+	}
+
+	Notify(&Notification{
+		RuleName:  rule.Name,
+		Metric:    rule.Metric,
+		Status:    alert.Status,
+		Value:     value,
+		Threshold: rule.Threshold,
+		Message:   message,
+		FireAt:    alert.FireAt,
+		CreateAt:  currentUtcMilliSec,
+	})
+
+	return nil
+}
+
+func getAlertMessage(rule config.AlertRule, value float64, firing bool) string {
+	state := "recovered"
+	if firing {
+		state = "firing"
+	}
+
+	metric := fmt.Sprintf("%s is %v", rule.Metric, value)
+	if rule.Metric != constants.ALERT_METRIC_SIGNER_BALANCE {
+		metric = fmt.Sprintf("%s in the last %d minutes is %v", rule.Metric, getWindowMinutes(rule), value)
+	}
+
+	return fmt.Sprintf("%s %s: %s, alert when %s %v", rule.Name, state, metric, rule.Comparison, rule.Threshold)
+}
+
+func getWindowMinutes(rule config.AlertRule) int {
+	if rule.WindowMinutes <= 0 {
+		return constants.ALERT_WINDOW_MINUTES_DEFAULT
+	}
+
+	return rule.WindowMinutes
+}
+
+func getMetricValue(rule config.AlertRule) (float64, error) {
+	updateAtMin := utils.GetCurrentUtcMilliSecond() - int64(getWindowMinutes(rule))*60*1000
+
+	var count int64
+	var err error
+	switch rule.Metric {
+	case constants.ALERT_METRIC_UNLOCK_FAILED:
+		count, err = models.GetOfflineDealCountByUnlockStatusUpdatedAfter(constants.OFFLINE_DEAL_UNLOCK_STATUS_UNLOCK_FAILED, updateAtMin)
+	case constants.ALERT_METRIC_DEAL_SENT_FAILED:
+		count, err = models.GetDealFileCountByStatusUpdatedAfter(constants.PROCESS_STATUS_DEAL_SENT_FAILED, updateAtMin)
+	case constants.ALERT_METRIC_REFUND_FAILED:
+		count, err = models.GetDealFileCountByStatusUpdatedAfter(constants.PROCESS_STATUS_UNLOCK_REFUNDFAILED, updateAtMin)
+	case constants.ALERT_METRIC_SIGNER_BALANCE:
+		return getSignerBalance()
+	case constants.ALERT_METRIC_LEDGER_DISCREPANCY:
+		count, err = models.GetLedgerDiscrepancyCountByStatus(constants.LEDGER_DISCREPANCY_STATUS_OPEN)
+	case constants.ALERT_METRIC_LOCK_WITHOUT_QUOTE:
+		count, err = models.GetEventLockPaymentCountWithoutQuoteCreatedAfter(updateAtMin)
+	default:
+		err := fmt.Errorf("alert rule:%s has unknown metric:%s", rule.Name, rule.Metric)
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	return float64(count), nil
+}
+
+// getSignerBalance returns the MATIC balance of the wallet paying gas for unlock and refund transactions
+func getSignerBalance() (float64, error) {
+	_, publicKeyAddress, err := client.GetPrivateKeyPublicKey(constants.PRIVATE_KEY_ON_POLYGON)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	ethClient, _, err := client.GetEthClient()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+	defer ethClient.Close()
+
+	balance, err := client.GetBalance(ethClient, *publicKeyAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	balanceMatic, _ := balance.Float64()
+	return balanceMatic, nil
+}
+
+// Notify sends the notification by all the notifiers, failures of one notifier do not stop the others
+func Notify(notification *Notification) map[string]error {
+	errs := map[string]error{}
+	for _, notifier := range GetNotifiers() {
+		err := notifier.Notify(notification)
+		if err != nil {
+			logs.GetLogger().Error(notifier.Name(), " notifier failed:", err)
+			errs[notifier.Name()] = err
+			continue
+		}
+	}
+
+	logs.GetLogger().Info(notification.getSubject(), ", ", notification.Message)
+	return errs
+}
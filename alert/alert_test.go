@@ -0,0 +1,175 @@
+package alert
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/models"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// memoryAlertStore keeps the alerts in memory in place of the alert table
+type memoryAlertStore struct {
+	alerts []*models.Alert
+}
+
+func (store *memoryAlertStore) GetFiringAlertByRuleName(ruleName string) (*models.Alert, error) {
+	for i := len(store.alerts) - 1; i >= 0; i-- {
+		if store.alerts[i].RuleName == ruleName && store.alerts[i].Status == constants.ALERT_STATUS_FIRING {
+			alert := *store.alerts[i]
+			return &alert, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (store *memoryAlertStore) SaveAlert(alert *models.Alert) error {
+	alert.ID = int64(len(store.alerts) + 1)
+	saved := *alert
+	store.alerts = append(store.alerts, &saved)
+	return nil
+}
+
+func (store *memoryAlertStore) UpdateAlertNotified(id int64, value float64, message string) error {
+	alert := store.alerts[id-1]
+	alert.Value = value
+	alert.Message = message
+	alert.NotifiedAt = utils.GetCurrentUtcMilliSecond()
+	return nil
+}
+
+func (store *memoryAlertStore) UpdateAlertResolved(id int64, value float64, message string) error {
+	alert := store.alerts[id-1]
+	resolvedAt := utils.GetCurrentUtcMilliSecond()
+	alert.Status = constants.ALERT_STATUS_RESOLVED
+	alert.Value = value
+	alert.Message = message
+	alert.ResolvedAt = &resolvedAt
+	return nil
+}
+
+func TestCheckAlertRule(t *testing.T) {
+	smtpServer := newTestSmtpServer(t)
+	httpServer := newTestHttpServer(t, http.StatusOK)
+
+	configuration := config.Configuration{}
+	configuration.Alert.SmtpHost = "127.0.0.1"
+	configuration.Alert.SmtpPort = smtpServer.port()
+	configuration.Alert.SmtpFrom = "mcs@example.com"
+	configuration.Alert.SmtpTo = []string{"ops@example.com"}
+	configuration.Alert.HttpUrl = httpServer.URL
+	config.SetConfig(configuration)
+
+	store := &memoryAlertStore{}
+	rule := config.AlertRule{
+		Name:       "unlock failed",
+		Metric:     constants.ALERT_METRIC_UNLOCK_FAILED,
+		Comparison: constants.ALERT_COMPARISON_ABOVE,
+		Threshold:  2,
+	}
+	repeatMinutes := 30
+
+	steps := []struct {
+		name           string
+		value          float64
+		notifiedMinAgo int64
+		status         string
+	}{
+		{"fires", 3, 0, constants.ALERT_STATUS_FIRING},
+		{"still firing is not notified again", 5, 0, ""},
+		{"still firing before repeat_minutes", 5, 29, ""},
+		{"still firing after repeat_minutes", 6, 31, constants.ALERT_STATUS_FIRING},
+		{"still firing just after the repeat", 6, 0, ""},
+		{"recovers", 2, 0, constants.ALERT_STATUS_RESOLVED},
+		{"recovered is not notified again", 1, 0, ""},
+		{"fires again", 4, 0, constants.ALERT_STATUS_FIRING},
+	}
+
+	notificationCount := 0
+	for _, step := range steps {
+		if step.notifiedMinAgo > 0 {
+			store.alerts[len(store.alerts)-1].NotifiedAt -= step.notifiedMinAgo * 60 * 1000
+		}
+
+		err := checkAlertRule(store, rule, step.value, repeatMinutes)
+		if err != nil {
+			t.Fatalf("%s: checkAlertRule() error %v", step.name, err)
+		}
+
+		notifications := httpServer.getNotifications(t)
+		mails := smtpServer.getMails()
+		if step.status == "" {
+			if len(notifications) != notificationCount || len(mails) != notificationCount {
+				t.Fatalf("%s: got %d notifications and %d mails, want %d", step.name, len(notifications), len(mails), notificationCount)
+			}
+			continue
+		}
+
+		notificationCount++
+		if len(notifications) != notificationCount || len(mails) != notificationCount {
+			t.Fatalf("%s: got %d notifications and %d mails, want %d", step.name, len(notifications), len(mails), notificationCount)
+		}
+
+		notification := notifications[notificationCount-1]
+		if notification.RuleName != rule.Name || notification.Status != step.status || notification.Value != step.value || notification.Threshold != rule.Threshold {
+			t.Errorf("%s: got notification %+v", step.name, notification)
+		}
+		if !strings.Contains(mails[notificationCount-1], "Subject: [MCS "+step.status+"] "+rule.Name+"\r\n") {
+			t.Errorf("%s: got mail %q", step.name, mails[notificationCount-1])
+		}
+	}
+
+	if len(store.alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2", len(store.alerts))
+	}
+	if store.alerts[0].Status != constants.ALERT_STATUS_RESOLVED || store.alerts[0].ResolvedAt == nil || store.alerts[0].Value != 2 {
+		t.Errorf("first alert %+v, want resolved at value 2", store.alerts[0])
+	}
+	if store.alerts[1].Status != constants.ALERT_STATUS_FIRING || store.alerts[1].Value != 4 {
+		t.Errorf("second alert %+v, want firing at value 4", store.alerts[1])
+	}
+}
+
+func TestCheckAlertRuleWithoutRepeat(t *testing.T) {
+	httpServer := newTestHttpServer(t, http.StatusOK)
+
+	configuration := config.Configuration{}
+	configuration.Alert.HttpUrl = httpServer.URL
+	config.SetConfig(configuration)
+
+	store := &memoryAlertStore{}
+	rule := config.AlertRule{
+		Name:       "signer balance",
+		Metric:     constants.ALERT_METRIC_SIGNER_BALANCE,
+		Comparison: constants.ALERT_COMPARISON_BELOW,
+		Threshold:  1,
+	}
+
+	for _, value := range []float64{1, 0.5, 0.4} {
+		err := checkAlertRule(store, rule, value, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// repeat_minutes 0 notifies only once while the rule keeps firing
+	store.alerts[0].NotifiedAt -= 24 * 60 * 60 * 1000
+	err := checkAlertRule(store, rule, 0.3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifications := httpServer.getNotifications(t)
+	if len(notifications) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(notifications))
+	}
+	if notifications[0].Status != constants.ALERT_STATUS_FIRING || notifications[0].Value != 0.5 {
+		t.Errorf("got notification %+v", notifications[0])
+	}
+	if notifications[0].Message != "signer balance firing: signer_balance is 0.5, alert when below 1" {
+		t.Errorf("got message %q", notifications[0].Message)
+	}
+}
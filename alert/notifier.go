@@ -0,0 +1,179 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/config"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+// Notification is sent when an alert fires, is still firing after repeat_minutes, or recovers
+type Notification struct {
+	RuleName  string  `json:"rule_name"`
+	Metric    string  `json:"metric"`
+	Status    string  `json:"status"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Message   string  `json:"message"`
+	FireAt    int64   `json:"fire_at"`
+	CreateAt  int64   `json:"create_at"`
+}
+
+func (notification *Notification) getSubject() string {
+	return fmt.Sprintf("[MCS %s] %s", notification.Status, notification.RuleName)
+}
+
+type Notifier interface {
+	Name() string
+	Notify(notification *Notification) error
+}
+
+// GetNotifiers returns the notifiers configured in [alert]
+func GetNotifiers() []Notifier {
+	alertConfig := config.GetConfig().Alert
+	notifiers := []Notifier{}
+	if alertConfig.SmtpHost != "" {
+		notifiers = append(notifiers, &smtpNotifier{
+			host:     alertConfig.SmtpHost,
+			port:     alertConfig.SmtpPort,
+			username: alertConfig.SmtpUsername,
+			password: os.Getenv(constants.ALERT_SMTP_PASSWORD),
+			from:     alertConfig.SmtpFrom,
+			to:       alertConfig.SmtpTo,
+		})
+	}
+
+	if alertConfig.SlackWebhookUrl != "" {
+		notifiers = append(notifiers, &slackNotifier{url: alertConfig.SlackWebhookUrl})
+	}
+
+	if alertConfig.HttpUrl != "" {
+		notifiers = append(notifiers, &httpNotifier{url: alertConfig.HttpUrl})
+	}
+
+	return notifiers
+}
+
+type smtpNotifier struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func (notifier *smtpNotifier) Name() string {
+	return "smtp"
+}
+
+func (notifier *smtpNotifier) Notify(notification *Notification) error {
+	if len(notifier.to) == 0 {
+		err := fmt.Errorf("smtp_to is not set")
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	// net/smtp only sends the password over tls, or to localhost
+	var auth smtp.Auth
+	if notifier.username != "" {
+		auth = smtp.PlainAuth("", notifier.username, notifier.password, notifier.host)
+	}
+
+	message := "From: " + notifier.from + "\r\n" +
+		"To: " + strings.Join(notifier.to, ",") + "\r\n" +
+		"Subject: " + notification.getSubject() + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		notification.Message + "\r\n"
+
+	addr := notifier.host + ":" + strconv.Itoa(notifier.port)
+	err := smtp.SendMail(addr, auth, notifier.from, notifier.to, []byte(message))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+type slackNotifier struct {
+	url string
+}
+
+func (notifier *slackNotifier) Name() string {
+	return "slack"
+}
+
+func (notifier *slackNotifier) Notify(notification *Notification) error {
+	body, err := json.Marshal(map[string]string{
+		"text": notification.getSubject() + "\n" + notification.Message,
+	})
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = postJson(notifier.url, body)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+type httpNotifier struct {
+	url string
+}
+
+func (notifier *httpNotifier) Name() string {
+	return "http"
+}
+
+func (notifier *httpNotifier) Notify(notification *Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = postJson(notifier.url, body)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func postJson(url string, body []byte) error {
+	httpClient := &http.Client{Timeout: constants.ALERT_TIMEOUT_SECONDS * time.Second}
+	response, err := httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+	defer response.Body.Close()
+
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, 1024*1024))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		err := fmt.Errorf("%s responded http status:%s", url, response.Status)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package alert
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/config"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testSmtpServer accepts mails on a local port and keeps their content, it does not offer STARTTLS or AUTH
+type testSmtpServer struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	mails    []string
+}
+
+func newTestSmtpServer(t *testing.T) *testSmtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &testSmtpServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+func (server *testSmtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL"), strings.HasPrefix(command, "RCPT"), strings.HasPrefix(command, "RSET"), strings.HasPrefix(command, "NOOP"):
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data := []string{}
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data = append(data, dataLine)
+			}
+
+			server.mutex.Lock()
+			server.mails = append(server.mails, strings.Join(data, ""))
+			server.mutex.Unlock()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+func (server *testSmtpServer) port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *testSmtpServer) getMails() []string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]string{}, server.mails...)
+}
+
+// testHttpServer keeps the json bodies posted to it and responds with status
+type testHttpServer struct {
+	*httptest.Server
+	mutex  sync.Mutex
+	status int
+	bodies [][]byte
+}
+
+func newTestHttpServer(t *testing.T, status int) *testHttpServer {
+	server := &testHttpServer{status: status}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		server.mutex.Lock()
+		server.bodies = append(server.bodies, body)
+		server.mutex.Unlock()
+		w.WriteHeader(server.status)
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (server *testHttpServer) getNotifications(t *testing.T) []*Notification {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	notifications := []*Notification{}
+	for _, body := range server.bodies {
+		notification := &Notification{}
+		err := json.Unmarshal(body, notification)
+		if err != nil {
+			t.Fatal(err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications
+}
+
+func TestNotify(t *testing.T) {
+	smtpServer := newTestSmtpServer(t)
+	slackServer := newTestHttpServer(t, http.StatusOK)
+	httpServer := newTestHttpServer(t, http.StatusOK)
+
+	configuration := config.Configuration{}
+	configuration.Alert.SmtpHost = "127.0.0.1"
+	configuration.Alert.SmtpPort = smtpServer.port()
+	configuration.Alert.SmtpFrom = "mcs@example.com"
+	configuration.Alert.SmtpTo = []string{"ops@example.com", "dev@example.com"}
+	configuration.Alert.SlackWebhookUrl = slackServer.URL
+	configuration.Alert.HttpUrl = httpServer.URL
+	config.SetConfig(configuration)
+
+	notification := &Notification{
+		RuleName:  "unlock failed",
+		Metric:    constants.ALERT_METRIC_UNLOCK_FAILED,
+		Status:    constants.ALERT_STATUS_FIRING,
+		Value:     3,
+		Threshold: 1,
+		Message:   "unlock failed firing",
+		FireAt:    1000,
+		CreateAt:  1000,
+	}
+
+	errs := Notify(notification)
+	if len(errs) != 0 {
+		t.Fatalf("Notify() errors %v", errs)
+	}
+
+	mails := smtpServer.getMails()
+	if len(mails) != 1 {
+		t.Fatalf("smtp got %d mails, want 1", len(mails))
+	}
+	for _, want := range []string{"To: ops@example.com,dev@example.com\r\n", "Subject: [MCS Firing] unlock failed\r\n", "\r\nunlock failed firing\r\n"} {
+		if !strings.Contains(mails[0], want) {
+			t.Errorf("smtp mail %q does not contain %q", mails[0], want)
+		}
+	}
+
+	slackServer.mutex.Lock()
+	slackBodies := slackServer.bodies
+	slackServer.mutex.Unlock()
+	if len(slackBodies) != 1 {
+		t.Fatalf("slack got %d messages, want 1", len(slackBodies))
+	}
+	slackMessage := map[string]string{}
+	err := json.Unmarshal(slackBodies[0], &slackMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slackMessage["text"] != "[MCS Firing] unlock failed\nunlock failed firing" {
+		t.Errorf("slack text = %q", slackMessage["text"])
+	}
+
+	notifications := httpServer.getNotifications(t)
+	if len(notifications) != 1 || *notifications[0] != *notification {
+		t.Errorf("http got %+v, want %+v", notifications, notification)
+	}
+}
+
+func TestNotifyFailure(t *testing.T) {
+	smtpServer := newTestSmtpServer(t)
+	httpServer := newTestHttpServer(t, http.StatusInternalServerError)
+
+	configuration := config.Configuration{}
+	configuration.Alert.SmtpHost = "127.0.0.1"
+	configuration.Alert.SmtpPort = smtpServer.port()
+	configuration.Alert.SmtpFrom = "mcs@example.com"
+	configuration.Alert.SmtpTo = []string{"ops@example.com"}
+	configuration.Alert.HttpUrl = httpServer.URL
+	config.SetConfig(configuration)
+
+	errs := Notify(&Notification{RuleName: "refund failed", Status: constants.ALERT_STATUS_RESOLVED, Message: "refund failed recovered"})
+	if len(errs) != 1 || errs["http"] == nil {
+		t.Fatalf("Notify() errors %v, want only http", errs)
+	}
+
+	// a notifier failing does not stop the others
+	mails := smtpServer.getMails()
+	if len(mails) != 1 || !strings.Contains(mails[0], "Subject: [MCS Resolved] refund failed\r\n") {
+		t.Errorf("smtp got %q", mails)
+	}
+
+	configuration.Alert.SmtpTo = nil
+	configuration.Alert.HttpUrl = ""
+	config.SetConfig(configuration)
+
+	errs = Notify(&Notification{RuleName: "refund failed", Status: constants.ALERT_STATUS_FIRING})
+	if errs["smtp"] == nil {
+		t.Errorf("Notify() without smtp_to errors %v, want smtp", errs)
+	}
+}
@@ -0,0 +1,165 @@
+package car
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	dagaggregator "github.com/filecoin-project/go-dagaggregator-unixfs"
+	"github.com/filecoin-project/go-dagaggregator-unixfs/lib/rambs"
+	"github.com/filswan/go-swan-lib/logs"
+	libmodel "github.com/filswan/go-swan-lib/model"
+	"github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	chunker "github.com/ipfs/go-ipfs-chunker"
+	exchangeoffline "github.com/ipfs/go-ipfs-exchange-offline"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-unixfs/importer"
+	gocar "github.com/ipld/go-car"
+)
+
+const (
+	CAR_VERSION_1 = 1
+	CAR_VERSION_2 = 2
+
+	CAR_V2_HEADER_SIZE = 40
+)
+
+var carV2Pragma = []byte{0x0a, 0xa1, 0x67, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x02}
+
+// CreateCarFile builds the car file of all the files under srcDir without lotus or ipfs,
+// files are chunked as `ipfs add` does by default and merged as ipfs.MergeFiles2CarFile does,
+// so a car v1 file has the same payload cid and piece cid as the one created by lotus
+func CreateCarFile(srcDir, carDir string, carVersion int) (*libmodel.FileDesc, error) {
+	srcFiles, err := ioutil.ReadDir(srcDir)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(srcFiles) == 0 {
+		err := fmt.Errorf("no files under directory:%s", srcDir)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	ctx := context.Background()
+	ramBs := new(rambs.RamBs)
+	dagService := merkledag.NewDAGService(blockservice.New(ramBs, exchangeoffline.Exchange(ramBs)))
+
+	srcFileSize := int64(0)
+	dagEntries := []dagaggregator.AggregateDagEntry{}
+	for _, srcFile := range srcFiles {
+		srcFileRoot, err := importFile(dagService, filepath.Join(srcDir, srcFile.Name()))
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		dagEntries = append(dagEntries, dagaggregator.AggregateDagEntry{RootCid: srcFileRoot.Cid()})
+		srcFileSize = srcFileSize + srcFile.Size()
+	}
+
+	root, _, err := dagaggregator.Aggregate(ctx, dagService, dagEntries)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	carFileName := root.String() + ".car"
+	carFilePath := filepath.Join(carDir, carFileName)
+	carFileSize, err := writeCarFile(ctx, dagService, root, carFilePath, carVersion)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	pieceCid, _, err := GetPieceCid(carFilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	fileDesc := &libmodel.FileDesc{
+		SourceFileName: filepath.Base(srcDir),
+		SourceFilePath: srcDir,
+		SourceFileSize: srcFileSize,
+		CarFileName:    carFileName,
+		CarFilePath:    carFilePath,
+		CarFileSize:    carFileSize,
+		PayloadCid:     root.String(),
+		PieceCid:       *pieceCid,
+	}
+
+	logs.GetLogger().Info("car file:", carFilePath, " created, payload_cid=", fileDesc.PayloadCid, ", piece_cid=", fileDesc.PieceCid)
+	return fileDesc, nil
+}
+
+func importFile(dagService ipld.DAGService, srcFilePath string) (ipld.Node, error) {
+	srcFile, err := os.Open(srcFilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+	defer srcFile.Close()
+
+	root, err := importer.BuildDagFromReader(dagService, chunker.DefaultSplitter(srcFile))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// blocks are written depth first and each only once, in the same order as `ipfs dag export`,
+// a car v2 file wraps the car v1 data with the v2 pragma and header, without index
+func writeCarFile(ctx context.Context, dagService ipld.DAGService, root cid.Cid, carFilePath string, carVersion int) (int64, error) {
+	carFile, err := os.Create(carFilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+	defer carFile.Close()
+
+	dataOffset := int64(0)
+	if carVersion == CAR_VERSION_2 {
+		dataOffset = int64(len(carV2Pragma) + CAR_V2_HEADER_SIZE)
+		_, err = carFile.Seek(dataOffset, io.SeekStart)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return 0, err
+		}
+	}
+
+	err = gocar.WriteCar(ctx, dagService, []cid.Cid{root}, carFile)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	carFileSize, err := carFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	if carVersion == CAR_VERSION_2 {
+		header := make([]byte, CAR_V2_HEADER_SIZE)
+		binary.LittleEndian.PutUint64(header[16:24], uint64(dataOffset))
+		binary.LittleEndian.PutUint64(header[24:32], uint64(carFileSize-dataOffset))
+
+		_, err = carFile.WriteAt(append(carV2Pragma, header...), 0)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return 0, err
+		}
+	}
+
+	return carFileSize, nil
+}
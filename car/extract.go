@@ -0,0 +1,84 @@
+package car
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/filecoin-project/go-dagaggregator-unixfs/lib/rambs"
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	exchangeoffline "github.com/ipfs/go-ipfs-exchange-offline"
+	"github.com/ipfs/go-merkledag"
+	unixfsio "github.com/ipfs/go-unixfs/io"
+	gocar "github.com/ipld/go-car"
+)
+
+// ExtractFile writes the content of the unixfs file rootCid stored in the car file to writer,
+// only the block sections of the file found by GetDagPositions are read, not the whole car file
+func ExtractFile(carFilePath, rootCid string, writer io.Writer) error {
+	dagPositions, err := GetDagPositions(carFilePath, []string{rootCid})
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	root, err := cid.Parse(rootCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	carFile, err := os.Open(carFilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+	defer carFile.Close()
+
+	_, err = carFile.Seek(dagPositions[0].Offset, io.SeekStart)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	// the block sections of the file are loaded as a car v1 file with the file as its root
+	header := &bytes.Buffer{}
+	err = gocar.WriteHeader(&gocar.CarHeader{Roots: []cid.Cid{root}, Version: CAR_VERSION_1}, header)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	ramBs := new(rambs.RamBs)
+	_, err = gocar.LoadCar(ramBs, io.MultiReader(header, io.LimitReader(carFile, dagPositions[0].Length)))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	ctx := context.Background()
+	dagService := merkledag.NewDAGService(blockservice.New(ramBs, exchangeoffline.Exchange(ramBs)))
+	rootNode, err := dagService.Get(ctx, root)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	dagReader, err := unixfsio.NewDagReader(ctx, rootNode, dagService)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+	defer dagReader.Close()
+
+	_, err = io.Copy(writer, dagReader)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
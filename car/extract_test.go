@@ -0,0 +1,35 @@
+package car
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractFile(t *testing.T) {
+	srcDir, srcFiles, rootCids := createTestSrcDir(t)
+
+	for _, carVersion := range []int{CAR_VERSION_1, CAR_VERSION_2} {
+		fileDesc, err := CreateCarFile(srcDir, t.TempDir(), carVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, content := range srcFiles {
+			extracted := &bytes.Buffer{}
+			err := ExtractFile(fileDesc.CarFilePath, rootCids[name], extracted)
+			if err != nil {
+				t.Fatalf("car v%d, %s: %s", carVersion, name, err.Error())
+			}
+
+			if !bytes.Equal(extracted.Bytes(), content) {
+				t.Errorf("car v%d, %s: %d bytes extracted, expected %d bytes", carVersion, name, extracted.Len(), len(content))
+			}
+		}
+	}
+
+	err := ExtractFile(filepath.Join(srcDir, "small.txt"), rootCids["small.txt"], &bytes.Buffer{})
+	if err == nil {
+		t.Errorf("extracting from a file which is not a car file should fail")
+	}
+}
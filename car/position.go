@@ -0,0 +1,183 @@
+package car
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-merkledag"
+	"github.com/multiformats/go-varint"
+)
+
+// DagPosition is the byte range of a sub dag inside a car file, from the first byte of its first block section
+// to the last byte of its last block section
+type DagPosition struct {
+	RootCid string `json:"root_cid"`
+	Offset  int64  `json:"offset"`
+	Length  int64  `json:"length"`
+}
+
+// block sections are keyed by multihash, since a block may be linked by either its cid v0 or cid v1
+type blockSection struct {
+	offset int64
+	length int64
+	links  []cid.Cid
+}
+
+// GetDagPositions finds where each of the rootCids is stored in the car file,
+// car files are exported depth first, so all the blocks of a sub dag are written next to each other
+func GetDagPositions(carFilePath string, rootCids []string) ([]*DagPosition, error) {
+	blockSections, err := readBlockSections(carFilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	dagPositions := []*DagPosition{}
+	for _, rootCidStr := range rootCids {
+		rootCid, err := cid.Parse(rootCidStr)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		start := int64(-1)
+		end := int64(-1)
+		visited := map[string]bool{}
+		cids := []cid.Cid{rootCid}
+		for len(cids) > 0 {
+			blockCid := cids[0]
+			cids = cids[1:]
+			if visited[string(blockCid.Hash())] {
+				continue
+			}
+			visited[string(blockCid.Hash())] = true
+
+			blockSection, ok := blockSections[string(blockCid.Hash())]
+			if !ok {
+				err := fmt.Errorf("block:%s of dag:%s not found in car file:%s", blockCid.String(), rootCidStr, carFilePath)
+				logs.GetLogger().Error(err)
+				return nil, err
+			}
+
+			if start < 0 || blockSection.offset < start {
+				start = blockSection.offset
+			}
+
+			if blockSection.offset+blockSection.length > end {
+				end = blockSection.offset + blockSection.length
+			}
+
+			cids = append(cids, blockSection.links...)
+		}
+
+		dagPositions = append(dagPositions, &DagPosition{
+			RootCid: rootCidStr,
+			Offset:  start,
+			Length:  end - start,
+		})
+	}
+
+	return dagPositions, nil
+}
+
+// a car file is a length prefixed header, followed by length prefixed sections of cid and block data
+func readBlockSections(carFilePath string) (map[string]*blockSection, error) {
+	carFile, err := os.Open(carFilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+	defer carFile.Close()
+
+	reader := bufio.NewReader(carFile)
+	headerLength, err := varint.ReadUvarint(reader)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	_, err = reader.Discard(int(headerLength))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	offset := int64(varint.UvarintSize(headerLength)) + int64(headerLength)
+
+	// a car v2 file starts with a pragma in the form of a car v1 header, the car v1 data follows the v2 header
+	if offset == int64(len(carV2Pragma)) {
+		_, err = reader.Discard(CAR_V2_HEADER_SIZE)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		headerLength, err = varint.ReadUvarint(reader)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		_, err = reader.Discard(int(headerLength))
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		offset = offset + CAR_V2_HEADER_SIZE + int64(varint.UvarintSize(headerLength)) + int64(headerLength)
+	}
+
+	blockSections := map[string]*blockSection{}
+	for {
+		sectionLength, err := varint.ReadUvarint(reader)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		section := make([]byte, sectionLength)
+		_, err = io.ReadFull(reader, section)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		cidLength, blockCid, err := cid.CidFromBytes(section)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		var links []cid.Cid
+		if blockCid.Type() == cid.DagProtobuf {
+			node, err := merkledag.DecodeProtobuf(section[cidLength:])
+			if err != nil {
+				logs.GetLogger().Error(err)
+				return nil, err
+			}
+
+			for _, link := range node.Links() {
+				links = append(links, link.Cid)
+			}
+		}
+
+		length := int64(varint.UvarintSize(sectionLength)) + int64(sectionLength)
+		blockSections[string(blockCid.Hash())] = &blockSection{
+			offset: offset,
+			length: length,
+			links:  links,
+		}
+
+		offset = offset + length
+	}
+
+	return blockSections, nil
+}
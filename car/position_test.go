@@ -0,0 +1,184 @@
+package car
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/filecoin-project/go-dagaggregator-unixfs/lib/rambs"
+	"github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	exchangeoffline "github.com/ipfs/go-ipfs-exchange-offline"
+	"github.com/ipfs/go-merkledag"
+	gocar "github.com/ipld/go-car"
+	"github.com/multiformats/go-varint"
+)
+
+// createTestSrcDir writes source files of one and several chunks, and returns their contents and root cids by name
+func createTestSrcDir(t *testing.T) (string, map[string][]byte, map[string]string) {
+	srcDir := t.TempDir()
+	srcFiles := map[string][]byte{
+		"small.txt": []byte("multi-chain-storage"),
+		"large.bin": make([]byte, 1024*1024+100),
+	}
+	rand.New(rand.NewSource(1)).Read(srcFiles["large.bin"])
+
+	rootCids := map[string]string{}
+	for name, content := range srcFiles {
+		srcFilePath := filepath.Join(srcDir, name)
+		err := os.WriteFile(srcFilePath, content, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ramBs := new(rambs.RamBs)
+		root, err := importFile(merkledag.NewDAGService(blockservice.New(ramBs, exchangeoffline.Exchange(ramBs))), srcFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rootCids[name] = root.Cid().String()
+	}
+
+	return srcDir, srcFiles, rootCids
+}
+
+func TestReadBlockSections(t *testing.T) {
+	srcDir, _, _ := createTestSrcDir(t)
+
+	carV1, err := CreateCarFile(srcDir, t.TempDir(), CAR_VERSION_1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	carV2, err := CreateCarFile(srcDir, t.TempDir(), CAR_VERSION_2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	carV1File, err := os.Open(carV1.CarFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer carV1File.Close()
+
+	carReader, err := gocar.NewCarReader(carV1File)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockCids := []cid.Cid{}
+	for {
+		block, err := carReader.Next()
+		if err != nil {
+			break
+		}
+		blockCids = append(blockCids, block.Cid())
+	}
+
+	carV2DataOffset := int64(len(carV2Pragma) + CAR_V2_HEADER_SIZE)
+	tests := []struct {
+		name        string
+		carFilePath string
+		dataOffset  int64
+	}{
+		{"car v1", carV1.CarFilePath, 0},
+		{"car v2", carV2.CarFilePath, carV2DataOffset},
+	}
+
+	for _, test := range tests {
+		blockSections, err := readBlockSections(test.carFilePath)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err.Error())
+		}
+
+		if len(blockSections) != len(blockCids) {
+			t.Fatalf("%s: %d block sections read, expected %d", test.name, len(blockSections), len(blockCids))
+		}
+
+		carBytes, err := ioutil.ReadFile(test.carFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, blockCid := range blockCids {
+			blockSection, ok := blockSections[string(blockCid.Hash())]
+			if !ok {
+				t.Fatalf("%s: block:%s not read", test.name, blockCid.String())
+			}
+
+			if blockSection.offset+blockSection.length > int64(len(carBytes)) {
+				t.Fatalf("%s: block:%s section is beyond the car file", test.name, blockCid.String())
+			}
+
+			// the section read at its offset is the length prefixed cid of the block and the block data
+			section := carBytes[blockSection.offset : blockSection.offset+blockSection.length]
+			sectionLength, lengthSize, err := varint.FromUvarint(section)
+			if err != nil || int64(lengthSize)+int64(sectionLength) != blockSection.length {
+				t.Fatalf("%s: block:%s section length is %d, expected %d", test.name, blockCid.String(), blockSection.length, int64(lengthSize)+int64(sectionLength))
+			}
+
+			_, sectionCid, err := cid.CidFromBytes(section[lengthSize:])
+			if err != nil || !sectionCid.Equals(blockCid) {
+				t.Errorf("%s: block:%s section has cid:%v", test.name, blockCid.String(), sectionCid)
+			}
+
+			// car v2 wraps the same car v1 data after its pragma and header
+			if test.dataOffset > 0 {
+				carV1Sections, err := readBlockSections(carV1.CarFilePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if blockSection.offset != carV1Sections[string(blockCid.Hash())].offset+test.dataOffset {
+					t.Errorf("%s: block:%s offset is %d, expected %d", test.name, blockCid.String(), blockSection.offset, carV1Sections[string(blockCid.Hash())].offset+test.dataOffset)
+				}
+			}
+		}
+	}
+}
+
+func TestGetDagPositions(t *testing.T) {
+	srcDir, srcFiles, rootCids := createTestSrcDir(t)
+
+	fileDesc, err := CreateCarFile(srcDir, t.TempDir(), CAR_VERSION_1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"small.txt", "large.bin"}
+	dagPositions, err := GetDagPositions(fileDesc.CarFilePath, []string{rootCids[names[0]], rootCids[names[1]]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	carBytes, err := ioutil.ReadFile(fileDesc.CarFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, dagPosition := range dagPositions {
+		if dagPosition.RootCid != rootCids[names[i]] {
+			t.Errorf("%s: root cid is %s, expected %s", names[i], dagPosition.RootCid, rootCids[names[i]])
+		}
+
+		// the first and last bytes of the file are within its dag position, which is smaller than the whole car file
+		dagBytes := carBytes[dagPosition.Offset : dagPosition.Offset+dagPosition.Length]
+		content := srcFiles[names[i]]
+		head := content[:len(content)/2]
+		if len(head) > 100 {
+			head = head[:100]
+		}
+		tail := content[len(content)-len(head):]
+		if !bytes.Contains(dagBytes, head) || !bytes.Contains(dagBytes, tail) || dagPosition.Length >= int64(len(carBytes)) {
+			t.Errorf("%s: dag position %d+%d does not hold the file", names[i], dagPosition.Offset, dagPosition.Length)
+		}
+	}
+
+	_, err = GetDagPositions(fileDesc.CarFilePath, []string{fileDesc.PieceCid})
+	if err == nil {
+		t.Errorf("dag not in the car file should not be found")
+	}
+}
@@ -0,0 +1,585 @@
+package car
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"sort"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filswan/go-swan-lib/logs"
+	libutils "github.com/filswan/go-swan-lib/utils"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-varint"
+)
+
+const (
+	FR32_UNPADDED_CHUNK_SIZE = 127
+	FR32_PADDED_CHUNK_SIZE   = 128
+	PIECE_NODE_SIZE          = 32
+	PIECE_SIZE_MIN           = 128
+	PIECE_TREE_LEVEL_MAX     = 40
+)
+
+// ProofNode is a node of the piece commitment tree, at level 0 are the 32 bytes leaves of the fr32 padded piece
+type ProofNode struct {
+	Level int    `json:"level"`
+	Index int64  `json:"index"`
+	Hash  string `json:"hash"`
+}
+
+// InclusionProof proves the car bytes [data_offset, data_offset+data_length) are the leaves [leaf_start, leaf_end)
+// of the piece, to verify: fr32 pad these bytes (bytes beyond the car file are 0), combine the leaves with the proof nodes
+// by sha256 with the 2 most significant bits of the last byte cleared, until the root equals the commP of piece_cid
+type InclusionProof struct {
+	PieceCid   string       `json:"piece_cid"`
+	PieceSize  int64        `json:"piece_size"`
+	RootCid    string       `json:"root_cid"`
+	CarOffset  int64        `json:"car_offset"`
+	CarLength  int64        `json:"car_length"`
+	DataOffset int64        `json:"data_offset"`
+	DataLength int64        `json:"data_length"`
+	LeafStart  int64        `json:"leaf_start"`
+	LeafEnd    int64        `json:"leaf_end"`
+	ProofNodes []*ProofNode `json:"proof_nodes"`
+}
+
+type pieceNode struct {
+	level int
+	index int64
+	hash  [PIECE_NODE_SIZE]byte
+}
+
+type proofBuilder struct {
+	stack           []*pieceNode
+	inclusionProofs []*InclusionProof
+}
+
+var zeroPieceNodes [PIECE_TREE_LEVEL_MAX + 1][PIECE_NODE_SIZE]byte
+
+func init() {
+	for level := 1; level <= PIECE_TREE_LEVEL_MAX; level++ {
+		zeroPieceNodes[level] = hashPieceNodes(zeroPieceNodes[level-1], zeroPieceNodes[level-1])
+	}
+}
+
+// GenerateInclusionProofs computes the piece commitment of the car file in one pass,
+// and collects for each dag position the sibling nodes needed to rebuild the commitment from its own bytes
+func GenerateInclusionProofs(carFilePath, pieceCid string, dagPositions []*DagPosition) ([]*InclusionProof, error) {
+	pieceCidParsed, err := cid.Parse(pieceCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	commP, err := commcid.CIDToPieceCommitmentV1(pieceCidParsed)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	carFile, err := os.Open(carFilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+	defer carFile.Close()
+
+	carFileInfo, err := carFile.Stat()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	pieceSize := getPieceSize(carFileInfo.Size())
+
+	builder := &proofBuilder{}
+	for _, dagPosition := range dagPositions {
+		chunkStart := dagPosition.Offset / FR32_UNPADDED_CHUNK_SIZE
+		chunkEnd := (dagPosition.Offset + dagPosition.Length + FR32_UNPADDED_CHUNK_SIZE - 1) / FR32_UNPADDED_CHUNK_SIZE
+		builder.inclusionProofs = append(builder.inclusionProofs, &InclusionProof{
+			PieceCid:   pieceCid,
+			PieceSize:  pieceSize,
+			RootCid:    dagPosition.RootCid,
+			CarOffset:  dagPosition.Offset,
+			CarLength:  dagPosition.Length,
+			DataOffset: chunkStart * FR32_UNPADDED_CHUNK_SIZE,
+			DataLength: (chunkEnd - chunkStart) * FR32_UNPADDED_CHUNK_SIZE,
+			LeafStart:  chunkStart * FR32_PADDED_CHUNK_SIZE / PIECE_NODE_SIZE,
+			LeafEnd:    chunkEnd * FR32_PADDED_CHUNK_SIZE / PIECE_NODE_SIZE,
+			ProofNodes: []*ProofNode{},
+		})
+	}
+
+	root, err := builder.buildPieceTree(carFile, pieceSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if !bytes.Equal(root[:], commP) {
+		err := fmt.Errorf("piece commitment:%s computed from car file:%s does not match piece cid:%s", hex.EncodeToString(root[:]), carFilePath, pieceCid)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	for _, inclusionProof := range builder.inclusionProofs {
+		sort.Slice(inclusionProof.ProofNodes, func(i, j int) bool {
+			return inclusionProof.ProofNodes[i].Level < inclusionProof.ProofNodes[j].Level
+		})
+	}
+
+	return builder.inclusionProofs, nil
+}
+
+// GetPieceCid calculates the piece commitment of the car file as lotus does, returns the piece cid and the padded piece size
+func GetPieceCid(carFilePath string) (*string, int64, error) {
+	carFile, err := os.Open(carFilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, 0, err
+	}
+	defer carFile.Close()
+
+	carFileInfo, err := carFile.Stat()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, 0, err
+	}
+
+	pieceSize := getPieceSize(carFileInfo.Size())
+	builder := &proofBuilder{}
+	root, err := builder.buildPieceTree(carFile, pieceSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, 0, err
+	}
+
+	pieceCid, err := commcid.PieceCommitmentV1ToCID(root[:])
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, 0, err
+	}
+
+	pieceCidStr := pieceCid.String()
+	return &pieceCidStr, pieceSize, nil
+}
+
+func getPieceSize(carFileSize int64) int64 {
+	_, sectorSize := libutils.CalculatePieceSize(carFileSize)
+	pieceSize := int64(sectorSize)
+	if pieceSize < PIECE_SIZE_MIN {
+		pieceSize = PIECE_SIZE_MIN
+	}
+
+	return pieceSize
+}
+
+// the car file is fr32 padded chunk by chunk into leaves, and the rest of the piece is zero padding
+func (builder *proofBuilder) buildPieceTree(carFile io.Reader, pieceSize int64) ([PIECE_NODE_SIZE]byte, error) {
+	var root [PIECE_NODE_SIZE]byte
+	rootLevel := bits.TrailingZeros64(uint64(pieceSize / PIECE_NODE_SIZE))
+
+	unpadded := make([]byte, FR32_UNPADDED_CHUNK_SIZE*1024)
+	padded := make([]byte, FR32_PADDED_CHUNK_SIZE*1024)
+	leafIndex := int64(0)
+	for {
+		bytesRead, err := io.ReadFull(carFile, unpadded)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil && err != io.ErrUnexpectedEOF {
+			logs.GetLogger().Error(err)
+			return root, err
+		}
+
+		chunks := (bytesRead + FR32_UNPADDED_CHUNK_SIZE - 1) / FR32_UNPADDED_CHUNK_SIZE
+		for i := bytesRead; i < chunks*FR32_UNPADDED_CHUNK_SIZE; i++ {
+			unpadded[i] = 0
+		}
+
+		padFr32(unpadded[:chunks*FR32_UNPADDED_CHUNK_SIZE], padded[:chunks*FR32_PADDED_CHUNK_SIZE])
+		for offset := 0; offset < chunks*FR32_PADDED_CHUNK_SIZE; offset = offset + PIECE_NODE_SIZE {
+			leaf := &pieceNode{level: 0, index: leafIndex}
+			copy(leaf.hash[:], padded[offset:offset+PIECE_NODE_SIZE])
+			builder.push(leaf)
+			leafIndex++
+		}
+
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+
+	if leafIndex == 0 {
+		err := fmt.Errorf("car file is empty")
+		logs.GetLogger().Error(err)
+		return root, err
+	}
+
+	for len(builder.stack) > 1 || builder.stack[0].level < rootLevel {
+		top := builder.stack[len(builder.stack)-1]
+		builder.push(&pieceNode{level: top.level, index: top.index + 1, hash: zeroPieceNodes[top.level]})
+	}
+
+	return builder.stack[0].hash, nil
+}
+
+// when two sibling nodes are combined, the one outside a proven range whose sibling is inside it is a proof node of the range
+func (builder *proofBuilder) push(node *pieceNode) {
+	for len(builder.stack) > 0 && builder.stack[len(builder.stack)-1].level == node.level {
+		left := builder.stack[len(builder.stack)-1]
+		builder.stack = builder.stack[:len(builder.stack)-1]
+
+		for _, inclusionProof := range builder.inclusionProofs {
+			leftIncluded := isPieceNodeInRange(left, inclusionProof)
+			rightIncluded := isPieceNodeInRange(node, inclusionProof)
+			if leftIncluded && !rightIncluded {
+				inclusionProof.ProofNodes = append(inclusionProof.ProofNodes, getProofNode(node))
+			} else if !leftIncluded && rightIncluded {
+				inclusionProof.ProofNodes = append(inclusionProof.ProofNodes, getProofNode(left))
+			}
+		}
+
+		node = &pieceNode{
+			level: left.level + 1,
+			index: left.index / 2,
+			hash:  hashPieceNodes(left.hash, node.hash),
+		}
+	}
+
+	builder.stack = append(builder.stack, node)
+}
+
+func isPieceNodeInRange(node *pieceNode, inclusionProof *InclusionProof) bool {
+	leafStart := node.index << node.level
+	leafEnd := (node.index + 1) << node.level
+	return leafStart < inclusionProof.LeafEnd && leafEnd > inclusionProof.LeafStart
+}
+
+func getProofNode(node *pieceNode) *ProofNode {
+	return &ProofNode{
+		Level: node.level,
+		Index: node.index,
+		Hash:  hex.EncodeToString(node.hash[:]),
+	}
+}
+
+func hashPieceNodes(left, right [PIECE_NODE_SIZE]byte) [PIECE_NODE_SIZE]byte {
+	hash := sha256.Sum256(append(left[:], right[:]...))
+	hash[PIECE_NODE_SIZE-1] &= 0x3f
+	return hash
+}
+
+// fr32 padding inserts 2 zero bits after every 254 bits, so each 127 bytes become 128 bytes
+func padFr32(in, out []byte) {
+	chunks := len(out) / FR32_PADDED_CHUNK_SIZE
+	for chunk := 0; chunk < chunks; chunk++ {
+		inOff := chunk * FR32_UNPADDED_CHUNK_SIZE
+		outOff := chunk * FR32_PADDED_CHUNK_SIZE
+
+		copy(out[outOff:outOff+31], in[inOff:inOff+31])
+
+		t := in[inOff+31] >> 6
+		out[outOff+31] = in[inOff+31] & 0x3f
+		var v byte
+
+		for i := 32; i < 64; i++ {
+			v = in[inOff+i]
+			out[outOff+i] = (v << 2) | t
+			t = v >> 6
+		}
+
+		t = v >> 4
+		out[outOff+63] &= 0x3f
+
+		for i := 64; i < 96; i++ {
+			v = in[inOff+i]
+			out[outOff+i] = (v << 4) | t
+			t = v >> 4
+		}
+
+		t = v >> 2
+		out[outOff+95] &= 0x3f
+
+		for i := 96; i < 127; i++ {
+			v = in[inOff+i]
+			out[outOff+i] = (v << 6) | t
+			t = v >> 2
+		}
+
+		out[outOff+127] = t & 0x3f
+	}
+}
+
+type proofVerifier struct {
+	rootLevel  int
+	proofNodes map[[2]int64][PIECE_NODE_SIZE]byte
+	stack      []*pieceNode
+}
+
+// VerifyInclusionProof checks the car bytes of the proof read from data, which starts at data_offset, rebuild the commP of piece_cid
+// with the proof nodes, and the block of root_cid is a block section within [car_offset, car_offset+car_length) whose data matches its cid,
+// data may be shorter than data_length at the end of the car file since the bytes beyond the car file are 0
+func VerifyInclusionProof(data io.ReadSeeker, inclusionProof *InclusionProof) error {
+	err := checkInclusionProofRange(inclusionProof)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	pieceCidParsed, err := cid.Parse(inclusionProof.PieceCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	commP, err := commcid.CIDToPieceCommitmentV1(pieceCidParsed)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	_, err = data.Seek(0, io.SeekStart)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	root, err := rebuildPieceRoot(io.LimitReader(data, inclusionProof.DataLength), inclusionProof)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if !bytes.Equal(root[:], commP) {
+		err := fmt.Errorf("piece commitment:%s rebuilt from the inclusion proof of:%s does not match piece cid:%s", hex.EncodeToString(root[:]), inclusionProof.RootCid, inclusionProof.PieceCid)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	_, err = data.Seek(inclusionProof.CarOffset-inclusionProof.DataOffset, io.SeekStart)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = findRootBlock(io.LimitReader(data, inclusionProof.CarLength), inclusionProof.RootCid, inclusionProof.CarLength)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// the leaves of the proof must be the fr32 padded chunks of its data, and the data must cover the dag in the car file
+func checkInclusionProofRange(inclusionProof *InclusionProof) error {
+	pieceSize := inclusionProof.PieceSize
+	if pieceSize < PIECE_SIZE_MIN || bits.OnesCount64(uint64(pieceSize)) != 1 {
+		err := fmt.Errorf("invalid piece size:%d", pieceSize)
+		return err
+	}
+
+	if inclusionProof.DataOffset < 0 || inclusionProof.DataLength <= 0 ||
+		inclusionProof.DataOffset%FR32_UNPADDED_CHUNK_SIZE != 0 || inclusionProof.DataLength%FR32_UNPADDED_CHUNK_SIZE != 0 {
+		err := fmt.Errorf("data offset:%d and length:%d are not whole fr32 chunks", inclusionProof.DataOffset, inclusionProof.DataLength)
+		return err
+	}
+
+	leafStart := inclusionProof.DataOffset / FR32_UNPADDED_CHUNK_SIZE * FR32_PADDED_CHUNK_SIZE / PIECE_NODE_SIZE
+	leafEnd := leafStart + inclusionProof.DataLength/FR32_UNPADDED_CHUNK_SIZE*FR32_PADDED_CHUNK_SIZE/PIECE_NODE_SIZE
+	if inclusionProof.LeafStart != leafStart || inclusionProof.LeafEnd != leafEnd || leafEnd > pieceSize/PIECE_NODE_SIZE {
+		err := fmt.Errorf("leaves [%d,%d) do not match data offset:%d and length:%d in piece size:%d", inclusionProof.LeafStart, inclusionProof.LeafEnd, inclusionProof.DataOffset, inclusionProof.DataLength, pieceSize)
+		return err
+	}
+
+	if inclusionProof.CarLength <= 0 || inclusionProof.CarOffset < inclusionProof.DataOffset ||
+		inclusionProof.CarOffset+inclusionProof.CarLength > inclusionProof.DataOffset+inclusionProof.DataLength {
+		err := fmt.Errorf("car offset:%d and length:%d are not within the data of the proof", inclusionProof.CarOffset, inclusionProof.CarLength)
+		return err
+	}
+
+	return nil
+}
+
+func rebuildPieceRoot(data io.Reader, inclusionProof *InclusionProof) ([PIECE_NODE_SIZE]byte, error) {
+	var root [PIECE_NODE_SIZE]byte
+	verifier := &proofVerifier{
+		rootLevel:  bits.TrailingZeros64(uint64(inclusionProof.PieceSize / PIECE_NODE_SIZE)),
+		proofNodes: map[[2]int64][PIECE_NODE_SIZE]byte{},
+	}
+
+	for _, proofNode := range inclusionProof.ProofNodes {
+		hash, err := hex.DecodeString(proofNode.Hash)
+		if err != nil || len(hash) != PIECE_NODE_SIZE {
+			err := fmt.Errorf("invalid hash:%s of proof node level:%d index:%d", proofNode.Hash, proofNode.Level, proofNode.Index)
+			return root, err
+		}
+
+		var node [PIECE_NODE_SIZE]byte
+		copy(node[:], hash)
+		verifier.proofNodes[[2]int64{int64(proofNode.Level), proofNode.Index}] = node
+	}
+
+	unpadded := make([]byte, FR32_UNPADDED_CHUNK_SIZE*1024)
+	padded := make([]byte, FR32_PADDED_CHUNK_SIZE*1024)
+	leafIndex := inclusionProof.LeafStart
+	for leafIndex < inclusionProof.LeafEnd {
+		bytesRead, err := io.ReadFull(data, unpadded)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return root, err
+		}
+
+		for i := bytesRead; i < len(unpadded); i++ {
+			unpadded[i] = 0
+		}
+
+		padFr32(unpadded, padded)
+		for offset := 0; offset < len(padded) && leafIndex < inclusionProof.LeafEnd; offset = offset + PIECE_NODE_SIZE {
+			leaf := &pieceNode{level: 0, index: leafIndex}
+			copy(leaf.hash[:], padded[offset:offset+PIECE_NODE_SIZE])
+			err = verifier.push(leaf)
+			if err != nil {
+				return root, err
+			}
+			leafIndex++
+		}
+	}
+
+	return verifier.getRoot()
+}
+
+// a node with an odd index is combined with its left sibling at once, the one with an even index waits in the stack for its right sibling
+func (verifier *proofVerifier) push(node *pieceNode) error {
+	for node.index%2 == 1 {
+		left, err := verifier.getLeftSibling(node)
+		if err != nil {
+			return err
+		}
+
+		node = &pieceNode{level: node.level + 1, index: node.index / 2, hash: hashPieceNodes(left, node.hash)}
+	}
+
+	verifier.stack = append(verifier.stack, node)
+	return nil
+}
+
+// after the last leaf, the nodes waiting in the stack are combined with the proof nodes on their right up to the root
+func (verifier *proofVerifier) getRoot() ([PIECE_NODE_SIZE]byte, error) {
+	var root [PIECE_NODE_SIZE]byte
+	if len(verifier.stack) == 0 {
+		err := fmt.Errorf("no leaf in inclusion proof")
+		return root, err
+	}
+
+	node := verifier.stack[len(verifier.stack)-1]
+	verifier.stack = verifier.stack[:len(verifier.stack)-1]
+	for node.level < verifier.rootLevel {
+		var left, right [PIECE_NODE_SIZE]byte
+		if node.index%2 == 1 {
+			var err error
+			left, err = verifier.getLeftSibling(node)
+			if err != nil {
+				return root, err
+			}
+			right = node.hash
+		} else {
+			proofNode, ok := verifier.proofNodes[[2]int64{int64(node.level), node.index + 1}]
+			if !ok {
+				err := fmt.Errorf("proof node of level:%d index:%d missing", node.level, node.index+1)
+				return root, err
+			}
+			left = node.hash
+			right = proofNode
+		}
+
+		node = &pieceNode{level: node.level + 1, index: node.index / 2, hash: hashPieceNodes(left, right)}
+	}
+
+	if len(verifier.stack) > 0 || node.index != 0 {
+		err := fmt.Errorf("inclusion proof does not end at the piece root")
+		return root, err
+	}
+
+	return node.hash, nil
+}
+
+func (verifier *proofVerifier) getLeftSibling(node *pieceNode) ([PIECE_NODE_SIZE]byte, error) {
+	if len(verifier.stack) > 0 {
+		top := verifier.stack[len(verifier.stack)-1]
+		if top.level == node.level && top.index == node.index-1 {
+			verifier.stack = verifier.stack[:len(verifier.stack)-1]
+			return top.hash, nil
+		}
+	}
+
+	proofNode, ok := verifier.proofNodes[[2]int64{int64(node.level), node.index - 1}]
+	if !ok {
+		err := fmt.Errorf("proof node of level:%d index:%d missing", node.level, node.index-1)
+		return proofNode, err
+	}
+
+	return proofNode, nil
+}
+
+// blocks are matched by multihash like GetDagPositions does, and the block data must hash to its cid
+func findRootBlock(carSections io.Reader, rootCid string, carLength int64) error {
+	rootCidParsed, err := cid.Parse(rootCid)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(carSections)
+	for {
+		sectionLength, err := varint.ReadUvarint(reader)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if sectionLength > uint64(carLength) {
+			err := fmt.Errorf("block section length:%d is beyond car length:%d", sectionLength, carLength)
+			return err
+		}
+
+		section := make([]byte, sectionLength)
+		_, err = io.ReadFull(reader, section)
+		if err != nil {
+			return err
+		}
+
+		cidLength, blockCid, err := cid.CidFromBytes(section)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(blockCid.Hash(), rootCidParsed.Hash()) {
+			continue
+		}
+
+		blockCidComputed, err := blockCid.Prefix().Sum(section[cidLength:])
+		if err != nil {
+			return err
+		}
+
+		if !blockCidComputed.Equals(blockCid) {
+			err := fmt.Errorf("data of block:%s does not match its cid", blockCid.String())
+			return err
+		}
+
+		return nil
+	}
+
+	err = fmt.Errorf("block:%s not found in the car bytes of the inclusion proof", rootCid)
+	return err
+}
@@ -0,0 +1,219 @@
+package car
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+)
+
+// commP of bytes i%251 of each size, as lotus computes them, and of zero pieces from lotus zerocomm
+var commPTests = []struct {
+	name      string
+	size      int
+	zero      bool
+	commP     string
+	pieceSize int64
+}{
+	{"65 bytes", 65, false, "5f6fdf721ee45657f563f7640e34812fc7d90175a77086c631c4a840cadb8734", 128},
+	{"one chunk", 127, false, "b817099547b8c59060fede17e8fef01cd2e5871fcc83cb2cf492000001a97216", 128},
+	{"one chunk and one byte", 128, false, "04fe0a0f7e0292ba93eec28cfa96c116cc6aa7b04221f6a1d92af1ed29824b33", 256},
+	{"zero padded", 1000, false, "95580a65d1dc378fe5ec2574128fe18059c8c94674cc0a84226d4d1588d61d37", 1024},
+	{"full piece", 2032, false, "6eadd63463ef3576a34dcb6ff742f8448eb22f09d59b9795a4f55d257be3da35", 2048},
+	{"full piece and one byte", 2033, false, "5dd5e971075d468f6afdec963f393fc553ba6ae0751fe52de307248c3d6bc700", 4096},
+	{"several buffers", 100000, false, "3f4694e22c90af1eae937e9b014d2c0c1708a12cfc5d0fd1ee5f1b75451b761c", 131072},
+	{"zero piece of 128", 127, true, "3731bb99ac689f66eef5973e4a94da188f4ddcae580724fc6f3fd60dfd488333", 128},
+	{"zero piece of 256", 254, true, "642a607ef886b004bf2c1978463ae1d4693ac0f410eb2d1b7a47fe205e5e750f", 256},
+	{"zero piece of 512", 508, true, "57a2381a28652bf47f6bef7aca679be4aede5871ab5cf3eb2c08114488cb8526", 512},
+}
+
+func TestPadFr32(t *testing.T) {
+	// 2 zero bits are inserted after every 254 bits, so each 32 bytes of all ones end with 0x3f
+	ones := bytes.Repeat([]byte{0xff}, FR32_UNPADDED_CHUNK_SIZE)
+	padded := make([]byte, FR32_PADDED_CHUNK_SIZE)
+	padFr32(ones, padded)
+	for i, b := range padded {
+		expected := byte(0xff)
+		if i%PIECE_NODE_SIZE == PIECE_NODE_SIZE-1 {
+			expected = 0x3f
+		}
+
+		if b != expected {
+			t.Fatalf("padded all ones byte %d is %#x, expected %#x", i, b, expected)
+		}
+	}
+
+	// the padded bits are 0 and the other bits are the input bits in order
+	unpadded := make([]byte, FR32_UNPADDED_CHUNK_SIZE*3)
+	rand.New(rand.NewSource(1)).Read(unpadded)
+	padded = make([]byte, FR32_PADDED_CHUNK_SIZE*3)
+	padFr32(unpadded, padded)
+
+	inBit := 0
+	for outBit := 0; outBit < len(padded)*8; outBit++ {
+		bit := padded[outBit/8] >> (outBit % 8) & 1
+		if outBit%256 >= 254 {
+			if bit != 0 {
+				t.Fatalf("padding bit %d is not 0", outBit)
+			}
+			continue
+		}
+
+		if bit != unpadded[inBit/8]>>(inBit%8)&1 {
+			t.Fatalf("padded bit %d is not input bit %d", outBit, inBit)
+		}
+		inBit++
+	}
+}
+
+func TestGetPieceCid(t *testing.T) {
+	for _, test := range commPTests {
+		data := make([]byte, test.size)
+		if !test.zero {
+			for i := range data {
+				data[i] = byte(i % 251)
+			}
+		}
+
+		carFilePath := filepath.Join(t.TempDir(), "test.car")
+		err := os.WriteFile(carFilePath, data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pieceCid, pieceSize, err := GetPieceCid(carFilePath)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err.Error())
+		}
+
+		commP, err := hex.DecodeString(test.commP)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := commcid.PieceCommitmentV1ToCID(commP)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if *pieceCid != expected.String() || pieceSize != test.pieceSize {
+			t.Errorf("%s: piece cid is %s of size %d, expected %s of size %d", test.name, *pieceCid, pieceSize, expected.String(), test.pieceSize)
+		}
+	}
+
+	_, _, err := GetPieceCid(filepath.Join(t.TempDir(), "empty.car"))
+	if err == nil {
+		t.Errorf("piece cid of a missing car file should fail")
+	}
+}
+
+func getInclusionProofData(carBytes []byte, inclusionProof *InclusionProof) *bytes.Reader {
+	dataEnd := inclusionProof.DataOffset + inclusionProof.DataLength
+	if dataEnd > int64(len(carBytes)) {
+		dataEnd = int64(len(carBytes))
+	}
+
+	return bytes.NewReader(carBytes[inclusionProof.DataOffset:dataEnd])
+}
+
+func TestGenerateInclusionProofs(t *testing.T) {
+	srcDir, _, rootCids := createTestSrcDir(t)
+
+	for _, carVersion := range []int{CAR_VERSION_1, CAR_VERSION_2} {
+		fileDesc, err := CreateCarFile(srcDir, t.TempDir(), carVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dagPositions, err := GetDagPositions(fileDesc.CarFilePath, []string{rootCids["small.txt"], rootCids["large.bin"]})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		inclusionProofs, err := GenerateInclusionProofs(fileDesc.CarFilePath, fileDesc.PieceCid, dagPositions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(inclusionProofs) != len(dagPositions) {
+			t.Fatalf("car v%d: %d inclusion proofs, expected %d", carVersion, len(inclusionProofs), len(dagPositions))
+		}
+
+		carBytes, err := ioutil.ReadFile(fileDesc.CarFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, inclusionProof := range inclusionProofs {
+			if inclusionProof.CarOffset != dagPositions[i].Offset || inclusionProof.CarLength != dagPositions[i].Length ||
+				inclusionProof.DataOffset > inclusionProof.CarOffset ||
+				inclusionProof.DataOffset+inclusionProof.DataLength < inclusionProof.CarOffset+inclusionProof.CarLength {
+				t.Errorf("car v%d: inclusion proof %+v does not cover dag position %+v", carVersion, inclusionProof, dagPositions[i])
+			}
+
+			err = VerifyInclusionProof(getInclusionProofData(carBytes, inclusionProof), inclusionProof)
+			if err != nil {
+				t.Errorf("car v%d: inclusion proof of %s does not verify against piece:%s, %v", carVersion, inclusionProof.RootCid, inclusionProof.PieceCid, err)
+			}
+
+			// a changed byte of the dag breaks the proof
+			tampered := append([]byte{}, carBytes...)
+			tampered[inclusionProof.CarOffset+inclusionProof.CarLength/2] ^= 0x01
+			err = VerifyInclusionProof(getInclusionProofData(tampered, inclusionProof), inclusionProof)
+			if err == nil {
+				t.Errorf("car v%d: inclusion proof of %s verified with a changed byte", carVersion, inclusionProof.RootCid)
+			}
+
+			// so does a changed proof node
+			tamperedProof := *inclusionProof
+			tamperedProof.ProofNodes = append([]*ProofNode{}, inclusionProof.ProofNodes...)
+			proofNode := *tamperedProof.ProofNodes[0]
+			proofNode.Hash = hex.EncodeToString(make([]byte, PIECE_NODE_SIZE))
+			tamperedProof.ProofNodes[0] = &proofNode
+			err = VerifyInclusionProof(getInclusionProofData(carBytes, inclusionProof), &tamperedProof)
+			if err == nil {
+				t.Errorf("car v%d: inclusion proof of %s verified with a changed proof node", carVersion, inclusionProof.RootCid)
+			}
+		}
+
+		// the proven bytes of one file do not prove the other file is in the piece
+		otherRootProof := *inclusionProofs[1]
+		otherRootProof.RootCid = inclusionProofs[0].RootCid
+		err = VerifyInclusionProof(getInclusionProofData(carBytes, &otherRootProof), &otherRootProof)
+		if err == nil {
+			t.Errorf("car v%d: inclusion proof of %s verified for root %s", carVersion, inclusionProofs[1].RootCid, otherRootProof.RootCid)
+		}
+
+		// nor are they in another piece
+		otherPieceProof := *inclusionProofs[0]
+		otherPieceProof.PieceCid = commPTestPieceCid(t)
+		err = VerifyInclusionProof(getInclusionProofData(carBytes, &otherPieceProof), &otherPieceProof)
+		if err == nil {
+			t.Errorf("car v%d: inclusion proof of %s verified for piece %s", carVersion, otherPieceProof.RootCid, otherPieceProof.PieceCid)
+		}
+	}
+
+	_, err := GenerateInclusionProofs(filepath.Join(srcDir, "small.txt"), commPTestPieceCid(t), nil)
+	if err == nil {
+		t.Errorf("inclusion proofs of a piece cid not matching the car file should fail")
+	}
+}
+
+func commPTestPieceCid(t *testing.T) string {
+	commP, err := hex.DecodeString(commPTests[0].commP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pieceCid, err := commcid.PieceCommitmentV1ToCID(commP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pieceCid.String()
+}
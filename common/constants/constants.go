@@ -7,6 +7,7 @@ const (
 	URL_EVENT_PREFIX   = "events"
 	URL_BILLING_PREFIX = "billing"
 	URL_STORAGE_PREFIX = "storage"
+	URL_ADMIN_PREFIX   = "admin"
 
 	HTTP_STATUS_SUCCESS = "success"
 	HTTP_STATUS_FAIL    = "fail"
@@ -42,9 +43,69 @@ const (
 	PROCESS_STATUS_UNLOCK_REFUNDFAILED = "UnlockRefundFailed"
 	PROCESS_STATUS_EXPIRE_REFUNDING    = "Refunding"
 	PROCESS_STATUS_EXPIRE_REFUNDED     = "Refunded"
+	PROCESS_STATUS_EXPIRE_REFUNDFAILED = "RefundFailed"
 
-	DEAL_STATUS_ACTIVE = "StorageDealActive"
-	DEAL_STATUS_ERROR  = "StorageDealError"
+	EXPIRE_REFUND_BATCH_SIZE_DEFAULT = 20
+
+	DEAL_STATUS_ACTIVE  = "StorageDealActive"
+	DEAL_STATUS_ERROR   = "StorageDealError"
+	DEAL_STATUS_FAILING = "StorageDealFailing"
+	DEAL_STATUS_SLASHED = "StorageDealSlashed"
+
+	MINER_SELECTION_MODE_AUTO_BID   = "auto_bid"
+	MINER_SELECTION_MODE_MANUAL_BID = "manual_bid"
+
+	DEAL_ENGINE_SWAN  = "swan"
+	DEAL_ENGINE_LOTUS = "lotus"
+
+	CAR_BUILDER_LOTUS = "lotus"
+	CAR_BUILDER_LOCAL = "local"
+
+	CAR_GROUP_STATUS_PLANNED = "Planned"
+	CAR_GROUP_STATUS_CLAIMED = "Claimed"
+	CAR_GROUP_STATUS_CREATED = "Created"
+	CAR_GROUP_STATUS_FAILED  = "Failed"
+
+	CAR_GROUP_ATTEMPTS_MAX            = 3
+	CAR_WORKER_NUMBER_DEFAULT         = 1
+	CAR_QUEUE_SIZE_DEFAULT            = 10
+	CAR_CLAIM_TIMEOUT_MINUTES_DEFAULT = 120
+	CAR_WORKER_IDLE_SECONDS           = 60
+
+	DEAL_SEND_WINDOW_DAYS_DEFAULT = 3
+
+	QUOTE_TTL_MINUTES_DEFAULT          = 30
+	QUOTE_LOCK_AMOUNT_MULTIPLE_DEFAULT = 1.5
+
+	LOCK_PAYMENT_QUOTE_STATUS_ACCEPTED   = "Accepted"
+	LOCK_PAYMENT_QUOTE_STATUS_NOT_QUOTED = "NotQuoted"
+	LOCK_PAYMENT_QUOTE_STATUS_REJECTED   = "Rejected"
+
+	CAR_STAGE_COPY   = "copy"
+	CAR_STAGE_CAR    = "car"
+	CAR_STAGE_UPLOAD = "upload_and_create_task"
+	CAR_STAGE_SAVE   = "save"
+
+	DEAL_PROPOSAL_STATUS_PROPOSED = "Proposed"
+	DEAL_PROPOSAL_STATUS_REJECTED = "Rejected"
+	DEAL_PROPOSAL_STATUS_FAILED   = "Failed"
+
+	RETRIEVAL_STATUS_QUEUED     = "Queued"
+	RETRIEVAL_STATUS_RETRIEVING = "Retrieving"
+	RETRIEVAL_STATUS_SUCCEEDED  = "Succeeded"
+	RETRIEVAL_STATUS_FAILED     = "Failed"
+	RETRIEVAL_POLL_SECONDS      = 5
+
+	LOCAL_FILE_TYPE_SOURCE   = "Source"
+	LOCAL_FILE_TYPE_CAR      = "Car"
+	LOCAL_FILE_TYPE_RENEW    = "Renew"
+	LOCAL_FILE_TYPE_RETRIEVE = "Retrieve"
+
+	LOCAL_FILE_STATUS_PRESENT = "Present"
+	LOCAL_FILE_STATUS_DELETED = "Deleted"
+
+	DISK_MIN_FREE_GB_DEFAULT          = 10
+	DISK_RETRIEVE_CACHE_HOURS_DEFAULT = 24
 
 	IPFS_URL_PREFIX_BEFORE_HASH = "/ipfs/"
 	IPFS_File_PINNED_STATUS     = "Pinned"
@@ -60,9 +121,112 @@ const (
 	SOURCE_FILE_STATUS_PAID         = "Paid"
 	SOURCE_FILE_STATUS_TASK_CREATED = "TaskCreated"
 
+	FILE_STATUS_UPLOADED   = "Uploaded"
+	FILE_STATUS_PAID       = "Paid"
+	FILE_STATUS_AGGREGATED = "Aggregated"
+	FILE_STATUS_DEAL_SENT  = "DealSent"
+	FILE_STATUS_ACTIVE     = "Active"
+	FILE_STATUS_UNLOCKED   = "Unlocked"
+	FILE_STATUS_REFUNDING  = "Refunding"
+	FILE_STATUS_REFUNDED   = "Refunded"
+	FILE_STATUS_EXPIRED    = "Expired"
+
+	WEBHOOK_EVENT_PAYMENT_LOCKED   = "payment.locked"
+	WEBHOOK_EVENT_CAR_CREATED      = "car.created"
+	WEBHOOK_EVENT_DEAL_SENT        = "deal.sent"
+	WEBHOOK_EVENT_DEAL_ACTIVE      = "deal.active"
+	WEBHOOK_EVENT_PAYMENT_UNLOCKED = "payment.unlocked"
+	WEBHOOK_EVENT_REFUND_ISSUED    = "refund.issued"
+	WEBHOOK_EVENT_PAYMENT_EXPIRED  = "payment.expired"
+
+	WEBHOOK_STATUS_ACTIVE  = "Active"
+	WEBHOOK_STATUS_DELETED = "Deleted"
+
+	API_KEY_STATUS_ACTIVE  = "Active"
+	API_KEY_STATUS_DELETED = "Deleted"
+	API_KEY_HEADER         = "X-MCS-Api-Key"
+
+	WEBHOOK_DELIVERY_STATUS_PENDING   = "Pending"
+	WEBHOOK_DELIVERY_STATUS_DELIVERED = "Delivered"
+	WEBHOOK_DELIVERY_STATUS_FAILED    = "Failed"
+
+	WEBHOOK_DELIVERY_ATTEMPTS_MAX   = 8
+	WEBHOOK_RETRY_BASE_SECONDS      = 30
+	WEBHOOK_TIMEOUT_SECONDS         = 10
+	WEBHOOK_DELIVERY_BATCH_SIZE     = 100
+	WEBHOOK_HEADER_EVENT            = "X-MCS-Event"
+	WEBHOOK_HEADER_DELIVERY         = "X-MCS-Delivery"
+	WEBHOOK_HEADER_TIMESTAMP        = "X-MCS-Timestamp"
+	WEBHOOK_HEADER_SIGNATURE        = "X-MCS-Signature"
+	WALLET_SIGNATURE_EXPIRE_SECONDS = 600
+
+	EVENT_SUBSCRIBER_BUFFER_SIZE = 100
+	EVENT_STREAM_REPLAY_LIMIT    = 1000
+	EVENT_STREAM_PING_SECONDS    = 15
+	EVENT_STREAM_NAME            = "file_status"
+	EVENT_STREAM_TOKEN_SECONDS   = 60
+
+	ALERT_METRIC_UNLOCK_FAILED      = "unlock_failed"
+	ALERT_METRIC_DEAL_SENT_FAILED   = "deal_sent_failed"
+	ALERT_METRIC_REFUND_FAILED      = "refund_failed"
+	ALERT_METRIC_SIGNER_BALANCE     = "signer_balance"
+	ALERT_METRIC_LEDGER_DISCREPANCY = "ledger_discrepancy"
+	ALERT_METRIC_LOCK_WITHOUT_QUOTE = "lock_without_quote"
+
+	ALERT_COMPARISON_ABOVE = "above"
+	ALERT_COMPARISON_BELOW = "below"
+
+	ALERT_STATUS_FIRING   = "Firing"
+	ALERT_STATUS_RESOLVED = "Resolved"
+
+	ALERT_WINDOW_MINUTES_DEFAULT = 60
+	ALERT_TIMEOUT_SECONDS        = 10
+
+	DAO_DEAL_VERIFICATION_STATUS_VERIFIED   = "Verified"
+	DAO_DEAL_VERIFICATION_STATUS_MISMATCHED = "Mismatched"
+	DAO_DEAL_VERIFICATION_STATUS_FAILED     = "Failed"
+
+	DAO_ROLE_LOG_BLOCK_RANGE = 3000
+	DAO_STATS_DAYS_DEFAULT   = 30
+
+	DAO_PROPOSAL_ACTION_SET_DAO_USERS    = "set_dao_users"
+	DAO_PROPOSAL_ACTION_UPDATE_THRESHOLD = "update_threshold"
+
+	DAO_PROPOSAL_STATUS_PENDING   = "Pending"
+	DAO_PROPOSAL_STATUS_APPROVED  = "Approved"
+	DAO_PROPOSAL_STATUS_REJECTED  = "Rejected"
+	DAO_PROPOSAL_STATUS_SUBMITTED = "Submitted"
+	DAO_PROPOSAL_STATUS_FAILED    = "Failed"
+
+	TX_JOB_UNLOCK = "unlock"
+	TX_JOB_REFUND = "refund"
+
 	OFFLINE_DEAL_UNLOCK_STATUS_NOT_UNLOCKED  = "NotUnlocked"
 	OFFLINE_DEAL_UNLOCK_STATUS_UNLOCKED      = "Unlocked"
 	OFFLINE_DEAL_UNLOCK_STATUS_UNLOCK_FAILED = "UnlockFailed"
+	OFFLINE_DEAL_UNLOCK_STATUS_SETTLED       = "Settled"
+
+	UNLOCK_CHECK_STATUS_CONSISTENT   = "Consistent"
+	UNLOCK_CHECK_STATUS_INCONSISTENT = "Inconsistent"
+
+	LEDGER_DISCREPANCY_STATUS_OPEN      = "Open"
+	LEDGER_DISCREPANCY_STATUS_RESOLVED  = "Resolved"
+	LEDGER_DISCREPANCY_STATUS_APPLIED   = "Applied"
+	LEDGER_DISCREPANCY_STATUS_DISMISSED = "Dismissed"
+
+	LEDGER_FIELD_LOCK_PAYMENT       = "event_lock_payment"
+	LEDGER_FIELD_LOCKED_FEE         = "ledger_balance.locked_fee"
+	LEDGER_FIELD_OWNER              = "event_lock_payment.address_from"
+	LEDGER_FIELD_RECIPIENT          = "event_lock_payment.address_to"
+	LEDGER_FIELD_DEADLINE           = "event_lock_payment.deadline"
+	LEDGER_FIELD_TOKEN_ADDRESS      = "event_lock_payment.token_address"
+	LEDGER_FIELD_SOURCE_FILE_STATUS = "source_file.status"
+	LEDGER_VALUE_EXISTS             = "exists"
+	LEDGER_VALUE_NOT_EXISTS         = "not exists"
+
+	DEAL_RENEWAL_STATUS_TASK_CREATED = "TaskCreated"
+	DEAL_RENEWAL_STATUS_FAILED       = "Failed"
+	DEAL_RENEWAL_ATTEMPTS_MAX        = 3
 
 	SIGNATURE_DEFAULT_VALUE = "0" //init value,no unlock operation has been performed
 	SIGNATURE_SUCCESS_VALUE = "1" //init value,no unlock operation has been performed
@@ -79,4 +243,19 @@ const (
 	EPOCH_PER_DAY = 24 * 60 * 2
 
 	PRIVATE_KEY_ON_POLYGON = "privateKeyOnPolygon"
+	CAR_URL_SECRET         = "carUrlSecret"
+	ADMIN_TOKEN            = "adminToken"
+	ADMIN_OPERATOR_TOKENS  = "adminOperatorTokens"
+	ALERT_SMTP_PASSWORD    = "alertSmtpPassword"
+	DAO_SIGNER_PRIVATE_KEY = "daoSignerPrivateKey"
+	QUOTE_SECRET           = "quoteSecret"
+	EVENT_STREAM_SECRET    = "eventStreamSecret"
+
+	RUN_MODE_DAO_SIGNER = "dao-signer"
+
+	CAR_TRANSFER_STATUS_TRANSFERRING = "Transferring"
+	CAR_TRANSFER_STATUS_COMPLETED    = "Completed"
+
+	DEAL_AUDIT_FORMAT_JSON = "json"
+	DEAL_AUDIT_FORMAT_PDF  = "pdf"
 )
@@ -31,6 +31,33 @@ const (
 
 	//type transfer error 007
 	TYPE_TRANSFER_ERROR_CODE = "500007001"
+
+	//car file download error 008
+	CAR_URL_SIGNATURE_ERROR_CODE  = "500008001"
+	CAR_FILE_NOT_FOUND_ERROR_CODE = "500008002"
+	RETRIEVE_FILE_ERROR_CODE      = "500008003"
+
+	//admin error 009
+	ADMIN_TOKEN_ERROR_CODE        = "500009001"
+	GET_DISK_USAGE_ERROR_CODE     = "500009002"
+	ALERT_NOTIFY_ERROR_CODE       = "500009003"
+	GET_WALLET_STATUS_ERROR_CODE  = "500009004"
+	GET_DAO_MEMBERS_ERROR_CODE    = "500009005"
+	DAO_PROPOSAL_ERROR_CODE       = "500009006"
+	LEDGER_DISCREPANCY_ERROR_CODE = "500009007"
+	ADMIN_OPERATOR_ERROR_CODE     = "500009008"
+
+	//wallet and webhook error 010
+	WALLET_SIGNATURE_ERROR_CODE  = "500010001"
+	WEBHOOK_URL_ERROR_CODE       = "500010002"
+	WEBHOOK_NOT_FOUND_ERROR_CODE = "500010003"
+	API_KEY_ERROR_CODE           = "500010004"
+
+	//billing error 011
+	DEAL_NOT_FOUND_ERROR_CODE     = "500011001"
+	DEAL_AUDIT_ERROR_CODE         = "500011002"
+	QUOTE_ERROR_CODE              = "500011003"
+	LOCK_PAYMENT_QUOTE_ERROR_CODE = "500011004"
 )
 
 var errorMap map[string]string
@@ -56,6 +83,25 @@ func init() {
 		GET_HOME_DIR_ERROR_CODE:                           "Getting home dir occurred error",
 		CREATE_DIR_ERROR_CODE:                             "Creating dir occurred error",
 		TYPE_TRANSFER_ERROR_CODE:                          "type transfer occurred error",
+		CAR_URL_SIGNATURE_ERROR_CODE:                      "Car file url is expired or its signature is invalid",
+		CAR_FILE_NOT_FOUND_ERROR_CODE:                     "Car file not found",
+		RETRIEVE_FILE_ERROR_CODE:                          "Retrieving file from filecoin occurred error",
+		ADMIN_TOKEN_ERROR_CODE:                            "Admin token is missing or invalid",
+		GET_DISK_USAGE_ERROR_CODE:                         "Getting disk usage occurred error",
+		ALERT_NOTIFY_ERROR_CODE:                           "Sending alert notification occurred error",
+		GET_WALLET_STATUS_ERROR_CODE:                      "Getting wallet balances occurred error",
+		GET_DAO_MEMBERS_ERROR_CODE:                        "Getting dao members from contract occurred error",
+		DAO_PROPOSAL_ERROR_CODE:                           "Dao proposal is invalid or can not be approved",
+		LEDGER_DISCREPANCY_ERROR_CODE:                     "Ledger discrepancy not found or can not be applied",
+		ADMIN_OPERATOR_ERROR_CODE:                         "An operator token is required",
+		WALLET_SIGNATURE_ERROR_CODE:                       "Wallet signature is expired or invalid",
+		WEBHOOK_URL_ERROR_CODE:                            "Webhook url should be a valid http or https url",
+		WEBHOOK_NOT_FOUND_ERROR_CODE:                      "Webhook or its delivery not found",
+		API_KEY_ERROR_CODE:                                "Api key is invalid or not found",
+		DEAL_NOT_FOUND_ERROR_CODE:                         "Deal not found",
+		DEAL_AUDIT_ERROR_CODE:                             "Building deal audit report occurred error",
+		QUOTE_ERROR_CODE:                                  "Creating quote occurred error",
+		LOCK_PAYMENT_QUOTE_ERROR_CODE:                     "Locked payment does not cover a valid quote",
 	}
 }
 
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/config"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	libutils "github.com/filswan/go-swan-lib/utils"
+)
+
+// IsCarServerEnabled returns false when url_prefix or carUrlSecret is not set, car urls can be forged without the secret
+func IsCarServerEnabled() bool {
+	return strings.Trim(config.GetConfig().CarServer.UrlPrefix, " ") != "" && os.Getenv(constants.CAR_URL_SECRET) != ""
+}
+
+// CheckCarServerConfig returns an error when url_prefix is set without carUrlSecret
+func CheckCarServerConfig() error {
+	if strings.Trim(config.GetConfig().CarServer.UrlPrefix, " ") != "" && os.Getenv(constants.CAR_URL_SECRET) == "" {
+		err := fmt.Errorf("%s is required in .env when [car_server].url_prefix is set", constants.CAR_URL_SECRET)
+		return err
+	}
+
+	return nil
+}
+
+func GetCarUrlSignature(pieceCid, minerFid string, expireAt int64) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv(constants.CAR_URL_SECRET)))
+	mac.Write([]byte(pieceCid + ":" + minerFid + ":" + strconv.FormatInt(expireAt, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// minerFid can be empty when the miner is not known yet, such as for swan auto-bid tasks
+func GetCarUrl(pieceCid, minerFid string) string {
+	expireAt := time.Now().Add(time.Duration(config.GetConfig().CarServer.UrlExpireHours) * time.Hour).Unix()
+
+	params := url.Values{}
+	params.Set("miner_fid", minerFid)
+	params.Set("expire_at", strconv.FormatInt(expireAt, 10))
+	params.Set("signature", GetCarUrlSignature(pieceCid, minerFid, expireAt))
+
+	carUrl := libutils.UrlJoin(config.GetConfig().CarServer.UrlPrefix, "api/v1", constants.URL_STORAGE_PREFIX, "car", pieceCid)
+	return carUrl + "?" + params.Encode()
+}
+
+func VerifyCarUrlSignature(pieceCid, minerFid string, expireAt int64, signature string) error {
+	if !IsCarServerEnabled() {
+		err := fmt.Errorf("car server is not enabled")
+		return err
+	}
+
+	if time.Now().Unix() > expireAt {
+		err := fmt.Errorf("url for piece_cid:%s expired at:%d", pieceCid, expireAt)
+		return err
+	}
+
+	expectedSignature := GetCarUrlSignature(pieceCid, minerFid, expireAt)
+	if !hmac.Equal([]byte(expectedSignature), []byte(signature)) {
+		err := fmt.Errorf("invalid signature for piece_cid:%s", pieceCid)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+var (
+	eventStreamSecret     []byte
+	eventStreamSecretOnce sync.Once
+)
+
+// getEventStreamSecret returns eventStreamSecret of .env, or a random secret of this process when it is not set,
+// then tokens are only accepted by the mcs instance issuing them
+func getEventStreamSecret() []byte {
+	eventStreamSecretOnce.Do(func() {
+		secret := os.Getenv(constants.EVENT_STREAM_SECRET)
+		if secret != "" {
+			eventStreamSecret = []byte(secret)
+			return
+		}
+
+		logs.GetLogger().Warn(constants.EVENT_STREAM_SECRET, " is not set in .env, event stream tokens are only valid on this instance")
+		eventStreamSecret = make([]byte, 32)
+		_, err := rand.Read(eventStreamSecret)
+		if err != nil {
+			logs.GetLogger().Fatal(err)
+		}
+	})
+
+	return eventStreamSecret
+}
+
+func getEventStreamTokenSignature(walletAddress string, expireAt int64) string {
+	mac := hmac.New(sha256.New, getEventStreamSecret())
+	mac.Write([]byte("event-stream:" + walletAddress + ":" + strconv.FormatInt(expireAt, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetEventStreamToken returns a token to open the event stream of the wallet within EVENT_STREAM_TOKEN_SECONDS,
+// it is put in the query string instead of the wallet signature, since EventSource cannot set headers
+func GetEventStreamToken(walletAddress string) (string, int64) {
+	walletAddress = strings.ToLower(walletAddress)
+	expireAt := time.Now().Unix() + constants.EVENT_STREAM_TOKEN_SECONDS
+	token := walletAddress + "." + strconv.FormatInt(expireAt, 10) + "." + getEventStreamTokenSignature(walletAddress, expireAt)
+	return token, expireAt
+}
+
+// VerifyEventStreamToken returns the wallet address of the token when it is signed by mcs and not expired
+func VerifyEventStreamToken(token string) (string, error) {
+	fields := strings.Split(token, ".")
+	if len(fields) != 3 {
+		err := fmt.Errorf("invalid event stream token")
+		return "", err
+	}
+
+	walletAddress := fields[0]
+	expireAt, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		err := fmt.Errorf("invalid event stream token")
+		return "", err
+	}
+
+	if time.Now().Unix() > expireAt {
+		err := fmt.Errorf("event stream token expired at:%d", expireAt)
+		return "", err
+	}
+
+	if !hmac.Equal([]byte(getEventStreamTokenSignature(walletAddress, expireAt)), []byte(fields[2])) {
+		err := fmt.Errorf("invalid event stream token")
+		return "", err
+	}
+
+	return walletAddress, nil
+}
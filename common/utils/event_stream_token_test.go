@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"multi-chain-storage/common/constants"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyEventStreamToken(t *testing.T) {
+	os.Setenv(constants.EVENT_STREAM_SECRET, "event-stream-secret")
+	defer os.Unsetenv(constants.EVENT_STREAM_SECRET)
+
+	walletAddress := "0xAbC0000000000000000000000000000000000001"
+	token, expireAt := GetEventStreamToken(walletAddress)
+
+	tokenWalletAddress, err := VerifyEventStreamToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tokenWalletAddress != strings.ToLower(walletAddress) {
+		t.Errorf("token of wallet:%s verified as wallet:%s", walletAddress, tokenWalletAddress)
+	}
+
+	fields := strings.Split(token, ".")
+	expiredAt := time.Now().Unix() - 1
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"another wallet", "0xabc0000000000000000000000000000000000002." + fields[1] + "." + fields[2]},
+		{"extended", fields[0] + "." + strconv.FormatInt(expireAt+60, 10) + "." + fields[2]},
+		{"expired", fields[0] + "." + strconv.FormatInt(expiredAt, 10) + "." + getEventStreamTokenSignature(fields[0], expiredAt)},
+		{"wallet signature", fields[0] + "." + fields[1]},
+	}
+
+	for _, test := range tests {
+		_, err := VerifyEventStreamToken(test.token)
+		if err == nil {
+			t.Errorf("%s: token accepted", test.name)
+		}
+	}
+}
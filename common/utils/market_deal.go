@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"multi-chain-storage/config"
+	"strings"
+
+	"github.com/filswan/go-swan-lib/client/lotus"
+	"github.com/filswan/go-swan-lib/client/web"
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+const (
+	LOTUS_STATE_MARKET_STORAGE_DEAL = "Filecoin.StateMarketStorageDeal"
+	LOTUS_STATE_LOOKUP_ID           = "Filecoin.StateLookupID"
+)
+
+// ErrMarketDealNotFound is returned when the deal is not in the market state, such as a deal never activated after its start epoch
+var ErrMarketDealNotFound = errors.New("market deal not found")
+
+type MarketDeal struct {
+	Proposal struct {
+		PieceCID             lotus.Cid `json:"PieceCID"`
+		VerifiedDeal         bool      `json:"VerifiedDeal"`
+		Client               string    `json:"Client"`
+		Provider             string    `json:"Provider"`
+		StartEpoch           int64     `json:"StartEpoch"`
+		EndEpoch             int64     `json:"EndEpoch"`
+		StoragePricePerEpoch string    `json:"StoragePricePerEpoch"`
+	} `json:"Proposal"`
+	State struct {
+		SectorStartEpoch int64 `json:"SectorStartEpoch"`
+		LastUpdatedEpoch int64 `json:"LastUpdatedEpoch"`
+		SlashEpoch       int64 `json:"SlashEpoch"`
+	} `json:"State"`
+}
+
+type marketDealResult struct {
+	lotus.LotusJsonRpcResult
+	Result *MarketDeal `json:"result"`
+}
+
+type lookupIdResult struct {
+	lotus.LotusJsonRpcResult
+	Result string `json:"result"`
+}
+
+func callLotus(method string, params []interface{}, result interface{}) error {
+	jsonRpcParams := lotus.LotusJsonRpcParams{
+		JsonRpc: lotus.LOTUS_JSON_RPC_VERSION,
+		Method:  method,
+		Params:  params,
+		Id:      lotus.LOTUS_JSON_RPC_ID,
+	}
+
+	response, err := web.HttpGet(config.GetConfig().Lotus.ClientApiUrl, config.GetConfig().Lotus.ClientAccessToken, jsonRpcParams)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = json.Unmarshal(response, result)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func GetMarketDeal(dealId int64) (*MarketDeal, error) {
+	var params []interface{}
+	params = append(params, dealId)
+	params = append(params, nil)
+
+	deal := &marketDealResult{}
+	err := callLotus(LOTUS_STATE_MARKET_STORAGE_DEAL, params, deal)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if deal.Error != nil && strings.Contains(deal.Error.Message, "not found") {
+		err := fmt.Errorf("deal:%d %w, message:%s", dealId, ErrMarketDealNotFound, deal.Error.Message)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if deal.Error != nil {
+		err := fmt.Errorf("get deal:%d from chain failed, code:%d, message:%s", dealId, deal.Error.Code, deal.Error.Message)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if deal.Result == nil {
+		err := fmt.Errorf("deal:%d %w on chain", dealId, ErrMarketDealNotFound)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return deal.Result, nil
+}
+
+// deals on chain reference the client by its id address, such as f01234
+func GetIdAddress(address string) (string, error) {
+	var params []interface{}
+	params = append(params, address)
+	params = append(params, nil)
+
+	id := &lookupIdResult{}
+	err := callLotus(LOTUS_STATE_LOOKUP_ID, params, id)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	if id.Error != nil {
+		err := fmt.Errorf("look up id of %s failed, code:%d, message:%s", address, id.Error.Code, id.Error.Message)
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	return id.Result, nil
+}
+
+func GetCurrentEpoch() (int64, error) {
+	lotusClient, err := lotus.LotusGetClient(config.GetConfig().Lotus.ClientApiUrl, config.GetConfig().Lotus.ClientAccessToken)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	currentEpoch, err := lotusClient.LotusGetCurrentEpoch()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	return *currentEpoch, nil
+}
+
+// the network prefix f or t is ignored, so that the same wallet matches on mainnet and testnets
+func IsSameFilecoinAddress(address1, address2 string) bool {
+	address1 = strings.ToLower(strings.Trim(address1, " "))
+	address2 = strings.ToLower(strings.Trim(address2, " "))
+	if len(address1) < 2 || len(address2) < 2 {
+		return false
+	}
+
+	return address1[1:] == address2[1:]
+}
+
+// CheckMarketDeal returns why the deal on chain does not match what is expected, nil when it matches and is active
+func CheckMarketDeal(marketDeal *MarketDeal, pieceCid, clientId, minerFid string, currentEpoch int64) error {
+	proposal := marketDeal.Proposal
+	if proposal.PieceCID.Cid != pieceCid {
+		err := fmt.Errorf("piece cid on chain is %s, not %s", proposal.PieceCID.Cid, pieceCid)
+		return err
+	}
+
+	if !IsSameFilecoinAddress(proposal.Client, clientId) {
+		err := fmt.Errorf("client on chain is %s, not %s", proposal.Client, clientId)
+		return err
+	}
+
+	if !IsSameFilecoinAddress(proposal.Provider, minerFid) {
+		err := fmt.Errorf("provider on chain is %s, not %s", proposal.Provider, minerFid)
+		return err
+	}
+
+	state := marketDeal.State
+	if state.SectorStartEpoch <= 0 {
+		err := fmt.Errorf("deal is not active on chain")
+		return err
+	}
+
+	if state.SlashEpoch >= 0 {
+		err := fmt.Errorf("deal was slashed at epoch %d", state.SlashEpoch)
+		return err
+	}
+
+	if proposal.EndEpoch <= currentEpoch {
+		err := fmt.Errorf("deal expired at epoch %d", proposal.EndEpoch)
+		return err
+	}
+
+	return nil
+}
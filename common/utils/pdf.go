@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	PDF_PAGE_WIDTH      = 595
+	PDF_PAGE_HEIGHT     = 842
+	PDF_MARGIN          = 40
+	PDF_FONT_SIZE       = 8
+	PDF_LINE_HEIGHT     = 11
+	PDF_CHARS_PER_LINE  = 105
+	PDF_LINES_PER_PAGE  = (PDF_PAGE_HEIGHT - 2*PDF_MARGIN) / PDF_LINE_HEIGHT
+	PDF_TITLE_FONT_SIZE = 12
+)
+
+// CreateTextPdf renders the lines in a monospaced font on A4 pages, lines too long for a page are wrapped,
+// it only supports ascii, other characters are replaced by '?'
+func CreateTextPdf(title string, lines []string) []byte {
+	wrappedLines := []string{}
+	for _, line := range lines {
+		line = toPdfAscii(line)
+		for len(line) > PDF_CHARS_PER_LINE {
+			wrappedLines = append(wrappedLines, line[:PDF_CHARS_PER_LINE])
+			line = "  " + line[PDF_CHARS_PER_LINE:]
+		}
+		wrappedLines = append(wrappedLines, line)
+	}
+
+	pages := [][]string{}
+	linesPerPage := PDF_LINES_PER_PAGE - 2
+	for i := 0; i < len(wrappedLines) || i == 0; i = i + linesPerPage {
+		end := i + linesPerPage
+		if end > len(wrappedLines) {
+			end = len(wrappedLines)
+		}
+		pages = append(pages, wrappedLines[i:end])
+	}
+
+	// objects 1, 2, 3 are catalog, pages and font, then each page takes a page object and a content object
+	objects := []string{}
+	kids := []string{}
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+2*i))
+	}
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>")
+
+	for i, pageLines := range pages {
+		var content bytes.Buffer
+		top := PDF_PAGE_HEIGHT - PDF_MARGIN
+		content.WriteString(fmt.Sprintf("BT /F1 %d Tf %d %d Td (%s) Tj ET\n", PDF_TITLE_FONT_SIZE, PDF_MARGIN, top, escapePdfText(toPdfAscii(title))))
+		content.WriteString(fmt.Sprintf("BT /F1 %d Tf %d TL %d %d Td\n", PDF_FONT_SIZE, PDF_LINE_HEIGHT, PDF_MARGIN, top-2*PDF_LINE_HEIGHT))
+		for _, line := range pageLines {
+			content.WriteString(fmt.Sprintf("(%s) Tj T*\n", escapePdfText(line)))
+		}
+		content.WriteString(fmt.Sprintf("(page %d of %d) Tj\nET\n", i+1, len(pages)))
+
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", PDF_PAGE_WIDTH, PDF_PAGE_HEIGHT, 5+2*i))
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := []int{}
+	for i, object := range objects {
+		offsets = append(offsets, pdf.Len())
+		pdf.WriteString(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, object))
+	}
+
+	xrefOffset := pdf.Len()
+	pdf.WriteString(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(objects)+1))
+	for _, offset := range offsets {
+		pdf.WriteString(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+	pdf.WriteString(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset))
+
+	return pdf.Bytes()
+}
+
+func toPdfAscii(text string) string {
+	var ascii strings.Builder
+	for _, r := range text {
+		if r < 32 || r > 126 {
+			r = '?'
+		}
+		ascii.WriteRune(r)
+	}
+
+	return ascii.String()
+}
+
+func escapePdfText(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\\\")
+	text = strings.ReplaceAll(text, "(", "\\(")
+	text = strings.ReplaceAll(text, ")", "\\)")
+	return text
+}
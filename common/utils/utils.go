@@ -1,10 +1,14 @@
 package utils
 
 import (
+	"math/big"
+	"multi-chain-storage/common/constants"
 	"strconv"
 	"time"
 
 	"github.com/filswan/go-swan-lib/logs"
+	libutils "github.com/filswan/go-swan-lib/utils"
+	"github.com/shopspring/decimal"
 )
 
 // GetEpochInMillis get current timestamp
@@ -28,3 +32,14 @@ func GetOffsetByPagenumber(pageNumber, pageSize string) (int64, error) {
 	offset := (pageNumberInt - 1) * pageSizeInt
 	return offset, nil
 }
+
+// GetPaymentByMaxPrice returns the payment for a copy of the file stored at max price for the default duration,
+// max price * sector size in GiB * duration in epochs * FIL rate * 1e18, it is the price quoted and the max price of the deals the other way round
+func GetPaymentByMaxPrice(fileSize int64, maxPrice decimal.Decimal, rate *big.Int) decimal.Decimal {
+	_, sectorSize := libutils.CalculatePieceSize(fileSize)
+
+	durationEpoch := decimal.NewFromInt(constants.DURATION_DAYS_DEFAULT * constants.EPOCH_PER_DAY)
+	sectorSizeGB := decimal.NewFromFloat(sectorSize).Div(decimal.NewFromInt(constants.BYTES_1GB))
+
+	return maxPrice.Mul(sectorSizeGB).Mul(durationEpoch).Mul(decimal.NewFromBigInt(rate, 0)).Mul(decimal.NewFromFloat(constants.LOTUS_PRICE_MULTIPLE_1E18))
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// GetWalletSignatureMessage is the message a wallet signs by personal_sign to prove it owns the wallet address,
+// it includes the http method and path of the request, so that a signature can not be replayed against another api
+func GetWalletSignatureMessage(method, path, walletAddress string, timestamp int64) string {
+	return "multi-chain-storage:" + strings.ToUpper(method) + ":" + path + ":" + strings.ToLower(walletAddress) + ":" + strconv.FormatInt(timestamp, 10)
+}
+
+// VerifyWalletSignature checks the signature of the request is signed by walletAddress within WALLET_SIGNATURE_EXPIRE_SECONDS
+func VerifyWalletSignature(method, path, walletAddress string, timestamp int64, signature string) error {
+	now := time.Now().Unix()
+	if timestamp < now-constants.WALLET_SIGNATURE_EXPIRE_SECONDS || timestamp > now+constants.WALLET_SIGNATURE_EXPIRE_SECONDS {
+		err := fmt.Errorf("signature timestamp:%d expired", timestamp)
+		return err
+	}
+
+	signatureBytes, err := hexutil.Decode(signature)
+	if err != nil {
+		return err
+	}
+
+	if len(signatureBytes) != crypto.SignatureLength {
+		err := fmt.Errorf("signature length should be %d", crypto.SignatureLength)
+		return err
+	}
+
+	// wallets sign with v in 27 or 28
+	if signatureBytes[crypto.RecoveryIDOffset] >= 27 {
+		signatureBytes[crypto.RecoveryIDOffset] -= 27
+	}
+
+	message := GetWalletSignatureMessage(method, path, walletAddress, timestamp)
+	publicKey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), signatureBytes)
+	if err != nil {
+		return err
+	}
+
+	signer := crypto.PubkeyToAddress(*publicKey).Hex()
+	if !strings.EqualFold(signer, walletAddress) {
+		err := fmt.Errorf("signature is signed by:%s instead of wallet:%s", signer, walletAddress)
+		return err
+	}
+
+	return nil
+}
+
+// GetWebhookSignature signs the timestamp and body of a webhook delivery, receivers verify it with the webhook secret
+func GetWebhookSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
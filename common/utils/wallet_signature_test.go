@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestVerifyWalletSignature(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	walletAddress := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	timestamp := time.Now().Unix()
+
+	message := GetWalletSignatureMessage(http.MethodGet, "/api/v1/storage/webhooks", walletAddress, timestamp)
+	signatureBytes, err := crypto.Sign(accounts.TextHash([]byte(message)), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatureBytes[crypto.RecoveryIDOffset] += 27
+	signature := hexutil.Encode(signatureBytes)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		timestamp int64
+		valid     bool
+	}{
+		{"signed request", http.MethodGet, "/api/v1/storage/webhooks", timestamp, true},
+		{"another method", http.MethodPost, "/api/v1/storage/webhooks", timestamp, false},
+		{"another path", http.MethodDelete, "/api/v1/storage/webhooks/1", timestamp, false},
+		{"another timestamp", http.MethodGet, "/api/v1/storage/webhooks", timestamp + 1, false},
+	}
+
+	for _, test := range tests {
+		err := VerifyWalletSignature(test.method, test.path, walletAddress, test.timestamp, signature)
+		if test.valid && err != nil {
+			t.Errorf("%s: error %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// private, shared, loopback, link local, multicast and reserved ranges, webhooks should not reach mcs's own network
+var nonPublicIpNets = parseIpNets(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+	"192.0.0.0/24", "192.168.0.0/16", "198.18.0.0/15", "224.0.0.0/4", "240.0.0.0/4",
+	"::/128", "::1/128", "fc00::/7", "fe80::/10", "ff00::/8",
+)
+
+func parseIpNets(cidrs ...string) []*net.IPNet {
+	ipNets := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+
+	return ipNets
+}
+
+func IsPublicIp(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	for _, ipNet := range nonPublicIpNets {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckWebhookUrl returns an error unless the url is http or https and all the addresses of its host are public
+func CheckWebhookUrl(webhookUrl string) error {
+	parsedUrl, err := url.Parse(webhookUrl)
+	if err != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Hostname() == "" {
+		err := fmt.Errorf("webhook url:%s should be a valid http or https url", webhookUrl)
+		return err
+	}
+
+	ips, err := net.LookupIP(parsedUrl.Hostname())
+	if err != nil {
+		err := fmt.Errorf("webhook url:%s host cannot be resolved, %s", webhookUrl, err.Error())
+		return err
+	}
+
+	for _, ip := range ips {
+		if !IsPublicIp(ip) {
+			err := fmt.Errorf("webhook url:%s host resolves to address:%s, which is not public", webhookUrl, ip.String())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewWebhookHttpClient refuses to connect to addresses not public, checked when connecting,
+// so a host resolving to another address after the webhook is saved, or a redirect, cannot reach them either
+func NewWebhookHttpClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil || !IsPublicIp(ip) {
+				err := fmt.Errorf("webhook address:%s is not public", address)
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:       nil,
+		DialContext: dialer.DialContext,
+	}
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
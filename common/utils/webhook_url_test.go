@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIp(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"1.1.1.1", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"172.32.0.1", true},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"::", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+
+	for _, test := range tests {
+		public := IsPublicIp(net.ParseIP(test.ip))
+		if public != test.public {
+			t.Errorf("%s: public is %t, expected %t", test.ip, public, test.public)
+		}
+	}
+}
+
+func TestCheckWebhookUrl(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://8.8.8.8/hook", true},
+		{"http://[2606:4700:4700::1111]:8080/hook", true},
+		{"ftp://8.8.8.8/hook", false},
+		{"https:///hook", false},
+		{"http://127.0.0.1:8888/hook", false},
+		{"http://localhost/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://10.0.0.1/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://[fe80::1]/hook", false},
+	}
+
+	for _, test := range tests {
+		err := CheckWebhookUrl(test.url)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: error is %v, expected valid %t", test.url, err, test.valid)
+		}
+	}
+}
+
+func TestNewWebhookHttpClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the loopback address of the server is refused when connecting, whatever the url was checked against
+	_, err = NewWebhookHttpClient(5 * time.Second).Get(server.URL)
+	if err == nil {
+		t.Errorf("webhook client should not connect to loopback server:%s", server.URL)
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"multi-chain-storage/common/constants"
 	"os"
 	"path/filepath"
 	"time"
@@ -17,6 +18,7 @@ type Configuration struct {
 	SwanPlatformFilWallet string       `toml:"swan_platform_fil_wallet"`
 	FLinkUrl              string       `toml:"flink_url"`
 	FilecoinNetwork       string       `toml:"filecoin_network"`
+	DealEngine            string       `toml:"deal_engine"`
 	Polygon               polygon      `toml:"polygon"`
 	Database              database     `toml:"database"`
 	SwanApi               swanApi      `toml:"swan_api"`
@@ -24,6 +26,13 @@ type Configuration struct {
 	IpfsServer            ipfsServer   `toml:"ipfs_server"`
 	SwanTask              swanTask     `toml:"swan_task"`
 	ScheduleRule          ScheduleRule `toml:"schedule_rule"`
+	MinerPolicy           minerPolicy  `toml:"miner_policy"`
+	CarServer             carServer    `toml:"car_server"`
+	DiskPolicy            diskPolicy   `toml:"disk_policy"`
+	Alert                 alert        `toml:"alert"`
+	GasPolicy             gasPolicy    `toml:"gas_policy"`
+	DaoSigner             daoSigner    `toml:"dao_signer"`
+	Quote                 quote        `toml:"quote"`
 }
 
 type polygon struct {
@@ -36,6 +45,10 @@ type polygon struct {
 	GasLimit                  uint64        `toml:"gas_limit"`
 	UnlockIntervalMinute      time.Duration `toml:"unlock_interval_minute"`
 	IntervalDaoUnlockBlock    int64         `toml:"interval_dao_unlock_block"`
+	UnlockBatchSize           int           `toml:"unlock_batch_size"`
+	DaoContractStartBlock     uint64        `toml:"dao_contract_start_block"`
+	FilinkConsumerAddress     string        `toml:"filink_consumer_address"`
+	ExpireRefundBatchSize     int           `toml:"expire_refund_batch_size"`
 }
 
 type database struct {
@@ -48,22 +61,30 @@ type database struct {
 }
 
 type lotus struct {
-	ClientApiUrl      string `toml:"client_api_url"`
-	ClientAccessToken string `toml:"client_access_token"`
+	ClientApiUrl      string   `toml:"client_api_url"`
+	ClientAccessToken string   `toml:"client_access_token"`
+	MinerFids         []string `toml:"miner_fids"`
 }
 
 type swanTask struct {
-	DirDeal              string          `toml:"dir_deal"`
-	Description          string          `toml:"description"`
-	CuratedDataset       string          `toml:"curated_dataset"`
-	Tags                 string          `toml:"tags"`
-	MaxPrice             decimal.Decimal `toml:"max_price"`
-	ExpireDays           int             `toml:"expire_days"`
-	VerifiedDeal         bool            `toml:"verified_deal"`
-	FastRetrieval        bool            `toml:"fast_retrieval"`
-	StartEpochHours      int             `toml:"start_epoch_hours"`
-	MaxAutoBidCopyNumber int             `toml:"max_auto_bid_copy_number"`
-	MinFileSize          int64           `toml:"min_file_size"`
+	DirDeal                string          `toml:"dir_deal"`
+	Description            string          `toml:"description"`
+	CuratedDataset         string          `toml:"curated_dataset"`
+	Tags                   string          `toml:"tags"`
+	MaxPrice               decimal.Decimal `toml:"max_price"`
+	ExpireDays             int             `toml:"expire_days"`
+	VerifiedDeal           bool            `toml:"verified_deal"`
+	FastRetrieval          bool            `toml:"fast_retrieval"`
+	StartEpochHours        int             `toml:"start_epoch_hours"`
+	MaxAutoBidCopyNumber   int             `toml:"max_auto_bid_copy_number"`
+	MinFileSize            int64           `toml:"min_file_size"`
+	RenewDaysBeforeExpiry  int             `toml:"renew_days_before_expiry"`
+	CarBuilder             string          `toml:"car_builder"`
+	CarVersion             int             `toml:"car_version"`
+	CarWorkerNumber        int             `toml:"car_worker_number"`
+	CarQueueSize           int             `toml:"car_queue_size"`
+	CarClaimTimeoutMinutes int             `toml:"car_claim_timeout_minutes"`
+	DealSendWindowDays     int             `toml:"deal_send_window_days"`
 }
 
 type swanApi struct {
@@ -77,12 +98,77 @@ type ipfsServer struct {
 	UploadUrlPrefix   string `toml:"upload_url_prefix"`
 }
 
+type minerPolicy struct {
+	SelectionMode string   `toml:"selection_mode"`
+	AllowList     []string `toml:"allow_list"`
+	DenyList      []string `toml:"deny_list"`
+	MinScore      float64  `toml:"min_score"`
+}
+
+type carServer struct {
+	UrlPrefix      string `toml:"url_prefix"`
+	UrlExpireHours int    `toml:"url_expire_hours"`
+}
+
+type diskPolicy struct {
+	MinFreeGb                 int64 `toml:"min_free_gb"`
+	CarActiveDealsToDelete    int   `toml:"car_active_deals_to_delete"`
+	SourceActiveDealsToDelete int   `toml:"source_active_deals_to_delete"`
+	RetrieveCacheHours        int   `toml:"retrieve_cache_hours"`
+}
+
+type gasPolicy struct {
+	SignerMinBalance     decimal.Decimal `toml:"signer_min_balance"`
+	UnlockDailyGasBudget decimal.Decimal `toml:"unlock_daily_gas_budget"`
+}
+
+type quote struct {
+	TtlMinutes         int             `toml:"ttl_minutes"`
+	LockAmountMultiple decimal.Decimal `toml:"lock_amount_multiple"`
+	Required           bool            `toml:"required"`
+}
+
+type daoSigner struct {
+	McsApiUrl string `toml:"mcs_api_url"`
+	SignRule  string `toml:"sign_rule"`
+}
+
+type alert struct {
+	RepeatMinutes   int         `toml:"repeat_minutes"`
+	SmtpHost        string      `toml:"smtp_host"`
+	SmtpPort        int         `toml:"smtp_port"`
+	SmtpUsername    string      `toml:"smtp_username"`
+	SmtpFrom        string      `toml:"smtp_from"`
+	SmtpTo          []string    `toml:"smtp_to"`
+	SlackWebhookUrl string      `toml:"slack_webhook_url"`
+	HttpUrl         string      `toml:"http_url"`
+	Rules           []AlertRule `toml:"rules"`
+}
+
+type AlertRule struct {
+	Name          string  `toml:"name"`
+	Metric        string  `toml:"metric"`
+	Comparison    string  `toml:"comparison"`
+	Threshold     float64 `toml:"threshold"`
+	WindowMinutes int     `toml:"window_minutes"`
+}
+
 type ScheduleRule struct {
-	UnlockPaymentRule  string `toml:"unlock_payment_rule"`
-	CreateTaskRule     string `toml:"create_task_rule"`
-	SendDealRule       string `toml:"send_deal_rule"`
-	ScanDealStatusRule string `toml:"scan_deal_status_rule"`
-	RefundRule         string `toml:"refund_rule"`
+	UnlockPaymentRule     string `toml:"unlock_payment_rule"`
+	CreateTaskRule        string `toml:"create_task_rule"`
+	SendDealRule          string `toml:"send_deal_rule"`
+	ScanDealStatusRule    string `toml:"scan_deal_status_rule"`
+	RefundRule            string `toml:"refund_rule"`
+	RenewDealRule         string `toml:"renew_deal_rule"`
+	ScoreMinerRule        string `toml:"score_miner_rule"`
+	CleanDiskRule         string `toml:"clean_disk_rule"`
+	DeliverWebhookRule    string `toml:"deliver_webhook_rule"`
+	CheckAlertRule        string `toml:"check_alert_rule"`
+	VerifyDaoDealRule     string `toml:"verify_dao_deal_rule"`
+	IndexDaoSignatureRule string `toml:"index_dao_signature_rule"`
+	ReconcileLedgerRule   string `toml:"reconcile_ledger_rule"`
+	ExpireRefundRule      string `toml:"expire_refund_rule"`
+	RetrieveFileRule      string `toml:"retrieve_file_rule"`
 }
 
 var config *Configuration
@@ -111,7 +197,38 @@ func GetConfig() Configuration {
 	return *config
 }
 
+// SetConfig replaces the configuration read from config.toml, for tests running without it
+func SetConfig(configuration Configuration) {
+	config = &configuration
+}
+
+// requiredFieldsOfDaoSigner are the fields used by the dao signer, which runs without the database, swan api, ipfs server
+// and schedulers of mcs
+var requiredFieldsOfDaoSigner = [][]string{
+	{"swan_platform_fil_wallet"},
+	{"filecoin_network"},
+
+	{"lotus", "client_api_url"},
+	{"lotus", "client_access_token"},
+
+	{"polygon", "polygon_rpc_url"},
+	{"polygon", "dao_contract_address"},
+	{"polygon", "mcs_payment_receiver_address"},
+	{"polygon", "gas_limit"},
+
+	{"dao_signer", "mcs_api_url"},
+	{"dao_signer", "sign_rule"},
+}
+
+func isDaoSignerMode() bool {
+	return len(os.Args) > 1 && os.Args[1] == constants.RUN_MODE_DAO_SIGNER
+}
+
 func requiredFieldsAreGiven(metaData toml.MetaData) bool {
+	if isDaoSignerMode() {
+		return fieldsAreGiven(metaData, requiredFieldsOfDaoSigner)
+	}
+
 	requiredFields := [][]string{
 		{"port"},
 		{"release"},
@@ -147,12 +264,23 @@ func requiredFieldsAreGiven(metaData toml.MetaData) bool {
 		{"swan_task", "start_epoch_hours"},
 		{"swan_task", "max_auto_bid_copy_number"},
 		{"swan_task", "min_file_size"},
+		{"swan_task", "renew_days_before_expiry"},
 
 		{"schedule_rule", "unlock_payment_rule"},
 		{"schedule_rule", "create_task_rule"},
 		{"schedule_rule", "send_deal_rule"},
 		{"schedule_rule", "scan_deal_status_rule"},
 		{"schedule_rule", "refund_rule"},
+		{"schedule_rule", "renew_deal_rule"},
+		{"schedule_rule", "score_miner_rule"},
+		{"schedule_rule", "clean_disk_rule"},
+		{"schedule_rule", "deliver_webhook_rule"},
+		{"schedule_rule", "check_alert_rule"},
+		{"schedule_rule", "verify_dao_deal_rule"},
+		{"schedule_rule", "index_dao_signature_rule"},
+		{"schedule_rule", "reconcile_ledger_rule"},
+		{"schedule_rule", "expire_refund_rule"},
+		{"schedule_rule", "retrieve_file_rule"},
 
 		{"polygon", "polygon_rpc_url"},
 		{"polygon", "payment_contract_address"},
@@ -165,6 +293,10 @@ func requiredFieldsAreGiven(metaData toml.MetaData) bool {
 		{"polygon", "interval_dao_unlock_block"},
 	}
 
+	return fieldsAreGiven(metaData, requiredFields)
+}
+
+func fieldsAreGiven(metaData toml.MetaData, requiredFields [][]string) bool {
 	for _, v := range requiredFields {
 		if !metaData.IsDefined(v...) {
 			logs.GetLogger().Fatal("required fields ", v)
@@ -0,0 +1,385 @@
+package daosigner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"multi-chain-storage/car"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/on-chain/client"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/filswan/go-swan-lib/client/web"
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+)
+
+const URL_DAO_SIGNATURE_DEALS = "/storage/dao/signature/deals"
+
+type candidateDeal struct {
+	DealId                int64                 `json:"deal_id"`
+	PayloadCid            string                `json:"payload_cid"`
+	PieceCid              string                `json:"piece_cid"`
+	MinerFid              string                `json:"miner_fid"`
+	ClientWalletAddress   string                `json:"client_wallet_address"`
+	SourceFilePayloadCids []string              `json:"payload_cids_source"`
+	InclusionProofs       []*car.InclusionProof `json:"inclusion_proofs"`
+	CarFileUrl            string                `json:"car_file_url"`
+}
+
+type candidateDealsResponse struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    []*candidateDeal `json:"data"`
+}
+
+type signedDeal struct {
+	DealId     string `json:"deal_id"`
+	PayloadCid string `json:"payload_cid"`
+	Recipent   string `json:"recipent"`
+	TxHash1    string `json:"tx_hash_1"`
+}
+
+// Run starts the dao signer, it blocks and does not need the database of mcs
+func Run() {
+	daoSigner := config.GetConfig().DaoSigner
+	if strings.Trim(daoSigner.McsApiUrl, " ") == "" {
+		logs.GetLogger().Fatal("dao_signer.mcs_api_url is required in dao signer mode")
+	}
+	if strings.Trim(daoSigner.SignRule, " ") == "" {
+		logs.GetLogger().Fatal("dao_signer.sign_rule is required in dao signer mode")
+	}
+
+	c := cron.New()
+	name := "dao sign"
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(daoSigner.SignRule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := SignDeals()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Run()
+}
+
+func SignDeals() error {
+	deals, err := getCandidateDeals()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if len(deals) == 0 {
+		logs.GetLogger().Info("no deal to sign")
+		return nil
+	}
+
+	currentEpoch, err := utils.GetCurrentEpoch()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	ethClient, _, err := client.GetEthClient()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+	defer ethClient.Close()
+
+	_, signerAddress, err := client.GetPrivateKeyPublicKey(constants.DAO_SIGNER_PRIVATE_KEY)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	for _, deal := range deals {
+		signed, err := isSignedBy(ethClient, deal, *signerAddress)
+		if err != nil {
+			logs.GetLogger().Error(getLog(deal, err.Error()))
+			continue
+		}
+
+		if signed {
+			logs.GetLogger().Info(getLog(deal, "already signed"))
+			continue
+		}
+
+		err = verifyDeal(deal, currentEpoch)
+		if err != nil {
+			logs.GetLogger().Error(getLog(deal, "not signed, ", err.Error()))
+			continue
+		}
+
+		err = verifySourceFiles(deal)
+		if err != nil {
+			logs.GetLogger().Error(getLog(deal, "not signed, ", err.Error()))
+			continue
+		}
+
+		txHash, err := signDeal(ethClient, deal)
+		if err != nil {
+			logs.GetLogger().Error(getLog(deal, err.Error()))
+			continue
+		}
+
+		logs.GetLogger().Info(getLog(deal, "signed, tx hash:", *txHash))
+
+		err = recordSignedDeal(deal, *txHash)
+		if err != nil {
+			logs.GetLogger().Error(getLog(deal, err.Error()))
+		}
+	}
+
+	return nil
+}
+
+func getLog(deal *candidateDeal, messages ...string) string {
+	text := "deal id:" + strconv.FormatInt(deal.DealId, 10) + ",payload cid:" + deal.PayloadCid
+
+	for _, msg := range messages {
+		text = text + "," + msg
+	}
+
+	return text
+}
+
+func getCandidateDeals() ([]*candidateDeal, error) {
+	url := strings.TrimRight(config.GetConfig().DaoSigner.McsApiUrl, "/") + URL_DAO_SIGNATURE_DEALS
+	response, err := web.HttpGetNoToken(url, nil)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	dealsResponse := &candidateDealsResponse{}
+	err = json.Unmarshal(response, dealsResponse)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if dealsResponse.Status != constants.HTTP_STATUS_SUCCESS {
+		err := fmt.Errorf("get deals to sign from %s failed, message:%s", url, dealsResponse.Message)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return dealsResponse.Data, nil
+}
+
+// verifyDeal checks the deal on filecoin chain by itself instead of trusting what mcs says about it
+func verifyDeal(deal *candidateDeal, currentEpoch int64) error {
+	marketDeal, err := utils.GetMarketDeal(deal.DealId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	// the client is the wallet configured for this signer, not the one in the response of mcs, which the signer does not trust
+	clientWallet := config.GetConfig().SwanPlatformFilWallet
+	if strings.Trim(deal.ClientWalletAddress, " ") != "" && deal.ClientWalletAddress != clientWallet {
+		err := fmt.Errorf("client wallet:%s of deal:%d is not the configured swan_platform_fil_wallet:%s", deal.ClientWalletAddress, deal.DealId, clientWallet)
+		return err
+	}
+
+	clientId, err := utils.GetIdAddress(clientWallet)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return utils.CheckMarketDeal(marketDeal, deal.PieceCid, clientId, deal.MinerFid, currentEpoch)
+}
+
+// verifySourceFiles checks each source file to sign is in the piece of the deal, which verifyDeal has checked on chain,
+// by its inclusion proof and the car bytes of the proof downloaded from the car url
+func verifySourceFiles(deal *candidateDeal) error {
+	if len(deal.SourceFilePayloadCids) == 0 {
+		err := fmt.Errorf("no source file to sign")
+		return err
+	}
+
+	if strings.Trim(deal.CarFileUrl, " ") == "" {
+		err := fmt.Errorf("no car file url to verify source files")
+		return err
+	}
+
+	for _, payloadCid := range deal.SourceFilePayloadCids {
+		var inclusionProof *car.InclusionProof
+		for _, proof := range deal.InclusionProofs {
+			if proof != nil && proof.RootCid == payloadCid && proof.PieceCid == deal.PieceCid {
+				inclusionProof = proof
+				break
+			}
+		}
+
+		if inclusionProof == nil {
+			err := fmt.Errorf("no inclusion proof of source file:%s in piece:%s", payloadCid, deal.PieceCid)
+			return err
+		}
+
+		err := verifyInclusionProof(deal.CarFileUrl, inclusionProof)
+		if err != nil {
+			err := fmt.Errorf("source file:%s is not proven in piece:%s, %w", payloadCid, deal.PieceCid, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// only the car bytes of the proof are downloaded, by a range request
+func verifyInclusionProof(carFileUrl string, inclusionProof *car.InclusionProof) error {
+	request, err := http.NewRequest(http.MethodGet, carFileUrl, nil)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	dataEnd := inclusionProof.DataOffset + inclusionProof.DataLength - 1
+	request.Header.Set("Range", "bytes="+strconv.FormatInt(inclusionProof.DataOffset, 10)+"-"+strconv.FormatInt(dataEnd, 10))
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusPartialContent {
+		err := fmt.Errorf("get car bytes %d-%d responded http status:%s", inclusionProof.DataOffset, dataEnd, response.Status)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	dataFile, err := ioutil.TempFile("", "dao-signer-*.car")
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+	defer os.Remove(dataFile.Name())
+	defer dataFile.Close()
+
+	_, err = io.Copy(dataFile, io.LimitReader(response.Body, inclusionProof.DataLength))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = car.VerifyInclusionProof(dataFile, inclusionProof)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func isSignedBy(ethClient *ethclient.Client, deal *candidateDeal, signerAddress common.Address) (bool, error) {
+	filswanOracleSession, err := client.GetFilswanOracleSession(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	dealIdStr := strconv.FormatInt(deal.DealId, 10)
+	daoSignatures, err := filswanOracleSession.GetSignatureList(dealIdStr, config.GetConfig().FilecoinNetwork)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	for _, daoSignature := range daoSignatures {
+		if daoSignature.Signer == signerAddress {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func signDeal(ethClient *ethclient.Client, deal *candidateDeal) (*string, error) {
+	privateKey, publicKeyAddress, err := client.GetPrivateKeyPublicKey(constants.DAO_SIGNER_PRIVATE_KEY)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	tansactOpts, err := client.GetTransactOpts(ethClient, privateKey, *publicKeyAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	filswanOracleTransactor, err := client.GetFilswanOracleTransactor(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	dealIdStr := strconv.FormatInt(deal.DealId, 10)
+	recipient := common.HexToAddress(config.GetConfig().Polygon.McsPaymentReceiverAddress)
+	tx, err := filswanOracleTransactor.SignCarTransaction(tansactOpts, deal.SourceFilePayloadCids, dealIdStr, config.GetConfig().FilecoinNetwork, recipient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	txHash := tx.Hash().Hex()
+	txReceipt, err := client.CheckTx(ethClient, tx)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, fmt.Errorf("tx hash:%s, %w", txHash, err)
+	}
+
+	if txReceipt.Status != uint64(1) {
+		err := fmt.Errorf("sign failed! txHash=%s", txHash)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return &txHash, nil
+}
+
+// mcs verifies the signature on chain before saving it, then stops serving the deal once enough dao members signed it
+func recordSignedDeal(deal *candidateDeal, txHash string) error {
+	url := strings.TrimRight(config.GetConfig().DaoSigner.McsApiUrl, "/") + URL_DAO_SIGNATURE_DEALS
+	signedDeals := []signedDeal{
+		{
+			DealId:     strconv.FormatInt(deal.DealId, 10),
+			PayloadCid: deal.PayloadCid,
+			Recipent:   config.GetConfig().Polygon.McsPaymentReceiverAddress,
+			TxHash1:    txHash,
+		},
+	}
+
+	_, err := web.HttpPut(url, "", signedDeals)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package daosigner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"multi-chain-storage/car"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVerifySourceFiles(t *testing.T) {
+	srcDir := t.TempDir()
+	content := make([]byte, 300*1024)
+	rand.New(rand.NewSource(1)).Read(content)
+	err := os.WriteFile(filepath.Join(srcDir, "source.bin"), content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileDesc, err := car.CreateCarFile(srcDir, t.TempDir(), car.CAR_VERSION_1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dagPositions, err := car.GetDagPositions(fileDesc.CarFilePath, []string{fileDesc.PayloadCid})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inclusionProofs, err := car.GenerateInclusionProofs(fileDesc.CarFilePath, fileDesc.PieceCid, dagPositions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	carBytes, err := ioutil.ReadFile(fileDesc.CarFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	servedBytes := carBytes
+	ignoreRange := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ignoreRange {
+			w.Write(servedBytes)
+			return
+		}
+		http.ServeContent(w, r, "test.car", time.Now(), bytes.NewReader(servedBytes))
+	}))
+	defer server.Close()
+
+	newDeal := func() *candidateDeal {
+		return &candidateDeal{
+			DealId:                1,
+			PieceCid:              fileDesc.PieceCid,
+			SourceFilePayloadCids: []string{fileDesc.PayloadCid},
+			InclusionProofs:       inclusionProofs,
+			CarFileUrl:            server.URL,
+		}
+	}
+
+	err = verifySourceFiles(newDeal())
+	if err != nil {
+		t.Fatalf("verifySourceFiles() error %v", err)
+	}
+
+	deal := newDeal()
+	deal.SourceFilePayloadCids = append(deal.SourceFilePayloadCids, "bafkqaaa")
+	err = verifySourceFiles(deal)
+	if err == nil {
+		t.Errorf("source file without inclusion proof verified")
+	}
+
+	deal = newDeal()
+	deal.PieceCid = "baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq"
+	err = verifySourceFiles(deal)
+	if err == nil {
+		t.Errorf("inclusion proof of another piece verified")
+	}
+
+	deal = newDeal()
+	deal.CarFileUrl = ""
+	err = verifySourceFiles(deal)
+	if err == nil {
+		t.Errorf("source file verified without car file url")
+	}
+
+	ignoreRange = true
+	err = verifySourceFiles(newDeal())
+	if err == nil {
+		t.Errorf("source file verified when the car server ignores the range")
+	}
+	ignoreRange = false
+
+	servedBytes = append([]byte{}, carBytes...)
+	servedBytes[inclusionProofs[0].CarOffset+inclusionProofs[0].CarLength/2] ^= 0x01
+	err = verifySourceFiles(newDeal())
+	if err == nil {
+		t.Errorf("source file verified with a changed car byte")
+	}
+}
@@ -0,0 +1,180 @@
+package disk
+
+import (
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/filswan/go-swan-lib/logs"
+	libutils "github.com/filswan/go-swan-lib/utils"
+)
+
+type DirUsage struct {
+	Dir        string `json:"dir"`
+	TotalSize  uint64 `json:"total_size"`
+	FreeSize   uint64 `json:"free_size"`
+	UsedByDir  int64  `json:"used_by_dir"`
+	MinFreeGb  int64  `json:"min_free_gb"`
+	LowOnSpace bool   `json:"low_on_space"`
+}
+
+type DiskUsage struct {
+	Dirs       []*DirUsage              `json:"dirs"`
+	LocalFiles []*models.LocalFileUsage `json:"local_files"`
+}
+
+func getMinFreeSize() int64 {
+	minFreeGb := config.GetConfig().DiskPolicy.MinFreeGb
+	if minFreeGb <= 0 {
+		minFreeGb = constants.DISK_MIN_FREE_GB_DEFAULT
+	}
+
+	return minFreeGb * constants.BYTES_1GB
+}
+
+// GetFreeSize returns the total and available size of the file system dir is on
+func GetFreeSize(dir string) (uint64, uint64, error) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(dir, &stat)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, 0, err
+	}
+
+	return uint64(stat.Blocks) * uint64(stat.Bsize), uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+// CheckFreeSize fails when writing sizeToWrite bytes under dir would leave less than min_free_gb free
+func CheckFreeSize(dir string, sizeToWrite int64) error {
+	_, freeSize, err := GetFreeSize(dir)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	minFreeSize := getMinFreeSize()
+	if int64(freeSize)-sizeToWrite < minFreeSize {
+		err := fmt.Errorf("not enough disk space under %s, free:%d bytes, to write:%d bytes, min free:%d bytes", dir, freeSize, sizeToWrite, minFreeSize)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// TrackFile records a file or directory written by mcs, so that it can be reported and deleted by the retention rules
+func TrackFile(fileType, filePath string, sourceFileId, dealFileId *int64) error {
+	fileSize, err := getSize(filePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	localFile := models.LocalFile{
+		FileType:     fileType,
+		FilePath:     filePath,
+		FileSize:     fileSize,
+		SourceFileId: sourceFileId,
+		DealFileId:   dealFileId,
+		Status:       constants.LOCAL_FILE_STATUS_PRESENT,
+		CreateAt:     currentUtcMilliSec,
+		UpdateAt:     currentUtcMilliSec,
+	}
+
+	err = database.SaveOne(&localFile)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func DeleteFile(localFile *models.LocalFile, note string) error {
+	err := os.RemoveAll(localFile.FilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = models.UpdateLocalFileDeleted(localFile.ID, note)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	logs.GetLogger().Info(localFile.FileType, " file:", localFile.FilePath, " deleted, ", note)
+	return nil
+}
+
+func GetDiskUsage(dirs ...string) (*DiskUsage, error) {
+	diskUsage := &DiskUsage{
+		Dirs: []*DirUsage{},
+	}
+
+	minFreeSize := getMinFreeSize()
+	for _, dir := range dirs {
+		totalSize, freeSize, err := GetFreeSize(dir)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		usedByDir, err := getSize(dir)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		diskUsage.Dirs = append(diskUsage.Dirs, &DirUsage{
+			Dir:        dir,
+			TotalSize:  totalSize,
+			FreeSize:   freeSize,
+			UsedByDir:  usedByDir,
+			MinFreeGb:  minFreeSize / constants.BYTES_1GB,
+			LowOnSpace: int64(freeSize) < minFreeSize,
+		})
+	}
+
+	localFileUsages, err := models.GetLocalFileUsages()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	diskUsage.LocalFiles = localFileUsages
+	return diskUsage, nil
+}
+
+func getSize(path string) (int64, error) {
+	if !libutils.IsFileExistsFullPath(path) {
+		return 0, nil
+	}
+
+	size := int64(0)
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			size = size + info.Size()
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	return size, nil
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"multi-chain-storage/common/constants"
+	"strings"
+	"sync"
+)
+
+// FileStatusEvent is a status change of a source file, its id is the id of the file_status_history row,
+// so that a subscriber can resume from the last event it received
+type FileStatusEvent struct {
+	ID            int64  `json:"id"`
+	WalletAddress string `json:"wallet_address"`
+	SourceFileId  int64  `json:"source_file_id"`
+	PayloadCid    string `json:"payload_cid"`
+	FromStatus    string `json:"from_status"`
+	ToStatus      string `json:"to_status"`
+	Reason        string `json:"reason"`
+	CreateAt      int64  `json:"create_at"`
+}
+
+type subscriber struct {
+	walletAddress string
+	events        chan *FileStatusEvent
+}
+
+var (
+	mutex       sync.Mutex
+	subscribers = map[*subscriber]struct{}{}
+)
+
+// Subscribe returns the channel of the status changes of the files of walletAddress and the function to unsubscribe,
+// the channel is closed when the subscriber falls behind, it should then resume from the database
+func Subscribe(walletAddress string) (<-chan *FileStatusEvent, func()) {
+	sub := &subscriber{
+		walletAddress: strings.ToLower(walletAddress),
+		events:        make(chan *FileStatusEvent, constants.EVENT_SUBSCRIBER_BUFFER_SIZE),
+	}
+
+	mutex.Lock()
+	subscribers[sub] = struct{}{}
+	mutex.Unlock()
+
+	unsubscribe := func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if _, ok := subscribers[sub]; ok {
+			delete(subscribers, sub)
+			close(sub.events)
+		}
+	}
+
+	return sub.events, unsubscribe
+}
+
+// Publish never blocks the schedulers publishing the events
+func Publish(event *FileStatusEvent) {
+	walletAddress := strings.ToLower(event.WalletAddress)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	for sub := range subscribers {
+		if sub.walletAddress != walletAddress {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			delete(subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
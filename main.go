@@ -2,8 +2,11 @@ package main
 
 import (
 	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
 	"multi-chain-storage/config"
+	"multi-chain-storage/daosigner"
 	"multi-chain-storage/database"
+	"multi-chain-storage/routers/admin"
 	"multi-chain-storage/routers/billing"
 	"multi-chain-storage/routers/common"
 	"multi-chain-storage/routers/storage"
@@ -23,6 +26,16 @@ import (
 func main() {
 	LoadEnv()
 
+	if len(os.Args) > 1 && os.Args[1] == constants.RUN_MODE_DAO_SIGNER {
+		daosigner.Run()
+		return
+	}
+
+	err := utils.CheckCarServerConfig()
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
 	db := database.Init()
 	defer database.CloseDB(db)
 
@@ -52,6 +65,7 @@ func createGinServer() {
 	common.HostManager(v1.Group(constants.URL_HOST_GET_COMMON))
 	billing.BillingManager(v1.Group(constants.URL_BILLING_PREFIX))
 	storage.SendDealManager(v1.Group(constants.URL_STORAGE_PREFIX))
+	admin.AdminManager(v1.Group(constants.URL_ADMIN_PREFIX))
 
 	err := r.Run(":" + strconv.Itoa(config.GetConfig().Port))
 	if err != nil {
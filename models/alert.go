@@ -0,0 +1,113 @@
+package models
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+type Alert struct {
+	ID         int64   `json:"id"`
+	RuleName   string  `json:"rule_name"`
+	Metric     string  `json:"metric"`
+	Status     string  `json:"status"`
+	Value      float64 `json:"value"`
+	Threshold  float64 `json:"threshold"`
+	Message    string  `json:"message"`
+	FireAt     int64   `json:"fire_at"`
+	NotifiedAt int64   `json:"notified_at"`
+	ResolvedAt *int64  `json:"resolved_at"`
+	CreateAt   int64   `json:"create_at"`
+	UpdateAt   int64   `json:"update_at"`
+}
+
+type recordCount struct {
+	Count int64 `json:"count"`
+}
+
+func GetFiringAlertByRuleName(ruleName string) (*Alert, error) {
+	var alerts []*Alert
+	sql := "select a.* from alert a where a.rule_name=? and a.status=? order by a.id desc"
+	err := database.GetDB().Raw(sql, ruleName, constants.ALERT_STATUS_FIRING).Scan(&alerts).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+
+	return alerts[0], nil
+}
+
+func GetAlerts(limit, offset int) ([]*Alert, error) {
+	var alerts []*Alert
+	sql := "select a.* from alert a order by a.id desc limit ? offset ?"
+	err := database.GetDB().Raw(sql, limit, offset).Scan(&alerts).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+func UpdateAlertNotified(id int64, value float64, message string) error {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	sql := "update alert set value=?,message=?,notified_at=?,update_at=? where id=?"
+	err := database.GetDB().Exec(sql, value, message, currentUtcMilliSec, currentUtcMilliSec, id).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func UpdateAlertResolved(id int64, value float64, message string) error {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	sql := "update alert set status=?,value=?,message=?,resolved_at=?,update_at=? where id=?"
+
+	params := []interface{}{}
+	params = append(params, constants.ALERT_STATUS_RESOLVED)
+	params = append(params, value)
+	params = append(params, message)
+	params = append(params, currentUtcMilliSec)
+	params = append(params, currentUtcMilliSec)
+	params = append(params, id)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func GetOfflineDealCountByUnlockStatusUpdatedAfter(unlockStatus string, updateAtMin int64) (int64, error) {
+	var recordCount recordCount
+	sql := "select count(*) count from offline_deal a where a.unlock_status=? and a.update_at>=?"
+	err := database.GetDB().Raw(sql, unlockStatus, updateAtMin).Scan(&recordCount).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	return recordCount.Count, nil
+}
+
+func GetDealFileCountByStatusUpdatedAfter(lockPaymentStatus string, updateAtMin int64) (int64, error) {
+	var recordCount recordCount
+	sql := "select count(*) count from deal_file a where a.lock_payment_status=? and a.update_at>=?"
+	err := database.GetDB().Raw(sql, lockPaymentStatus, updateAtMin).Scan(&recordCount).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	return recordCount.Count, nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+	"strings"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+// ApiKey authorizes the webhook apis for the wallet that created it, only the sha256 of the key is saved
+type ApiKey struct {
+	ID            int64  `json:"id"`
+	WalletAddress string `json:"wallet_address"`
+	KeyHash       string `json:"-"`
+	KeyPrefix     string `json:"key_prefix"`
+	Status        string `json:"status"`
+	CreateAt      int64  `json:"create_at"`
+	UpdateAt      int64  `json:"update_at"`
+}
+
+func GetApiKeyByKeyHash(keyHash string) (*ApiKey, error) {
+	var apiKeys []*ApiKey
+	sql := "select a.* from api_key a where a.key_hash=? and a.status=?"
+	err := database.GetDB().Raw(sql, keyHash, constants.API_KEY_STATUS_ACTIVE).Scan(&apiKeys).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(apiKeys) == 0 {
+		return nil, nil
+	}
+
+	return apiKeys[0], nil
+}
+
+func GetApiKeysByWalletAddress(walletAddress string) ([]*ApiKey, error) {
+	var apiKeys []*ApiKey
+	sql := "select a.* from api_key a where a.wallet_address=? and a.status=? order by a.id"
+	err := database.GetDB().Raw(sql, strings.ToLower(walletAddress), constants.API_KEY_STATUS_ACTIVE).Scan(&apiKeys).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+// DeleteApiKey deletes the api key of the wallet and the webhooks registered with it
+func DeleteApiKey(id int64, walletAddress string) (bool, error) {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	db := database.GetDBTransaction()
+	sql := "update api_key set status=?,update_at=? where id=? and wallet_address=? and status=?"
+
+	params := []interface{}{}
+	params = append(params, constants.API_KEY_STATUS_DELETED)
+	params = append(params, currentUtcMilliSec)
+	params = append(params, id)
+	params = append(params, strings.ToLower(walletAddress))
+	params = append(params, constants.API_KEY_STATUS_ACTIVE)
+
+	result := db.Exec(sql, params...)
+	if result.Error != nil {
+		db.Rollback()
+		logs.GetLogger().Error(result.Error)
+		return false, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		db.Rollback()
+		return false, nil
+	}
+
+	sql = "update webhook set status=?,update_at=? where api_key_id=? and status=?"
+	err := db.Exec(sql, constants.WEBHOOK_STATUS_DELETED, currentUtcMilliSec, id, constants.WEBHOOK_STATUS_ACTIVE).Error
+	if err != nil {
+		db.Rollback()
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	err = db.Commit().Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	return true, nil
+}
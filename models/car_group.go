@@ -0,0 +1,205 @@
+package models
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/shopspring/decimal"
+)
+
+type CarGroup struct {
+	ID        int64           `json:"id"`
+	Status    string          `json:"status"`
+	FileCount int             `json:"file_count"`
+	TotalSize int64           `json:"total_size"`
+	MaxPrice  decimal.Decimal `json:"max_price"`
+	ClaimedBy *string         `json:"claimed_by"`
+	ClaimedAt *int64          `json:"claimed_at"`
+	Attempts  int             `json:"attempts"`
+	Note      string          `json:"note"`
+	CreateAt  int64           `json:"create_at"`
+	UpdateAt  int64           `json:"update_at"`
+}
+
+type CarGroupStatusCount struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+func GetCarGroupById(id int64) (*CarGroup, error) {
+	var carGroups []*CarGroup
+	sql := "select a.* from car_group a where a.id=?"
+	err := database.GetDB().Raw(sql, id).Scan(&carGroups).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(carGroups) == 0 {
+		return nil, nil
+	}
+
+	return carGroups[0], nil
+}
+
+func GetCarGroupIdsByStatus(status string, limit int) ([]int64, error) {
+	var carGroups []*CarGroup
+	sql := "select a.id from car_group a where a.status=? order by a.id limit ?"
+	err := database.GetDB().Raw(sql, status, limit).Scan(&carGroups).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	ids := []int64{}
+	for _, carGroup := range carGroups {
+		ids = append(ids, carGroup.ID)
+	}
+
+	return ids, nil
+}
+
+func GetCarGroupStatusCounts() ([]*CarGroupStatusCount, error) {
+	var carGroupStatusCounts []*CarGroupStatusCount
+	sql := "select a.status,count(*) count from car_group a group by a.status"
+	err := database.GetDB().Raw(sql).Scan(&carGroupStatusCounts).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return carGroupStatusCounts, nil
+}
+
+// ClaimCarGroup only succeeds for the first claimer, since the row is updated only when it is still planned
+func ClaimCarGroup(id int64, claimedBy string) (bool, error) {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	sql := "update car_group set status=?,claimed_by=?,claimed_at=?,update_at=? where id=? and status=?"
+
+	params := []interface{}{}
+	params = append(params, constants.CAR_GROUP_STATUS_CLAIMED)
+	params = append(params, claimedBy)
+	params = append(params, currentUtcMilliSec)
+	params = append(params, currentUtcMilliSec)
+	params = append(params, id)
+	params = append(params, constants.CAR_GROUP_STATUS_PLANNED)
+
+	result := database.GetDB().Exec(sql, params...)
+	if result.Error != nil {
+		logs.GetLogger().Error(result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseCarGroup puts the car group back to be claimed again, until it has failed attemptsMax times,
+// then the source files of the failed car group are taken out of it, to be planned into another car group
+func ReleaseCarGroup(id int64, claimedBy, note string, attemptsMax int) error {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	db := database.GetDBTransaction()
+	sql := "update car_group set status=case when attempts+1>=? then ? else ? end,claimed_by=null,claimed_at=null,attempts=attempts+1,note=?,update_at=? " +
+		"where id=? and status=? and claimed_by=?"
+
+	params := []interface{}{}
+	params = append(params, attemptsMax)
+	params = append(params, constants.CAR_GROUP_STATUS_FAILED)
+	params = append(params, constants.CAR_GROUP_STATUS_PLANNED)
+	params = append(params, note)
+	params = append(params, currentUtcMilliSec)
+	params = append(params, id)
+	params = append(params, constants.CAR_GROUP_STATUS_CLAIMED)
+	params = append(params, claimedBy)
+
+	err := db.Exec(sql, params...).Error
+	if err != nil {
+		db.Rollback()
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	sql = "update source_file set car_group_id=null,update_at=? where car_group_id=? and exists (select 1 from car_group where id=? and status=?)"
+
+	params = []interface{}{}
+	params = append(params, currentUtcMilliSec)
+	params = append(params, id)
+	params = append(params, id)
+	params = append(params, constants.CAR_GROUP_STATUS_FAILED)
+
+	err = db.Exec(sql, params...).Error
+	if err != nil {
+		db.Rollback()
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = db.Commit().Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// RenewCarGroupClaim refreshes claimed_at of the car group only when it is still claimed by claimedBy,
+// so a worker checks and keeps its claim in one statement before each stage, and the reaper does not take it in between
+func RenewCarGroupClaim(id int64, claimedBy string) (bool, error) {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	sql := "update car_group set claimed_at=?,update_at=? where id=? and status=? and claimed_by=?"
+
+	params := []interface{}{}
+	params = append(params, currentUtcMilliSec)
+	params = append(params, currentUtcMilliSec)
+	params = append(params, id)
+	params = append(params, constants.CAR_GROUP_STATUS_CLAIMED)
+	params = append(params, claimedBy)
+
+	result := database.GetDB().Exec(sql, params...)
+	if result.Error != nil {
+		logs.GetLogger().Error(result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func UpdateCarGroupCreated(id int64, claimedBy string) error {
+	sql := "update car_group set status=?,note='',update_at=? where id=? and claimed_by=?"
+
+	params := []interface{}{}
+	params = append(params, constants.CAR_GROUP_STATUS_CREATED)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, id)
+	params = append(params, claimedBy)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ReapCarGroups releases the car groups claimed before claimedAtMax, whose workers are considered stuck or gone
+func ReapCarGroups(claimedAtMax int64) (int64, error) {
+	sql := "update car_group set status=?,claimed_by=null,claimed_at=null,note=?,update_at=? where status=? and claimed_at<?"
+
+	params := []interface{}{}
+	params = append(params, constants.CAR_GROUP_STATUS_PLANNED)
+	params = append(params, "claim timed out")
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, constants.CAR_GROUP_STATUS_CLAIMED)
+	params = append(params, claimedAtMax)
+
+	result := database.GetDB().Exec(sql, params...)
+	if result.Error != nil {
+		logs.GetLogger().Error(result.Error)
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
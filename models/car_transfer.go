@@ -0,0 +1,68 @@
+package models
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+type CarTransfer struct {
+	ID               int64  `json:"id"`
+	DealFileId       int64  `json:"deal_file_id"`
+	PieceCid         string `json:"piece_cid"`
+	MinerFid         string `json:"miner_fid"`
+	CarFileSize      int64  `json:"car_file_size"`
+	BytesTransferred int64  `json:"bytes_transferred"`
+	RequestCount     int    `json:"request_count"`
+	Status           string `json:"status"`
+	CreateAt         int64  `json:"create_at"`
+	UpdateAt         int64  `json:"update_at"`
+}
+
+func GetCarTransfersByPieceCid(pieceCid string) ([]*CarTransfer, error) {
+	var carTransfers []*CarTransfer
+	sql := "select a.* from car_transfer a where a.piece_cid=? order by a.update_at desc"
+	err := database.GetDB().Raw(sql, pieceCid).Scan(&carTransfers).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return carTransfers, nil
+}
+
+func SaveCarTransferProgress(dealFile *DealFile, minerFid string, bytesTransferred int64) error {
+	sql := "insert into car_transfer (deal_file_id,piece_cid,miner_fid,car_file_size,bytes_transferred,request_count,status,create_at,update_at) " +
+		"values (?,?,?,?,?,1,?,?,?) " +
+		"on duplicate key update bytes_transferred=bytes_transferred+values(bytes_transferred),request_count=request_count+1," +
+		"status=case when bytes_transferred>=car_file_size then ? else ? end,update_at=values(update_at)"
+
+	curUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+
+	status := constants.CAR_TRANSFER_STATUS_TRANSFERRING
+	if bytesTransferred >= dealFile.CarFileSize {
+		status = constants.CAR_TRANSFER_STATUS_COMPLETED
+	}
+
+	params := []interface{}{}
+	params = append(params, dealFile.ID)
+	params = append(params, dealFile.PieceCid)
+	params = append(params, minerFid)
+	params = append(params, dealFile.CarFileSize)
+	params = append(params, bytesTransferred)
+	params = append(params, status)
+	params = append(params, curUtcMilliSec)
+	params = append(params, curUtcMilliSec)
+	params = append(params, constants.CAR_TRANSFER_STATUS_COMPLETED)
+	params = append(params, constants.CAR_TRANSFER_STATUS_TRANSFERRING)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
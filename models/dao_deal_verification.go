@@ -0,0 +1,78 @@
+package models
+
+import (
+	"multi-chain-storage/database"
+	"strconv"
+	"time"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+type DaoDealVerification struct {
+	ID                   int64  `json:"id"`
+	DealId               int64  `json:"deal_id"`
+	Status               string `json:"status"`
+	Reason               string `json:"reason"`
+	Provider             string `json:"provider"`
+	Client               string `json:"client"`
+	PieceCid             string `json:"piece_cid"`
+	VerifiedDeal         bool   `json:"verified_deal"`
+	StartEpoch           int64  `json:"start_epoch"`
+	EndEpoch             int64  `json:"end_epoch"`
+	StoragePricePerEpoch string `json:"storage_price_per_epoch"`
+	SectorStartEpoch     int64  `json:"sector_start_epoch"`
+	SlashEpoch           int64  `json:"slash_epoch"`
+	CreateAt             int64  `json:"create_at"`
+	UpdateAt             int64  `json:"update_at"`
+}
+
+type DealToVerifyForDao struct {
+	DealId       int64  `json:"deal_id"`
+	PieceCid     string `json:"piece_cid"`
+	MinerFid     string `json:"miner_fid"`
+	SenderWallet string `json:"sender_wallet"`
+}
+
+// GetDealsToVerifyForDao returns the deals that can be served to dao members to sign, before they are verified on chain
+func GetDealsToVerifyForDao() ([]*DealToVerifyForDao, error) {
+	sql := "select b.deal_id,a.piece_cid,b.miner_fid,b.sender_wallet from deal_file a left join offline_deal b on a.id = b.deal_file_id left join event_lock_payment c on a.payload_cid=c.payload_cid " +
+		" where b.deal_id not in (select deal_id from dao_fetched_deal) " +
+		" and b.deal_id > 0 and IFNULL(c.deadline,0) < " + strconv.FormatInt(time.Now().Unix(), 10)
+
+	var deals []*DealToVerifyForDao
+	err := database.GetDB().Raw(sql).Scan(&deals).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return deals, nil
+}
+
+func GetDaoDealVerificationByDealId(dealId int64) (*DaoDealVerification, error) {
+	var verifications []*DaoDealVerification
+	sql := "select a.* from dao_deal_verification a where a.deal_id=?"
+	err := database.GetDB().Raw(sql, dealId).Scan(&verifications).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(verifications) == 0 {
+		return nil, nil
+	}
+
+	return verifications[0], nil
+}
+
+func GetDaoDealVerificationsByStatus(status string, limit, offset int) ([]*DaoDealVerification, error) {
+	var verifications []*DaoDealVerification
+	sql := "select a.* from dao_deal_verification a where a.status=? order by a.update_at desc limit ? offset ?"
+	err := database.GetDB().Raw(sql, status, limit, offset).Scan(&verifications).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return verifications, nil
+}
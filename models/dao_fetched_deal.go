@@ -1,7 +1,50 @@
 package models
 
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
 type DaoFetchedDeal struct {
 	ID       int64 `json:"id"`
 	DealId   int64 `json:"deal_id"`
 	CreateAt int64 `json:"create_at"`
 }
+
+func GetDaoFetchedDealByDealId(dealId int64) (*DaoFetchedDeal, error) {
+	var daoFetchedDeals []*DaoFetchedDeal
+	sql := "select a.* from dao_fetched_deal a where a.deal_id=?"
+	err := database.GetDB().Raw(sql, dealId).Scan(&daoFetchedDeals).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(daoFetchedDeals) == 0 {
+		return nil, nil
+	}
+
+	return daoFetchedDeals[0], nil
+}
+
+type DealToIndexDaoSignatures struct {
+	DealId     int64  `json:"deal_id"`
+	PayloadCid string `json:"payload_cid"`
+}
+
+// GetDealsToIndexDaoSignatures returns the deals on chain whose dao signatures have not reached the threshold yet
+func GetDealsToIndexDaoSignatures() ([]*DealToIndexDaoSignatures, error) {
+	sql := "select b.deal_id,a.payload_cid from deal_file a, offline_deal b where a.id=b.deal_file_id and b.deal_id>0 and b.unlock_status in (?,?) " +
+		"and b.deal_id not in (select deal_id from dao_fetched_deal)"
+
+	var deals []*DealToIndexDaoSignatures
+	err := database.GetDB().Raw(sql, constants.OFFLINE_DEAL_UNLOCK_STATUS_NOT_UNLOCKED, constants.OFFLINE_DEAL_UNLOCK_STATUS_UNLOCK_FAILED).Scan(&deals).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return deals, nil
+}
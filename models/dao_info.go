@@ -2,7 +2,12 @@ package models
 
 import (
 	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
 	"multi-chain-storage/database"
+	"strconv"
+	"strings"
+
+	"github.com/filswan/go-swan-lib/logs"
 )
 
 type DaoInfo struct {
@@ -27,3 +32,108 @@ func FindDaoInfoList(whereCondition interface{}, orderCondition, limit, offset s
 	err := db.Where(whereCondition).Offset(offset).Limit(limit).Order(orderCondition).Find(&models).Error
 	return models, err
 }
+
+func GetDaoInfos() ([]*DaoInfo, error) {
+	var daoInfos []*DaoInfo
+	sql := "select a.* from dao_info a order by a.order_index"
+	err := database.GetDB().Raw(sql).Scan(&daoInfos).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return daoInfos, nil
+}
+
+// SyncDaoInfos makes dao_info the same as daoInfos, in the order of daoInfos, the existing members keep their ids
+func SyncDaoInfos(daoInfos []*DaoInfo) error {
+	existingDaoInfos, err := GetDaoInfos()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	existingDaoInfoMap := map[string]*DaoInfo{}
+	for _, existingDaoInfo := range existingDaoInfos {
+		existingDaoInfoMap[strings.ToLower(existingDaoInfo.DaoAddress)] = existingDaoInfo
+	}
+
+	db := database.GetDBTransaction()
+	currentUtcMilliSecond := utils.GetCurrentUtcMilliSecond()
+	for i, daoInfo := range daoInfos {
+		daoAddress := strings.ToLower(daoInfo.DaoAddress)
+		daoInfo.OrderIndex = strconv.Itoa(i + 1)
+		daoInfo.CreateAt = currentUtcMilliSecond
+		if existingDaoInfo, ok := existingDaoInfoMap[daoAddress]; ok {
+			daoInfo.ID = existingDaoInfo.ID
+			daoInfo.CreateAt = existingDaoInfo.CreateAt
+			if daoInfo.DaoName == "" {
+				daoInfo.DaoName = existingDaoInfo.DaoName
+			}
+			if daoInfo.Description == "" {
+				daoInfo.Description = existingDaoInfo.Description
+			}
+			delete(existingDaoInfoMap, daoAddress)
+		}
+
+		err = database.SaveOneInTransaction(db, daoInfo)
+		if err != nil {
+			db.Rollback()
+			logs.GetLogger().Error(err)
+			return err
+		}
+	}
+
+	for _, existingDaoInfo := range existingDaoInfoMap {
+		err = db.Exec("delete from dao_info where id=?", existingDaoInfo.ID).Error
+		if err != nil {
+			db.Rollback()
+			logs.GetLogger().Error(err)
+			return err
+		}
+	}
+
+	err = db.Commit().Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+type DaoSignatureStatistics struct {
+	DaoAddress        string  `json:"dao_address"`
+	SignedDealCount   int64   `json:"signed_deal_count"`
+	AvgLatencyMinutes float64 `json:"avg_latency_minutes"`
+	MaxLatencyMinutes float64 `json:"max_latency_minutes"`
+	LastSignedAt      int64   `json:"last_signed_at"`
+}
+
+// GetDaoSignatureStatistics counts the valid dao signatures signed since blockTimeMin, in seconds,
+// latency is from the deal becoming active to the block of its signature
+func GetDaoSignatureStatistics(blockTimeMin int64) ([]*DaoSignatureStatistics, int64, error) {
+	sql := "select lower(a.dao_address) dao_address,count(distinct a.deal_id) signed_deal_count," +
+		"ifnull(avg(case when b.active_at is not null then (cast(a.block_time as unsigned)*1000-b.active_at)/60000 end),0) avg_latency_minutes," +
+		"ifnull(max(case when b.active_at is not null then (cast(a.block_time as unsigned)*1000-b.active_at)/60000 end),0) max_latency_minutes," +
+		"max(cast(a.block_time as unsigned)) last_signed_at " +
+		"from event_dao_signature a left join offline_deal b on a.deal_id=b.deal_id " +
+		"where a.signature_unlock_status=? and cast(a.block_time as unsigned)>=? group by lower(a.dao_address)"
+
+	var statistics []*DaoSignatureStatistics
+	err := database.GetDB().Raw(sql, constants.SIGNATURE_SUCCESS_VALUE, blockTimeMin).Scan(&statistics).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, 0, err
+	}
+
+	sql = "select count(distinct a.deal_id) count from event_dao_signature a where a.signature_unlock_status=? and cast(a.block_time as unsigned)>=?"
+	var dealCount recordCount
+	err = database.GetDB().Raw(sql, constants.SIGNATURE_SUCCESS_VALUE, blockTimeMin).Scan(&dealCount).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, 0, err
+	}
+
+	return statistics, dealCount.Count, nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+// DaoProposal is a change to the dao contract, it is submitted by the platform signer only after another operator approved it
+type DaoProposal struct {
+	ID         int64  `json:"id"`
+	Action     string `json:"action"`
+	Params     string `json:"params"`
+	Status     string `json:"status"`
+	ProposedBy string `json:"proposed_by"`
+	ReviewedBy string `json:"reviewed_by"`
+	TxHash     string `json:"tx_hash"`
+	Note       string `json:"note"`
+	CreateAt   int64  `json:"create_at"`
+	UpdateAt   int64  `json:"update_at"`
+}
+
+func GetDaoProposalById(id int64) (*DaoProposal, error) {
+	var daoProposals []*DaoProposal
+	sql := "select a.* from dao_proposal a where a.id=?"
+	err := database.GetDB().Raw(sql, id).Scan(&daoProposals).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(daoProposals) == 0 {
+		return nil, nil
+	}
+
+	return daoProposals[0], nil
+}
+
+func GetDaoProposals(limit, offset int) ([]*DaoProposal, error) {
+	var daoProposals []*DaoProposal
+	sql := "select a.* from dao_proposal a order by a.id desc limit ? offset ?"
+	err := database.GetDB().Raw(sql, limit, offset).Scan(&daoProposals).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return daoProposals, nil
+}
+
+// ReviewDaoProposal moves a pending proposal to status, it returns false when the proposal is not pending any more
+func ReviewDaoProposal(id int64, status, reviewedBy string) (bool, error) {
+	sql := "update dao_proposal set status=?,reviewed_by=?,update_at=? where id=? and status=?"
+
+	params := []interface{}{}
+	params = append(params, status)
+	params = append(params, reviewedBy)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, id)
+	params = append(params, constants.DAO_PROPOSAL_STATUS_PENDING)
+
+	result := database.GetDB().Exec(sql, params...)
+	err := result.Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func UpdateDaoProposalResult(id int64, status, txHash, note string) error {
+	sql := "update dao_proposal set status=?,tx_hash=?,note=?,update_at=? where id=?"
+
+	params := []interface{}{}
+	params = append(params, status)
+	params = append(params, txHash)
+	params = append(params, note)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, id)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -70,6 +70,20 @@ func GetDealFileByDealId(dealId int64) (*DealFile, error) {
 	return nil, err
 }
 
+func GetDealFilesByPieceCid(pieceCid string) ([]*DealFile, error) {
+	sql := "select a.* from deal_file a where a.piece_cid=? order by a.id desc"
+	var dealFiles []*DealFile
+
+	err := database.GetDB().Raw(sql, pieceCid).Scan(&dealFiles).Error
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return dealFiles, nil
+}
+
 func GetDealFilesByStatus(status string) ([]*DealFile, error) {
 	sql := "select a.* from deal_file a where a.lock_payment_status=?"
 	var dealFiles []*DealFile
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+type DealProposal struct {
+	ID              int64           `json:"id"`
+	DealFileId      int64           `json:"deal_file_id"`
+	MinerFid        string          `json:"miner_fid"`
+	AskPrice        decimal.Decimal `json:"ask_price"`
+	StartEpoch      int64           `json:"start_epoch"`
+	DealCid         string          `json:"deal_cid"`
+	Status          string          `json:"status"`
+	Note            string          `json:"note"`
+	TransferUrl     string          `json:"transfer_url"`
+	BoostDealParams string          `json:"boost_deal_params"`
+	CreateAt        int64           `json:"create_at"`
+	UpdateAt        int64           `json:"update_at"`
+}
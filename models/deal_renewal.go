@@ -0,0 +1,95 @@
+package models
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/shopspring/decimal"
+)
+
+type DealRenewal struct {
+	ID                int64  `json:"id"`
+	DealFileId        int64  `json:"deal_file_id"`
+	OfflineDealId     int64  `json:"offline_deal_id"`
+	DealId            int64  `json:"deal_id"`
+	MinerFid          string `json:"miner_fid"`
+	EndEpoch          int64  `json:"end_epoch"`
+	RenewedDealFileId *int64 `json:"renewed_deal_file_id"`
+	Status            string `json:"status"`
+	Note              string `json:"note"`
+	CreateAt          int64  `json:"create_at"`
+	UpdateAt          int64  `json:"update_at"`
+}
+
+// RenewalReservation is the part of the locked fee of a source file reserved to pay for the deals of a renewed deal file
+type RenewalReservation struct {
+	ID                int64           `json:"id"`
+	SourceFileId      int64           `json:"source_file_id"`
+	PayloadCid        string          `json:"payload_cid"`
+	RenewedDealFileId int64           `json:"renewed_deal_file_id"`
+	Amount            decimal.Decimal `json:"amount"`
+	CreateAt          int64           `json:"create_at"`
+}
+
+type DealRenewalExt struct {
+	DealRenewal
+	TaskUuid     string         `json:"task_uuid"`
+	RenewedDeals []*OfflineDeal `json:"renewed_deals"`
+}
+
+func GetDealRenewalsBySourceFileId(sourceFileId int64) ([]*DealRenewalExt, error) {
+	var dealRenewals []*DealRenewalExt
+	sql := "select a.*,c.task_uuid from deal_renewal a " +
+		"join source_file_deal_file_map b on a.deal_file_id=b.deal_file_id " +
+		"left join deal_file c on a.renewed_deal_file_id=c.id " +
+		"where b.source_file_id=? order by a.create_at desc"
+	err := database.GetDB().Raw(sql, sourceFileId).Scan(&dealRenewals).Error
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return dealRenewals, nil
+}
+
+// GetOfflineDeals2BeRenewed returns the active deals ending before endEpochMax not renewed yet,
+// deals failed to renew DEAL_RENEWAL_ATTEMPTS_MAX times are not tried again
+func GetOfflineDeals2BeRenewed(endEpochMax int64) ([]*OfflineDeal, error) {
+	var offlineDeals []*OfflineDeal
+	sql := "select a.* from offline_deal a, deal_file b " +
+		"where a.deal_file_id=b.id and a.status=? and a.start_epoch+b.duration*?<=? " +
+		"and not exists (select 1 from deal_renewal c where c.offline_deal_id=a.id and c.status!=?) " +
+		"and (select count(*) from deal_renewal d where d.offline_deal_id=a.id and d.status=?)<?"
+	err := database.GetDB().Raw(sql, constants.DEAL_STATUS_ACTIVE, constants.EPOCH_PER_DAY, endEpochMax, constants.DEAL_RENEWAL_STATUS_FAILED,
+		constants.DEAL_RENEWAL_STATUS_FAILED, constants.DEAL_RENEWAL_ATTEMPTS_MAX).Scan(&offlineDeals).Error
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return offlineDeals, nil
+}
+
+// GetReservedRenewalFee returns the locked fee of the payload cid reserved by renewals whose deal files are not refunded yet,
+// the fee left after them is refunded with the payment once the renewed deals are unlocked
+func GetReservedRenewalFee(payloadCid string) (decimal.Decimal, error) {
+	var reservedFees []*struct {
+		Amount decimal.Decimal
+	}
+	sql := "select coalesce(sum(a.amount),0) amount from renewal_reservation a, deal_file b " +
+		"where a.payload_cid=? and a.renewed_deal_file_id=b.id and (b.lock_payment_status is null or b.lock_payment_status!=?)"
+	err := database.GetDB().Raw(sql, payloadCid, constants.PROCESS_STATUS_UNLOCK_REFUNDED).Scan(&reservedFees).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return decimal.Zero, err
+	}
+
+	if len(reservedFees) == 0 {
+		return decimal.Zero, nil
+	}
+
+	return reservedFees[0].Amount, nil
+}
@@ -57,6 +57,22 @@ func GetEventDaoSignaturesByDealId(dealId int64) ([]*EventDaoSignature, error) {
 	return eventDaoSignatures, nil
 }
 
+func GetEventDaoSignatureByDealIdDaoAddress(dealId int64, daoAddress string) (*EventDaoSignature, error) {
+	var eventDaoSignatures []*EventDaoSignature
+	sql := "select a.* from event_dao_signature a where a.deal_id=? and lower(a.dao_address)=lower(?) order by a.id desc"
+	err := database.GetDB().Raw(sql, dealId, daoAddress).Scan(&eventDaoSignatures).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(eventDaoSignatures) == 0 {
+		return nil, nil
+	}
+
+	return eventDaoSignatures[0], nil
+}
+
 func GetEventDaoSignatures(whereCondition interface{}) (*EventDaoSignature, error) {
 	db := database.GetDB()
 	var eventDaoSignatures []*EventDaoSignature
@@ -73,3 +89,16 @@ func GetEventDaoSignatures(whereCondition interface{}) (*EventDaoSignature, erro
 		return nil, nil
 	}
 }
+
+// GetAllEventDaoSignaturesByDealId returns the signatures of the deal whatever their unlock status, in the order they were signed
+func GetAllEventDaoSignaturesByDealId(dealId int64) ([]*EventDaoSignature, error) {
+	var eventDaoSignatures []*EventDaoSignature
+	sql := "select a.* from event_dao_signature a where a.deal_id=? order by a.block_no,a.id"
+	err := database.GetDB().Raw(sql, dealId).Scan(&eventDaoSignatures).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return eventDaoSignatures, nil
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"multi-chain-storage/common/constants"
 	"multi-chain-storage/common/utils"
 	"multi-chain-storage/database"
@@ -28,6 +29,9 @@ type EventLockPayment struct {
 	LockPaymentTime int64           `json:"lock_payment_time"`
 	CreateAt        int64           `json:"create_at"`
 	SourceFileId    int64           `json:"source_file_id"`
+	QuoteSignature  string          `json:"quote_signature"`
+	QuoteStatus     string          `json:"quote_status"`
+	QuoteNote       string          `json:"quote_note"`
 }
 
 type EventLockPaymentQuery struct {
@@ -94,6 +98,19 @@ func FindExpiredLockPayment() ([]*EventLockPaymentQuery, error) {
 	return models, nil
 }
 
+// GetEventLockPaymentCountWithoutQuoteCreatedAfter returns the number of lock payments written without a quote since createAtMin
+func GetEventLockPaymentCountWithoutQuoteCreatedAfter(createAtMin int64) (int64, error) {
+	var recordCount recordCount
+	sql := "select count(*) count from event_lock_payment a where (a.quote_signature is null or a.quote_signature='') and a.create_at>=?"
+	err := database.GetDB().Raw(sql, createAtMin).Scan(&recordCount).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	return recordCount.Count, nil
+}
+
 func CreateEventLockPayment(eventLockPayment EventLockPayment) error {
 	currentUtcMilliSecond := utils.GetCurrentUtcMilliSecond()
 	eventLockPayment.CreateAt = currentUtcMilliSecond
@@ -104,7 +121,13 @@ func CreateEventLockPayment(eventLockPayment EventLockPayment) error {
 		return err
 	}
 
+	isRejected := eventLockPayment.QuoteStatus == constants.LOCK_PAYMENT_QUOTE_STATUS_REJECTED
 	if len(eventLockPayments) > 0 {
+		// a lock payment accepted before is not overwritten by a rejected write of the same payload cid
+		if isRejected && eventLockPayments[0].QuoteStatus != constants.LOCK_PAYMENT_QUOTE_STATUS_REJECTED {
+			logs.GetLogger().Info("payload_cid:", eventLockPayment.PayloadCid, " lock payment accepted before, rejected one not saved")
+			return nil
+		}
 		eventLockPayment.ID = eventLockPayments[0].ID
 	}
 
@@ -116,6 +139,16 @@ func CreateEventLockPayment(eventLockPayment EventLockPayment) error {
 		return err
 	}
 
+	// a rejected lock payment is kept for the ledger and refund, but its source file is not paid to be aggregated
+	if isRejected {
+		err = db.Commit().Error
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+		return nil
+	}
+
 	sql := "update source_file set status=?,update_at=? where id=?"
 
 	params := []interface{}{}
@@ -130,11 +163,21 @@ func CreateEventLockPayment(eventLockPayment EventLockPayment) error {
 		return err
 	}
 
+	reason := fmt.Sprintf("locked fee:%s paid by:%s", eventLockPayment.LockedFee.String(), eventLockPayment.AddressFrom)
+	fileStatusHistory, err := TransitFileStatusInTransaction(db, eventLockPayment.SourceFileId, constants.FILE_STATUS_PAID, reason)
+	if err != nil {
+		db.Rollback()
+		logs.GetLogger().Error(err)
+		return err
+	}
+
 	err = db.Commit().Error
 	if err != nil {
 		logs.GetLogger().Error(err)
 		return err
 	}
 
+	PublishFileStatusHistory(fileStatusHistory)
+
 	return nil
 }
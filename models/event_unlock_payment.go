@@ -4,6 +4,7 @@ import (
 	"multi-chain-storage/common/constants"
 	"multi-chain-storage/common/utils"
 	"multi-chain-storage/database"
+	"strconv"
 
 	"github.com/filswan/go-swan-lib/logs"
 	"github.com/shopspring/decimal"
@@ -30,6 +31,8 @@ type EventUnlockPayment struct {
 	CoinId                int64           `json:"coin_id"`
 	UnlockStatus          string          `json:"unlock_status"`
 	SourceFileId          *int64          `json:"source_file_id"`
+	RefundableAmount      decimal.Decimal `json:"refundable_amount"`
+	UnlockCheckStatus     string          `json:"unlock_check_status"`
 }
 
 func GetEventUnlockPaymentsByPayloadCid(payloadCid string, limit, offset string) ([]*EventUnlockPayment, error) {
@@ -52,16 +55,20 @@ func GetEventUnlockPaymentsByPayloadCid(payloadCid string, limit, offset string)
 	return dealFiles, nil
 }
 
-func UpdateUnlockAmount(srcFileId, dealId int64, txHash, blockNo string, unlockedFee decimal.Decimal) error {
-	sql := "update event_unlock_payment set tx_hash=?,block_no=?,unlock_to_admin_amount=locked_fee_before_unlock-?,locked_fee_after_unlock=?,update_at=? where source_file_id=? and deal_id=?"
+// UpdateUnlockAmount stores the amount unlocked from the source file by the deal, and its locked fee before and after the unlock
+func UpdateUnlockAmount(srcFileId, dealId int64, txHash, blockNo, unlockFromAddress, unlockToAdminAddress string, lockedFeeBeforeUnlock, unlockedFee, lockedFeeAfterUnlock decimal.Decimal) error {
+	sql := "update event_unlock_payment set tx_hash=?,block_no=?,unlock_from_address=?,unlock_to_admin_address=?,unlock_to_admin_amount=?,locked_fee_before_unlock=?,locked_fee_after_unlock=?,update_at=? where source_file_id=? and deal_id=?"
 
 	curUtcMilliSec := utils.GetCurrentUtcMilliSecond()
 
 	params := []interface{}{}
 	params = append(params, txHash)
 	params = append(params, blockNo)
-	params = append(params, unlockedFee)
-	params = append(params, unlockedFee)
+	params = append(params, unlockFromAddress)
+	params = append(params, unlockToAdminAddress)
+	params = append(params, unlockedFee.String())
+	params = append(params, lockedFeeBeforeUnlock)
+	params = append(params, lockedFeeAfterUnlock)
 	params = append(params, curUtcMilliSec)
 	params = append(params, srcFileId)
 	params = append(params, dealId)
@@ -74,3 +81,54 @@ func UpdateUnlockAmount(srcFileId, dealId int64, txHash, blockNo string, unlocke
 
 	return nil
 }
+
+func GetEventUnlockPaymentsByDealId(dealId int64) ([]*EventUnlockPayment, error) {
+	var eventUnlockPayments []*EventUnlockPayment
+	sql := "select a.* from event_unlock_payment a where a.deal_id=? order by a.id"
+	err := database.GetDB().Raw(sql, dealId).Scan(&eventUnlockPayments).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return eventUnlockPayments, nil
+}
+
+// GetDealIdsByUnlockTxHash returns the deals unlocked by the tx, to decode the tx when the contract emits no UnlockCarPayment event
+func GetDealIdsByUnlockTxHash(txHash string) ([]string, error) {
+	var eventUnlockPayments []*EventUnlockPayment
+	sql := "select distinct a.deal_id from event_unlock_payment a where a.tx_hash=? order by a.deal_id"
+	err := database.GetDB().Raw(sql, txHash).Scan(&eventUnlockPayments).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	dealIds := []string{}
+	for _, eventUnlockPayment := range eventUnlockPayments {
+		dealIds = append(dealIds, strconv.FormatInt(eventUnlockPayment.DealId, 10))
+	}
+
+	return dealIds, nil
+}
+
+// UpdateUnlockCheck stores the locked fee of the source file on chain after the unlock tx, which is what a refund would return,
+// and whether it is the same as the locked fee after unlock computed from the tx
+func UpdateUnlockCheck(payloadCid, txHash string, refundableAmount decimal.Decimal, unlockCheckStatus string) error {
+	sql := "update event_unlock_payment set refundable_amount=?,unlock_check_status=?,update_at=? where payload_cid=? and tx_hash=?"
+
+	params := []interface{}{}
+	params = append(params, refundableAmount)
+	params = append(params, unlockCheckStatus)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, payloadCid)
+	params = append(params, txHash)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+	"multi-chain-storage/events"
+	"strings"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/jinzhu/gorm"
+)
+
+type FileStatusHistory struct {
+	ID           int64  `json:"id"`
+	SourceFileId int64  `json:"source_file_id"`
+	FromStatus   string `json:"from_status"`
+	ToStatus     string `json:"to_status"`
+	Reason       string `json:"reason"`
+	CreateAt     int64  `json:"create_at"`
+}
+
+// the lifecycle of a source file:
+// Uploaded -> Paid -> Aggregated -> DealSent -> Active -> Unlocked -> Refunding -> Refunded,
+// and the locked payment can be refunded on expiry from any state after Paid, or from Uploaded when the lock payment is rejected by the quote check
+var fileStatusTransitions = map[string][]string{
+	constants.FILE_STATUS_UPLOADED:   {constants.FILE_STATUS_PAID, constants.FILE_STATUS_REFUNDING, constants.FILE_STATUS_EXPIRED},
+	constants.FILE_STATUS_PAID:       {constants.FILE_STATUS_AGGREGATED, constants.FILE_STATUS_REFUNDING, constants.FILE_STATUS_EXPIRED},
+	constants.FILE_STATUS_AGGREGATED: {constants.FILE_STATUS_DEAL_SENT, constants.FILE_STATUS_REFUNDING, constants.FILE_STATUS_EXPIRED},
+	constants.FILE_STATUS_DEAL_SENT:  {constants.FILE_STATUS_ACTIVE, constants.FILE_STATUS_REFUNDING, constants.FILE_STATUS_EXPIRED},
+	constants.FILE_STATUS_ACTIVE:     {constants.FILE_STATUS_UNLOCKED, constants.FILE_STATUS_REFUNDING, constants.FILE_STATUS_EXPIRED},
+	constants.FILE_STATUS_UNLOCKED:   {constants.FILE_STATUS_REFUNDING},
+	constants.FILE_STATUS_REFUNDING:  {constants.FILE_STATUS_REFUNDED, constants.FILE_STATUS_EXPIRED},
+}
+
+var fileStatusOrders = map[string]int{
+	constants.FILE_STATUS_UPLOADED:   1,
+	constants.FILE_STATUS_PAID:       2,
+	constants.FILE_STATUS_AGGREGATED: 3,
+	constants.FILE_STATUS_DEAL_SENT:  4,
+	constants.FILE_STATUS_ACTIVE:     5,
+	constants.FILE_STATUS_UNLOCKED:   6,
+	constants.FILE_STATUS_REFUNDING:  7,
+	constants.FILE_STATUS_REFUNDED:   8,
+	constants.FILE_STATUS_EXPIRED:    8,
+}
+
+func isFileStatusTransitionValid(fromStatus, toStatus string) bool {
+	// source files created before the lifecycle was tracked can start from any status
+	if fromStatus == "" {
+		return true
+	}
+
+	for _, status := range fileStatusTransitions[fromStatus] {
+		if status == toStatus {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TransitFileStatus(sourceFileId int64, toStatus, reason string) error {
+	db := database.GetDBTransaction()
+	fileStatusHistory, err := TransitFileStatusInTransaction(db, sourceFileId, toStatus, reason)
+	if err != nil {
+		db.Rollback()
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = db.Commit().Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	PublishFileStatusHistory(fileStatusHistory)
+	return nil
+}
+
+// TransitFileStatusInTransaction moves the source file to toStatus and appends the transition to file_status_history,
+// a transition to the current status or to a status already passed is ignored, since the same deal file may be sent
+// again when it is renewed, other transitions not in the lifecycle are rejected.
+// The history saved is returned to be published by PublishFileStatusHistory after the transaction is committed,
+// it is nil when the transition is ignored
+func TransitFileStatusInTransaction(db *gorm.DB, sourceFileId int64, toStatus, reason string) (*FileStatusHistory, error) {
+	var sourceFiles []*SourceFile
+	err := db.Raw("select a.* from source_file a where a.id=? for update", sourceFileId).Scan(&sourceFiles).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(sourceFiles) == 0 {
+		err := fmt.Errorf("source file:%d not exists", sourceFileId)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	fromStatus := sourceFiles[0].FileStatus
+	if fromStatus == toStatus {
+		return nil, nil
+	}
+
+	if fromStatus != "" && fileStatusOrders[toStatus] < fileStatusOrders[fromStatus] {
+		logs.GetLogger().Info("source file:", sourceFileId, " is already ", fromStatus, ", not changed to ", toStatus)
+		return nil, nil
+	}
+
+	if !isFileStatusTransitionValid(fromStatus, toStatus) {
+		err := fmt.Errorf("source file:%d cannot be changed from %s to %s", sourceFileId, fromStatus, toStatus)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	sql := "update source_file set file_status=?,update_at=? where id=?"
+	err = db.Exec(sql, toStatus, currentUtcMilliSec, sourceFileId).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	fileStatusHistory := FileStatusHistory{
+		SourceFileId: sourceFileId,
+		FromStatus:   fromStatus,
+		ToStatus:     toStatus,
+		Reason:       reason,
+		CreateAt:     currentUtcMilliSec,
+	}
+
+	err = database.SaveOneInTransaction(db, &fileStatusHistory)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	err = createWebhookDeliveriesInTransaction(db, sourceFiles[0], toStatus, reason, currentUtcMilliSec)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	logs.GetLogger().Info("source file:", sourceFileId, " changed from ", fromStatus, " to ", toStatus, ", ", reason)
+	return &fileStatusHistory, nil
+}
+
+// TransitFileStatusByDealFileId changes all the source files in the deal file, failures are only logged
+func TransitFileStatusByDealFileId(dealFileId int64, toStatus, reason string) {
+	sourceFiles, err := GetSourceFilesByDealFileId(dealFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return
+	}
+
+	for _, sourceFile := range sourceFiles {
+		err = TransitFileStatus(sourceFile.ID, toStatus, reason)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+	}
+}
+
+func GetFileStatusHistoriesBySourceFileId(sourceFileId int64) ([]*FileStatusHistory, error) {
+	var fileStatusHistories []*FileStatusHistory
+	sql := "select a.* from file_status_history a where a.source_file_id=? order by a.id"
+	err := database.GetDB().Raw(sql, sourceFileId).Scan(&fileStatusHistories).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return fileStatusHistories, nil
+}
+
+// PublishFileStatusHistory publishes the status change to the event subscribers of the wallets uploaded the source file
+func PublishFileStatusHistory(fileStatusHistory *FileStatusHistory) {
+	if fileStatusHistory == nil {
+		return
+	}
+
+	fileStatusEvents, err := getFileStatusEvents("a.id=?", 0, fileStatusHistory.ID)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return
+	}
+
+	for _, fileStatusEvent := range fileStatusEvents {
+		events.Publish(fileStatusEvent)
+	}
+}
+
+// GetFileStatusEventsByWalletAddress returns the status changes of the files of the wallet after lastEventId
+func GetFileStatusEventsByWalletAddress(walletAddress string, lastEventId int64, limit int) ([]*events.FileStatusEvent, error) {
+	fileStatusEvents, err := getFileStatusEvents("lower(b.wallet_address)=? and a.id>?", limit, strings.ToLower(walletAddress), lastEventId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return fileStatusEvents, nil
+}
+
+func getFileStatusEvents(condition string, limit int, params ...interface{}) ([]*events.FileStatusEvent, error) {
+	sql := "select distinct a.id,lower(b.wallet_address) wallet_address,a.source_file_id,c.payload_cid,a.from_status,a.to_status,a.reason,a.create_at\n" +
+		"from file_status_history a, source_file_upload_history b, source_file c\n" +
+		"where a.source_file_id=b.source_file_id and a.source_file_id=c.id and " + condition + " order by a.id"
+	if limit > 0 {
+		sql = sql + " limit ?"
+		params = append(params, limit)
+	}
+
+	var fileStatusEvents []*events.FileStatusEvent
+	err := database.GetDB().Raw(sql, params...).Scan(&fileStatusEvents).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return fileStatusEvents, nil
+}
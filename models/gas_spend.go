@@ -0,0 +1,40 @@
+package models
+
+import (
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/shopspring/decimal"
+)
+
+type GasSpend struct {
+	ID       int64           `json:"id"`
+	TxJob    string          `json:"tx_job"`
+	TxHash   string          `json:"tx_hash"`
+	TxStatus uint64          `json:"tx_status"`
+	GasUsed  uint64          `json:"gas_used"`
+	GasPrice string          `json:"gas_price"`
+	Fee      decimal.Decimal `json:"fee"`
+	CreateAt int64           `json:"create_at"`
+}
+
+type gasSpendSum struct {
+	Fee decimal.NullDecimal `json:"fee"`
+}
+
+// GetGasSpent returns the MATIC paid for gas by the transactions of the job sent since createAtMin
+func GetGasSpent(txJob string, createAtMin int64) (decimal.Decimal, error) {
+	var gasSpendSum gasSpendSum
+	sql := "select sum(a.fee) fee from gas_spend a where a.tx_job=? and a.create_at>=?"
+	err := database.GetDB().Raw(sql, txJob, createAtMin).Scan(&gasSpendSum).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return decimal.Zero, err
+	}
+
+	if !gasSpendSum.Fee.Valid {
+		return decimal.Zero, nil
+	}
+
+	return gasSpendSum.Fee.Decimal, nil
+}
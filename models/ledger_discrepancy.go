@@ -0,0 +1,340 @@
+package models
+
+import (
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/shopspring/decimal"
+)
+
+// LedgerDiscrepancy is a field in database that is not the same as the payment contract, record id is the id of the row having the field
+type LedgerDiscrepancy struct {
+	ID           int64  `json:"id"`
+	SourceFileId int64  `json:"source_file_id"`
+	PayloadCid   string `json:"payload_cid"`
+	Field        string `json:"field"`
+	RecordId     int64  `json:"record_id"`
+	DbValue      string `json:"db_value"`
+	ChainValue   string `json:"chain_value"`
+	Status       string `json:"status"`
+	ReviewedBy   string `json:"reviewed_by"`
+	CreateAt     int64  `json:"create_at"`
+	UpdateAt     int64  `json:"update_at"`
+}
+
+type ReconciliationRun struct {
+	ID                  int64  `json:"id"`
+	CheckedCount        int64  `json:"checked_count"`
+	FailedCount         int64  `json:"failed_count"`
+	DiscrepancyCount    int64  `json:"discrepancy_count"`
+	NewDiscrepancyCount int64  `json:"new_discrepancy_count"`
+	ResolvedCount       int64  `json:"resolved_count"`
+	Summary             string `json:"summary"`
+	StartAt             int64  `json:"start_at"`
+	EndAt               int64  `json:"end_at"`
+}
+
+// LedgerBalance is the locked fee on chain of a payload cid set by an operator from a discrepancy,
+// it is kept apart from the lock and unlock events, which still show what was locked and unlocked
+type LedgerBalance struct {
+	ID                  int64           `json:"id"`
+	PayloadCid          string          `json:"payload_cid"`
+	LockedFee           decimal.Decimal `json:"locked_fee"`
+	LedgerDiscrepancyId int64           `json:"ledger_discrepancy_id"`
+	ReviewedBy          string          `json:"reviewed_by"`
+	CreateAt            int64           `json:"create_at"`
+}
+
+// GetSourceFilesToReconcile returns the first source file of each payload cid not refunded or expired yet,
+// since the payment is locked by payload cid
+func GetSourceFilesToReconcile() ([]*SourceFile, error) {
+	var sourceFiles []*SourceFile
+	sql := "select a.* from source_file a where a.id in (select min(b.id) from source_file b where b.file_status not in (?,?) group by b.payload_cid) order by a.id"
+	err := database.GetDB().Raw(sql, constants.FILE_STATUS_REFUNDED, constants.FILE_STATUS_EXPIRED).Scan(&sourceFiles).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return sourceFiles, nil
+}
+
+// GetLatestUnlockedEventUnlockPaymentByPayloadCid returns the last unlock of the source file, its locked fee after unlock is the one expected on chain
+func GetLatestUnlockedEventUnlockPaymentByPayloadCid(payloadCid string) (*EventUnlockPayment, error) {
+	var eventUnlockPayments []*EventUnlockPayment
+	sql := "select a.* from event_unlock_payment a where a.payload_cid=? and a.tx_hash is not null and a.tx_hash!='' order by a.update_at desc,a.id desc limit 1"
+	err := database.GetDB().Raw(sql, payloadCid).Scan(&eventUnlockPayments).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(eventUnlockPayments) == 0 {
+		return nil, nil
+	}
+
+	return eventUnlockPayments[0], nil
+}
+
+// GetLatestLedgerBalanceByPayloadCid returns the last locked fee set by an operator for the payload cid
+func GetLatestLedgerBalanceByPayloadCid(payloadCid string) (*LedgerBalance, error) {
+	var ledgerBalances []*LedgerBalance
+	sql := "select a.* from ledger_balance a where a.payload_cid=? order by a.create_at desc,a.id desc limit 1"
+	err := database.GetDB().Raw(sql, payloadCid).Scan(&ledgerBalances).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(ledgerBalances) == 0 {
+		return nil, nil
+	}
+
+	return ledgerBalances[0], nil
+}
+
+// IsPaymentRefundedByPayloadCid returns whether the payment of the payload cid is refunded or refunded after expired,
+// both return all the locked fee left on chain
+func IsPaymentRefundedByPayloadCid(payloadCid string) (bool, error) {
+	var recordCount recordCount
+	sql := "select count(*) count from (" +
+		"select a.id from refund_settlement a where a.payload_cid=? and a.refund_status=? " +
+		"union all select b.id from event_expire_payment b where b.payload_cid=?) c"
+	err := database.GetDB().Raw(sql, payloadCid, constants.PROCESS_STATUS_UNLOCK_REFUNDED, payloadCid).Scan(&recordCount).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	return recordCount.Count > 0, nil
+}
+
+// SaveLedgerDiscrepancy updates the open discrepancy of the same field of the source file, or adds it when there is none,
+// it returns whether it is added
+func SaveLedgerDiscrepancy(ledgerDiscrepancy *LedgerDiscrepancy) (bool, error) {
+	var ledgerDiscrepancies []*LedgerDiscrepancy
+	sql := "select a.* from ledger_discrepancy a where a.source_file_id=? and a.field=? and a.status=?"
+	err := database.GetDB().Raw(sql, ledgerDiscrepancy.SourceFileId, ledgerDiscrepancy.Field, constants.LEDGER_DISCREPANCY_STATUS_OPEN).Scan(&ledgerDiscrepancies).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	ledgerDiscrepancy.Status = constants.LEDGER_DISCREPANCY_STATUS_OPEN
+	ledgerDiscrepancy.CreateAt = currentUtcMilliSec
+	ledgerDiscrepancy.UpdateAt = currentUtcMilliSec
+
+	isNew := len(ledgerDiscrepancies) == 0
+	if !isNew {
+		ledgerDiscrepancy.ID = ledgerDiscrepancies[0].ID
+		ledgerDiscrepancy.CreateAt = ledgerDiscrepancies[0].CreateAt
+	}
+
+	err = database.SaveOne(ledgerDiscrepancy)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	return isNew, nil
+}
+
+// ResolveLedgerDiscrepancies closes the open discrepancies of the source file whose fields are the same as chain now
+func ResolveLedgerDiscrepancies(sourceFileId int64, openFields []string) (int64, error) {
+	sql := "update ledger_discrepancy set status=?,update_at=? where source_file_id=? and status=?"
+
+	params := []interface{}{}
+	params = append(params, constants.LEDGER_DISCREPANCY_STATUS_RESOLVED)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, sourceFileId)
+	params = append(params, constants.LEDGER_DISCREPANCY_STATUS_OPEN)
+
+	if len(openFields) > 0 {
+		sql = sql + " and field not in (?)"
+		params = append(params, openFields)
+	}
+
+	result := database.GetDB().Exec(sql, params...)
+	err := result.Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	return result.RowsAffected, nil
+}
+
+func GetLedgerDiscrepancyById(id int64) (*LedgerDiscrepancy, error) {
+	var ledgerDiscrepancies []*LedgerDiscrepancy
+	sql := "select a.* from ledger_discrepancy a where a.id=?"
+	err := database.GetDB().Raw(sql, id).Scan(&ledgerDiscrepancies).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(ledgerDiscrepancies) == 0 {
+		return nil, nil
+	}
+
+	return ledgerDiscrepancies[0], nil
+}
+
+func GetLedgerDiscrepancies(status string, limit, offset int) ([]*LedgerDiscrepancy, error) {
+	sql := "select a.* from ledger_discrepancy a"
+	params := []interface{}{}
+	if status != "" {
+		sql = sql + " where a.status=?"
+		params = append(params, status)
+	}
+	sql = sql + " order by a.id desc limit ? offset ?"
+	params = append(params, limit)
+	params = append(params, offset)
+
+	var ledgerDiscrepancies []*LedgerDiscrepancy
+	err := database.GetDB().Raw(sql, params...).Scan(&ledgerDiscrepancies).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return ledgerDiscrepancies, nil
+}
+
+func GetLedgerDiscrepancyCountByStatus(status string) (int64, error) {
+	var recordCount recordCount
+	sql := "select count(*) count from ledger_discrepancy a where a.status=?"
+	err := database.GetDB().Raw(sql, status).Scan(&recordCount).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	return recordCount.Count, nil
+}
+
+// ReviewLedgerDiscrepancy moves an open discrepancy to status, it returns false when the discrepancy is not open any more
+func ReviewLedgerDiscrepancy(id int64, status, reviewedBy string) (bool, error) {
+	sql := "update ledger_discrepancy set status=?,reviewed_by=?,update_at=? where id=? and status=?"
+
+	params := []interface{}{}
+	params = append(params, status)
+	params = append(params, reviewedBy)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, id)
+	params = append(params, constants.LEDGER_DISCREPANCY_STATUS_OPEN)
+
+	result := database.GetDB().Exec(sql, params...)
+	err := result.Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// ApplyLedgerDiscrepancy sets the field in database to the value on chain and marks the discrepancy applied in one transaction,
+// a locked fee is saved as a new ledger balance instead of changing the lock and unlock events,
+// a missing lock payment is not applied here since it is created from chain by CreateEventLockPayment
+func ApplyLedgerDiscrepancy(ledgerDiscrepancy *LedgerDiscrepancy, reviewedBy string) error {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	chainValue := ledgerDiscrepancy.ChainValue
+
+	var sql string
+	params := []interface{}{}
+	switch ledgerDiscrepancy.Field {
+	case constants.LEDGER_FIELD_LOCKED_FEE:
+		lockedFee, err := decimal.NewFromString(chainValue)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+
+		sql = "insert into ledger_balance(payload_cid,locked_fee,ledger_discrepancy_id,reviewed_by,create_at) values(?,?,?,?,?)"
+		params = append(params, ledgerDiscrepancy.PayloadCid)
+		params = append(params, lockedFee)
+		params = append(params, ledgerDiscrepancy.ID)
+		params = append(params, reviewedBy)
+		params = append(params, currentUtcMilliSec)
+	case constants.LEDGER_FIELD_OWNER:
+		sql = "update event_lock_payment set address_from=? where id=?"
+		params = append(params, chainValue)
+	case constants.LEDGER_FIELD_RECIPIENT:
+		sql = "update event_lock_payment set address_to=? where id=?"
+		params = append(params, chainValue)
+	case constants.LEDGER_FIELD_DEADLINE:
+		sql = "update event_lock_payment set deadline=? where id=?"
+		params = append(params, chainValue)
+	case constants.LEDGER_FIELD_TOKEN_ADDRESS:
+		coin, err := FindCoinByCoinAddress(chainValue)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+
+		sql = "update event_lock_payment set token_address=?,coin_id=?,network_id=? where id=?"
+		params = append(params, chainValue)
+		params = append(params, coin.ID)
+		params = append(params, coin.NetworkId)
+	case constants.LEDGER_FIELD_SOURCE_FILE_STATUS:
+		sql = "update source_file set status=?,update_at=? where id=?"
+		params = append(params, chainValue)
+		params = append(params, currentUtcMilliSec)
+	default:
+		err := fmt.Errorf("discrepancy of %s can not be applied", ledgerDiscrepancy.Field)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if ledgerDiscrepancy.Field != constants.LEDGER_FIELD_LOCKED_FEE {
+		params = append(params, ledgerDiscrepancy.RecordId)
+	}
+
+	db := database.GetDBTransaction()
+	err := db.Exec(sql, params...).Error
+	if err != nil {
+		db.Rollback()
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	sql = "update ledger_discrepancy set status=?,reviewed_by=?,update_at=? where id=? and status=?"
+	result := db.Exec(sql, constants.LEDGER_DISCREPANCY_STATUS_APPLIED, reviewedBy, currentUtcMilliSec, ledgerDiscrepancy.ID, constants.LEDGER_DISCREPANCY_STATUS_OPEN)
+	if result.Error != nil {
+		db.Rollback()
+		logs.GetLogger().Error(result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected != 1 {
+		db.Rollback()
+		err := fmt.Errorf("ledger discrepancy:%d is not %s", ledgerDiscrepancy.ID, constants.LEDGER_DISCREPANCY_STATUS_OPEN)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = db.Commit().Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func GetReconciliationRuns(limit, offset int) ([]*ReconciliationRun, error) {
+	var reconciliationRuns []*ReconciliationRun
+	sql := "select a.* from reconciliation_run a order by a.id desc limit ? offset ?"
+	err := database.GetDB().Raw(sql, limit, offset).Scan(&reconciliationRuns).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return reconciliationRuns, nil
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+type LocalFile struct {
+	ID           int64  `json:"id"`
+	FileType     string `json:"file_type"`
+	FilePath     string `json:"file_path"`
+	FileSize     int64  `json:"file_size"`
+	SourceFileId *int64 `json:"source_file_id"`
+	DealFileId   *int64 `json:"deal_file_id"`
+	Status       string `json:"status"`
+	Note         string `json:"note"`
+	CreateAt     int64  `json:"create_at"`
+	UpdateAt     int64  `json:"update_at"`
+}
+
+type LocalFileUsage struct {
+	FileType  string `json:"file_type"`
+	FileCount int64  `json:"file_count"`
+	TotalSize int64  `json:"total_size"`
+}
+
+func GetLocalFileUsages() ([]*LocalFileUsage, error) {
+	var localFileUsages []*LocalFileUsage
+	sql := "select a.file_type,count(*) file_count,sum(a.file_size) total_size from local_file a where a.status=? group by a.file_type"
+	err := database.GetDB().Raw(sql, constants.LOCAL_FILE_STATUS_PRESENT).Scan(&localFileUsages).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return localFileUsages, nil
+}
+
+// GetCarLocalFiles2Delete gets the car directories whose car files are pinned to ipfs server and have at least activeDealsMin active deals,
+// all signed by the dao, since dao signers verify the source files by the car bytes
+func GetCarLocalFiles2Delete(activeDealsMin int) ([]*LocalFile, error) {
+	sql := "select a.* from local_file a, deal_file b where a.deal_file_id=b.id and a.status=? and a.file_type in (?,?) and b.pin_status=?\n" +
+		"and (select count(*) from offline_deal c where c.deal_file_id=b.id and c.status=?)>=?\n" +
+		"and not exists (select 1 from offline_deal d where d.deal_file_id=b.id and d.status=? and d.deal_id not in (select e.deal_id from dao_fetched_deal e))"
+
+	params := []interface{}{}
+	params = append(params, constants.LOCAL_FILE_STATUS_PRESENT)
+	params = append(params, constants.LOCAL_FILE_TYPE_CAR)
+	params = append(params, constants.LOCAL_FILE_TYPE_RENEW)
+	params = append(params, constants.IPFS_File_PINNED_STATUS)
+	params = append(params, constants.DEAL_STATUS_ACTIVE)
+	params = append(params, activeDealsMin)
+	params = append(params, constants.DEAL_STATUS_ACTIVE)
+
+	var localFiles []*LocalFile
+	err := database.GetDB().Raw(sql, params...).Scan(&localFiles).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return localFiles, nil
+}
+
+// GetSourceLocalFiles2Delete gets the uploaded source files pinned to ipfs server, whose car files have at least activeDealsMin active deals
+func GetSourceLocalFiles2Delete(activeDealsMin int) ([]*LocalFile, error) {
+	sql := "select a.* from local_file a, source_file b where a.source_file_id=b.id and a.status=? and a.file_type=? and b.pin_status=?\n" +
+		"and exists (select 1 from source_file_deal_file_map c where c.source_file_id=b.id\n" +
+		"and (select count(*) from offline_deal d where d.deal_file_id=c.deal_file_id and d.status=?)>=?)"
+
+	params := []interface{}{}
+	params = append(params, constants.LOCAL_FILE_STATUS_PRESENT)
+	params = append(params, constants.LOCAL_FILE_TYPE_SOURCE)
+	params = append(params, constants.IPFS_File_PINNED_STATUS)
+	params = append(params, constants.DEAL_STATUS_ACTIVE)
+	params = append(params, activeDealsMin)
+
+	var localFiles []*LocalFile
+	err := database.GetDB().Raw(sql, params...).Scan(&localFiles).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return localFiles, nil
+}
+
+func GetLocalFilesByTypeCreatedBefore(fileType string, createAtMax int64) ([]*LocalFile, error) {
+	sql := "select a.* from local_file a where a.status=? and a.file_type=? and a.create_at<?"
+
+	var localFiles []*LocalFile
+	err := database.GetDB().Raw(sql, constants.LOCAL_FILE_STATUS_PRESENT, fileType, createAtMax).Scan(&localFiles).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return localFiles, nil
+}
+
+func UpdateLocalFileDeleted(id int64, note string) error {
+	sql := "update local_file set status=?,note=?,update_at=? where id=?"
+
+	params := []interface{}{}
+	params = append(params, constants.LOCAL_FILE_STATUS_DELETED)
+	params = append(params, note)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, id)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+type MinerReputation struct {
+	ID                 int64   `json:"id"`
+	MinerFid           string  `json:"miner_fid"`
+	DealCount          int     `json:"deal_count"`
+	ActiveCount        int     `json:"active_count"`
+	ErrorCount         int     `json:"error_count"`
+	SlashedCount       int     `json:"slashed_count"`
+	UnlockSuccessCount int     `json:"unlock_success_count"`
+	UnlockFailCount    int     `json:"unlock_fail_count"`
+	AvgActiveMinutes   int64   `json:"avg_active_minutes"`
+	Score              float64 `json:"score"`
+	CreateAt           int64   `json:"create_at"`
+	UpdateAt           int64   `json:"update_at"`
+}
+
+func GetMinerDealStatistics() ([]*MinerReputation, error) {
+	var minerReputations []*MinerReputation
+	sql := "select a.miner_fid,count(*) deal_count," +
+		"sum(case when a.status=? then 1 else 0 end) active_count," +
+		"sum(case when a.status in (?,?) then 1 else 0 end) error_count," +
+		"sum(case when a.status=? then 1 else 0 end) slashed_count," +
+		"sum(case when a.unlock_status=? then 1 else 0 end) unlock_success_count," +
+		"sum(case when a.unlock_status=? then 1 else 0 end) unlock_fail_count," +
+		"ifnull(avg(case when a.active_at is not null then (a.active_at-a.create_at)/60000 end),0) avg_active_minutes " +
+		"from offline_deal a group by a.miner_fid"
+
+	params := []interface{}{}
+	params = append(params, constants.DEAL_STATUS_ACTIVE)
+	params = append(params, constants.DEAL_STATUS_ERROR, constants.DEAL_STATUS_FAILING)
+	params = append(params, constants.DEAL_STATUS_SLASHED)
+	params = append(params, constants.OFFLINE_DEAL_UNLOCK_STATUS_UNLOCKED)
+	params = append(params, constants.OFFLINE_DEAL_UNLOCK_STATUS_UNLOCK_FAILED)
+
+	err := database.GetDB().Raw(sql, params...).Scan(&minerReputations).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return minerReputations, nil
+}
+
+func GetMinerReputations() ([]*MinerReputation, error) {
+	var minerReputations []*MinerReputation
+	sql := "select a.* from miner_reputation a order by a.score desc"
+	err := database.GetDB().Raw(sql).Scan(&minerReputations).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return minerReputations, nil
+}
+
+func SaveMinerReputation(minerReputation *MinerReputation) error {
+	sql := "insert into miner_reputation (miner_fid,deal_count,active_count,error_count,slashed_count,unlock_success_count,unlock_fail_count,avg_active_minutes,score,create_at,update_at) " +
+		"values (?,?,?,?,?,?,?,?,?,?,?) " +
+		"on duplicate key update deal_count=values(deal_count),active_count=values(active_count),error_count=values(error_count),slashed_count=values(slashed_count)," +
+		"unlock_success_count=values(unlock_success_count),unlock_fail_count=values(unlock_fail_count),avg_active_minutes=values(avg_active_minutes),score=values(score),update_at=values(update_at)"
+
+	params := []interface{}{}
+	params = append(params, minerReputation.MinerFid)
+	params = append(params, minerReputation.DealCount)
+	params = append(params, minerReputation.ActiveCount)
+	params = append(params, minerReputation.ErrorCount)
+	params = append(params, minerReputation.SlashedCount)
+	params = append(params, minerReputation.UnlockSuccessCount)
+	params = append(params, minerReputation.UnlockFailCount)
+	params = append(params, minerReputation.AvgActiveMinutes)
+	params = append(params, minerReputation.Score)
+	params = append(params, minerReputation.CreateAt)
+	params = append(params, minerReputation.UpdateAt)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
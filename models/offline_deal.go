@@ -24,6 +24,7 @@ type OfflineDeal struct {
 	CreateAt     int64  `json:"create_at"`
 	UpdateAt     int64  `json:"update_at"`
 	UnlockAt     int64  `json:"unlock_at"`
+	ActiveAt     *int64 `json:"active_at"`
 }
 
 func GetOfflineDealsBySourceFileId(sourceFileId int64) ([]*OfflineDeal, error) {
@@ -104,6 +105,20 @@ func GetOfflineDealsByDealFileId(dealFileId int64) ([]*OfflineDeal, error) {
 	return offlineDeals, nil
 }
 
+// GetOfflineDealsByPayloadCid returns the deals with a deal id of all the source files of the payload cid
+func GetOfflineDealsByPayloadCid(payloadCid string) ([]*OfflineDeal, error) {
+	var offlineDeals []*OfflineDeal
+	sql := "select distinct a.* from offline_deal a, source_file_deal_file_map b, source_file c where c.payload_cid=? and b.source_file_id=c.id and a.deal_file_id=b.deal_file_id and a.deal_id>0"
+	err := database.GetDB().Raw(sql, payloadCid).Scan(&offlineDeals).Error
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return offlineDeals, nil
+}
+
 func GetOfflineDealsByDealFileIds(dealFileIds []int64) ([]*OfflineDeal, error) {
 	dealFileIdsStr := ""
 
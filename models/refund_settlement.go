@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// RefundSettlement is how the remaining payment of a source file is refunded after its deals are settled,
+// the refund amount is all the locked fee left after the active replicas are unlocked, not a share of it
+type RefundSettlement struct {
+	ID             int64           `json:"id"`
+	SourceFileId   int64           `json:"source_file_id"`
+	DealFileId     int64           `json:"deal_file_id"`
+	PayloadCid     string          `json:"payload_cid"`
+	PaidReplicas   int             `json:"paid_replicas"`
+	ActiveReplicas int             `json:"active_replicas"`
+	LockedFee      decimal.Decimal `json:"locked_fee"`
+	UnlockedFee    decimal.Decimal `json:"unlocked_fee"`
+	RefundAmount   decimal.Decimal `json:"refund_amount"`
+	RefundStatus   string          `json:"refund_status"`
+	TxHash         string          `json:"tx_hash"`
+	CreateAt       int64           `json:"create_at"`
+}
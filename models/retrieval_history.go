@@ -0,0 +1,99 @@
+package models
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+type RetrievalHistory struct {
+	ID              int64  `json:"id"`
+	SourceFileId    int64  `json:"source_file_id"`
+	DealFileId      int64  `json:"deal_file_id"`
+	MinerFid        string `json:"miner_fid"`
+	Status          string `json:"status"`
+	Note            string `json:"note"`
+	ElapsedMilliSec int64  `json:"elapsed_milli_sec"`
+	CreateAt        int64  `json:"create_at"`
+	UpdateAt        int64  `json:"update_at"`
+}
+
+// RetrievalJob is a retrieval of a source file queued by its owner, run by the retrieve file scheduler
+type RetrievalJob struct {
+	ID            int64  `json:"id"`
+	SourceFileId  int64  `json:"source_file_id"`
+	WalletAddress string `json:"wallet_address"`
+	Status        string `json:"status"`
+	FilePath      string `json:"-"`
+	Note          string `json:"note"`
+	CreateAt      int64  `json:"create_at"`
+	UpdateAt      int64  `json:"update_at"`
+}
+
+func GetLatestRetrievalJobBySourceFileId(sourceFileId int64) (*RetrievalJob, error) {
+	var retrievalJobs []*RetrievalJob
+	sql := "select * from retrieval_job where source_file_id=? order by id desc limit 1"
+	err := database.GetDB().Raw(sql, sourceFileId).Scan(&retrievalJobs).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(retrievalJobs) == 0 {
+		return nil, nil
+	}
+
+	return retrievalJobs[0], nil
+}
+
+func GetRetrievalJobsByStatus(status string) ([]*RetrievalJob, error) {
+	var retrievalJobs []*RetrievalJob
+	sql := "select * from retrieval_job where status=? order by id"
+	err := database.GetDB().Raw(sql, status).Scan(&retrievalJobs).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return retrievalJobs, nil
+}
+
+// ClaimRetrievalJob moves a queued retrieval job to retrieving, it returns false when the job has been claimed by another instance
+func ClaimRetrievalJob(id int64) (bool, error) {
+	sql := "update retrieval_job set status=?,update_at=? where id=? and status=?"
+
+	params := []interface{}{}
+	params = append(params, constants.RETRIEVAL_STATUS_RETRIEVING)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, id)
+	params = append(params, constants.RETRIEVAL_STATUS_QUEUED)
+
+	result := database.GetDB().Exec(sql, params...)
+	if result.Error != nil {
+		logs.GetLogger().Error(result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func UpdateRetrievalJob(id int64, status, filePath, note string) error {
+	sql := "update retrieval_job set status=?,file_path=?,note=?,update_at=? where id=?"
+
+	params := []interface{}{}
+	params = append(params, status)
+	params = append(params, filePath)
+	params = append(params, note)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, id)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -29,6 +29,9 @@ type SourceFile struct {
 	RefundAmount *decimal.Decimal `json:"refund_amount"`
 	RefundAt     *int64           `json:"refund_at"`
 	RefundTxHash *string          `json:"refund_tx_hash"`
+	AutoRenew    bool             `json:"auto_renew"`
+	CarGroupId   *int64           `json:"car_group_id"`
+	FileStatus   string           `json:"file_status"`
 	CreateAt     int64            `json:"create_at"`
 	UpdateAt     int64            `json:"update_at"`
 }
@@ -135,8 +138,22 @@ func GetSourceFileExtByPayloadCid(payloadCid, walletAddress string) (*SourceFile
 
 func GetSourceFilesNeed2Car() ([]*SourceFileExt, error) {
 	var sourceFiles []*SourceFileExt
-	sql := "select a.*,b.locked_fee from source_file a, event_lock_payment b where b.source_file_id=a.id and a.status=? and a.file_type=?"
-	err := database.GetDB().Raw(sql, constants.SOURCE_FILE_STATUS_PAID, constants.SOURCE_FILE_TYPE_NORMAL).Scan(&sourceFiles).Error
+	sql := "select a.*,b.locked_fee from source_file a, event_lock_payment b where b.source_file_id=a.id and a.status=? and a.file_type=? and a.car_group_id is null" +
+		" and (b.quote_status is null or b.quote_status!=?)"
+	err := database.GetDB().Raw(sql, constants.SOURCE_FILE_STATUS_PAID, constants.SOURCE_FILE_TYPE_NORMAL, constants.LOCK_PAYMENT_QUOTE_STATUS_REJECTED).Scan(&sourceFiles).Error
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return sourceFiles, nil
+}
+
+func GetSourceFilesByCarGroupId(carGroupId int64) ([]*SourceFileExt, error) {
+	var sourceFiles []*SourceFileExt
+	sql := "select a.*,b.locked_fee from source_file a, event_lock_payment b where b.source_file_id=a.id and a.car_group_id=? order by a.id"
+	err := database.GetDB().Raw(sql, carGroupId).Scan(&sourceFiles).Error
 
 	if err != nil {
 		logs.GetLogger().Error(err)
@@ -160,7 +177,7 @@ func CreateSourceFile(sourceFile SourceFile) (*SourceFile, error) {
 func GetSourceFiles(limit, offset string, walletAddress, payloadCid string, file_name string, orderByColumn int, ascdesc string) ([]*SourceFileExt, error) {
 	sql := "select s.id, h.file_name,s.file_size,s.pin_status,s.create_at,s.payload_cid,s.ipfs_url,h.wallet_address,s.mint_address, s.nft_tx_hash, s.token_id,df.id deal_file_id,df.lock_payment_status status,df.duration, evpm.locked_fee from source_file s "
 	sql = sql + "left join source_file_upload_history h on s.id=h.source_file_id "
-	sql = sql + "left join source_file_deal_file_map sfdfm on s.id = sfdfm.source_file_id "
+	sql = sql + "left join (select source_file_id,max(deal_file_id) deal_file_id from source_file_deal_file_map group by source_file_id) sfdfm on s.id = sfdfm.source_file_id "
 	sql = sql + "left join deal_file df on sfdfm.deal_file_id = df.id "
 
 	params := []interface{}{}
@@ -195,7 +212,7 @@ func GetSourceFiles(limit, offset string, walletAddress, payloadCid string, file
 func GetSourceFilesByWalletAddress(walletAddress string) ([]*SourceFileExt, error) {
 	sql := "select s.id, h.file_name,s.file_size,s.pin_status,s.create_at,s.payload_cid,s.ipfs_url,h.wallet_address,s.mint_address, s.nft_tx_hash, s.token_id,df.id deal_file_id,df.lock_payment_status status,df.duration, evpm.locked_fee from source_file s "
 	sql = sql + "left join source_file_upload_history h on s.id=h.source_file_id "
-	sql = sql + "left join source_file_deal_file_map sfdfm on s.id = sfdfm.source_file_id "
+	sql = sql + "left join (select source_file_id,max(deal_file_id) deal_file_id from source_file_deal_file_map group by source_file_id) sfdfm on s.id = sfdfm.source_file_id "
 	sql = sql + "left join deal_file df on sfdfm.deal_file_id = df.id "
 
 	params := []interface{}{}
@@ -267,3 +284,50 @@ func UpdateSourceFileRefundStatus(srcFileId int64, refundStatus string, refundTx
 
 	return nil
 }
+
+func UpdateSourceFileAutoRenew(srcFileId int64, autoRenew bool) error {
+	sql := "update source_file set auto_renew=?,update_at=? where id=?"
+
+	params := []interface{}{}
+	params = append(params, autoRenew)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, srcFileId)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetSourceFilesExpiredWithoutActiveDeal returns the paid source files whose lock payment passed its deadline before any deal was active,
+// and not refunded on expiry yet, the payment is locked by payload cid, so no deal of any source file of the same payload cid can be active,
+// source files whose lock payment is rejected by the quote check stay uploaded and are refunded as well
+func GetSourceFilesExpiredWithoutActiveDeal(deadline int64) ([]*SourceFile, error) {
+	var sourceFiles []*SourceFile
+	sql := "select distinct a.* from source_file a, event_lock_payment b where a.payload_cid=b.payload_cid and cast(b.deadline as unsigned)<? and (a.file_status in (?,?,?,?) or (a.file_status=? and b.quote_status=?))" +
+		" and (a.refund_status is null or a.refund_status!=?)" +
+		" and not exists (select 1 from source_file e, source_file_deal_file_map c, offline_deal d where e.payload_cid=a.payload_cid and c.source_file_id=e.id and d.deal_file_id=c.deal_file_id and (d.status=? or d.active_at is not null))" +
+		" order by a.id"
+
+	params := []interface{}{}
+	params = append(params, deadline)
+	params = append(params, constants.FILE_STATUS_PAID)
+	params = append(params, constants.FILE_STATUS_AGGREGATED)
+	params = append(params, constants.FILE_STATUS_DEAL_SENT)
+	params = append(params, constants.FILE_STATUS_REFUNDING)
+	params = append(params, constants.FILE_STATUS_UPLOADED)
+	params = append(params, constants.LOCK_PAYMENT_QUOTE_STATUS_REJECTED)
+	params = append(params, constants.PROCESS_STATUS_EXPIRE_REFUNDED)
+	params = append(params, constants.DEAL_STATUS_ACTIVE)
+
+	err := database.GetDB().Raw(sql, params...).Scan(&sourceFiles).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return sourceFiles, nil
+}
@@ -7,11 +7,21 @@ import (
 )
 
 type SourceFileDealFileMap struct {
-	SourceFileId int64 `json:"source_file_id"`
-	FileIndex    int   `json:"file_index"`
-	DealFileId   int64 `json:"deal_file_id"`
-	CreateAt     int64 `json:"create_at"`
-	UpdateAt     int64 `json:"update_at"`
+	SourceFileId   int64   `json:"source_file_id"`
+	FileIndex      int     `json:"file_index"`
+	DealFileId     int64   `json:"deal_file_id"`
+	RootCid        *string `json:"root_cid"`
+	CarOffset      *int64  `json:"car_offset"`
+	CarLength      *int64  `json:"car_length"`
+	InclusionProof *string `json:"inclusion_proof"`
+	CreateAt       int64   `json:"create_at"`
+	UpdateAt       int64   `json:"update_at"`
+}
+
+type SourceFileDealFileMapExt struct {
+	SourceFileDealFileMap
+	PieceCid   string `json:"piece_cid"`
+	PayloadCid string `json:"payload_cid"`
 }
 
 func GetSourceFileDealFileMapBySourceFilePayloadCid(sourceFilePayloadCid string) ([]*SourceFileDealFileMap, error) {
@@ -26,3 +36,29 @@ func GetSourceFileDealFileMapBySourceFilePayloadCid(sourceFilePayloadCid string)
 
 	return sourceFileDealFileMap, nil
 }
+
+func GetSourceFileDealFileMapsByDealFileId(dealFileId int64) ([]*SourceFileDealFileMap, error) {
+	var sourceFileDealFileMaps []*SourceFileDealFileMap
+	sql := "select a.* from source_file_deal_file_map a where a.deal_file_id=? order by a.file_index"
+	err := database.GetDB().Raw(sql, dealFileId).Scan(&sourceFileDealFileMaps).Error
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return sourceFileDealFileMaps, nil
+}
+
+func GetSourceFileDealFileMapsBySourceFileId(sourceFileId int64) ([]*SourceFileDealFileMapExt, error) {
+	var sourceFileDealFileMaps []*SourceFileDealFileMapExt
+	sql := "select a.*,b.piece_cid,b.payload_cid from source_file_deal_file_map a, deal_file b where a.deal_file_id=b.id and a.source_file_id=? order by a.deal_file_id"
+	err := database.GetDB().Raw(sql, sourceFileId).Scan(&sourceFileDealFileMaps).Error
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return sourceFileDealFileMaps, nil
+}
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"encoding/json"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/database"
+	"strings"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/jinzhu/gorm"
+)
+
+type Webhook struct {
+	ID            int64  `json:"id"`
+	WalletAddress string `json:"wallet_address"`
+	ApiKeyId      *int64 `json:"api_key_id"`
+	Url           string `json:"url"`
+	Secret        string `json:"secret,omitempty"`
+	Events        string `json:"events"`
+	Status        string `json:"status"`
+	CreateAt      int64  `json:"create_at"`
+	UpdateAt      int64  `json:"update_at"`
+}
+
+type WebhookDelivery struct {
+	ID            int64  `json:"id"`
+	WebhookId     int64  `json:"webhook_id"`
+	Event         string `json:"event"`
+	SourceFileId  int64  `json:"source_file_id"`
+	Payload       string `json:"payload"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	NextAttemptAt int64  `json:"next_attempt_at"`
+	ResponseCode  *int   `json:"response_code"`
+	Note          string `json:"note"`
+	DeliveredAt   *int64 `json:"delivered_at"`
+	ReplayOfId    *int64 `json:"replay_of_id"`
+	CreateAt      int64  `json:"create_at"`
+	UpdateAt      int64  `json:"update_at"`
+}
+
+type WebhookDeliveryExt struct {
+	WebhookDelivery
+	Url           string `json:"url"`
+	Secret        string `json:"-"`
+	WalletAddress string `json:"wallet_address"`
+	ApiKeyId      *int64 `json:"api_key_id"`
+}
+
+// WebhookEvent is the json body posted to the webhook urls
+type WebhookEvent struct {
+	Event         string `json:"event"`
+	WalletAddress string `json:"wallet_address"`
+	SourceFileId  int64  `json:"source_file_id"`
+	PayloadCid    string `json:"payload_cid"`
+	FileStatus    string `json:"file_status"`
+	Reason        string `json:"reason"`
+	CreateAt      int64  `json:"create_at"`
+}
+
+var webhookEvents = map[string]string{
+	constants.FILE_STATUS_PAID:       constants.WEBHOOK_EVENT_PAYMENT_LOCKED,
+	constants.FILE_STATUS_AGGREGATED: constants.WEBHOOK_EVENT_CAR_CREATED,
+	constants.FILE_STATUS_DEAL_SENT:  constants.WEBHOOK_EVENT_DEAL_SENT,
+	constants.FILE_STATUS_ACTIVE:     constants.WEBHOOK_EVENT_DEAL_ACTIVE,
+	constants.FILE_STATUS_UNLOCKED:   constants.WEBHOOK_EVENT_PAYMENT_UNLOCKED,
+	constants.FILE_STATUS_REFUNDED:   constants.WEBHOOK_EVENT_REFUND_ISSUED,
+	constants.FILE_STATUS_EXPIRED:    constants.WEBHOOK_EVENT_PAYMENT_EXPIRED,
+}
+
+func IsWebhookEventValid(event string) bool {
+	for _, webhookEvent := range webhookEvents {
+		if webhookEvent == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetWebhookById(id int64) (*Webhook, error) {
+	var webhooks []*Webhook
+	sql := "select a.* from webhook a where a.id=? and a.status=?"
+	err := database.GetDB().Raw(sql, id, constants.WEBHOOK_STATUS_ACTIVE).Scan(&webhooks).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+
+	return webhooks[0], nil
+}
+
+// GetWebhooksByWalletAddress returns the webhooks of the wallet, only the ones registered with the api key when apiKeyId is given
+func GetWebhooksByWalletAddress(walletAddress string, apiKeyId *int64) ([]*Webhook, error) {
+	var webhooks []*Webhook
+	sql := "select a.* from webhook a where a.wallet_address=? and a.status=?"
+
+	params := []interface{}{}
+	params = append(params, strings.ToLower(walletAddress))
+	params = append(params, constants.WEBHOOK_STATUS_ACTIVE)
+	if apiKeyId != nil {
+		sql = sql + " and a.api_key_id=?"
+		params = append(params, *apiKeyId)
+	}
+
+	err := database.GetDB().Raw(sql+" order by a.id", params...).Scan(&webhooks).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func DeleteWebhook(id int64) error {
+	sql := "update webhook set status=?,update_at=? where id=?"
+	err := database.GetDB().Exec(sql, constants.WEBHOOK_STATUS_DELETED, utils.GetCurrentUtcMilliSecond(), id).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func GetWebhookDeliveryById(id int64) (*WebhookDeliveryExt, error) {
+	var webhookDeliveries []*WebhookDeliveryExt
+	sql := "select a.*,b.url,b.secret,b.wallet_address,b.api_key_id from webhook_delivery a, webhook b where a.webhook_id=b.id and a.id=?"
+	err := database.GetDB().Raw(sql, id).Scan(&webhookDeliveries).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(webhookDeliveries) == 0 {
+		return nil, nil
+	}
+
+	return webhookDeliveries[0], nil
+}
+
+// GetWebhookDeliveriesByWalletAddress returns the deliveries of the webhooks of the wallet, only the ones registered with the api key when apiKeyId is given
+func GetWebhookDeliveriesByWalletAddress(walletAddress string, apiKeyId *int64, limit, offset int) ([]*WebhookDeliveryExt, error) {
+	var webhookDeliveries []*WebhookDeliveryExt
+	sql := "select a.*,b.url,b.wallet_address,b.api_key_id from webhook_delivery a, webhook b where a.webhook_id=b.id and b.wallet_address=?"
+
+	params := []interface{}{}
+	params = append(params, strings.ToLower(walletAddress))
+	if apiKeyId != nil {
+		sql = sql + " and b.api_key_id=?"
+		params = append(params, *apiKeyId)
+	}
+	params = append(params, limit)
+	params = append(params, offset)
+
+	err := database.GetDB().Raw(sql+" order by a.id desc limit ? offset ?", params...).Scan(&webhookDeliveries).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return webhookDeliveries, nil
+}
+
+func GetWebhookDeliveries2Send(nextAttemptAtMax int64, limit int) ([]*WebhookDeliveryExt, error) {
+	var webhookDeliveries []*WebhookDeliveryExt
+	sql := "select a.*,b.url,b.secret,b.wallet_address from webhook_delivery a, webhook b where a.webhook_id=b.id and b.status=? and a.status=? and a.next_attempt_at<=? order by a.id limit ?"
+
+	params := []interface{}{}
+	params = append(params, constants.WEBHOOK_STATUS_ACTIVE)
+	params = append(params, constants.WEBHOOK_DELIVERY_STATUS_PENDING)
+	params = append(params, nextAttemptAtMax)
+	params = append(params, limit)
+
+	err := database.GetDB().Raw(sql, params...).Scan(&webhookDeliveries).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return webhookDeliveries, nil
+}
+
+func UpdateWebhookDeliveryResult(webhookDelivery *WebhookDelivery) error {
+	sql := "update webhook_delivery set status=?,attempts=?,next_attempt_at=?,response_code=?,note=?,delivered_at=?,update_at=? where id=?"
+
+	params := []interface{}{}
+	params = append(params, webhookDelivery.Status)
+	params = append(params, webhookDelivery.Attempts)
+	params = append(params, webhookDelivery.NextAttemptAt)
+	params = append(params, webhookDelivery.ResponseCode)
+	params = append(params, webhookDelivery.Note)
+	params = append(params, webhookDelivery.DeliveredAt)
+	params = append(params, utils.GetCurrentUtcMilliSecond())
+	params = append(params, webhookDelivery.ID)
+
+	err := database.GetDB().Exec(sql, params...).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ReplayWebhookDelivery queues a new delivery with the same payload, the original delivery is kept in the log
+func ReplayWebhookDelivery(webhookDelivery *WebhookDelivery) (*WebhookDelivery, error) {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	replay := WebhookDelivery{
+		WebhookId:     webhookDelivery.WebhookId,
+		Event:         webhookDelivery.Event,
+		SourceFileId:  webhookDelivery.SourceFileId,
+		Payload:       webhookDelivery.Payload,
+		Status:        constants.WEBHOOK_DELIVERY_STATUS_PENDING,
+		NextAttemptAt: currentUtcMilliSec,
+		ReplayOfId:    &webhookDelivery.ID,
+		CreateAt:      currentUtcMilliSec,
+		UpdateAt:      currentUtcMilliSec,
+	}
+
+	err := database.SaveOne(&replay)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return &replay, nil
+}
+
+// the deliveries are saved in the same transaction as the status change, and posted later by the webhook scheduler,
+// so that an event is neither lost nor sent for a status change rolled back
+func createWebhookDeliveriesInTransaction(db *gorm.DB, sourceFile *SourceFile, toStatus, reason string, createAt int64) error {
+	event, ok := webhookEvents[toStatus]
+	if !ok {
+		return nil
+	}
+
+	var webhooks []*Webhook
+	sql := "select a.* from webhook a where a.status=? and a.wallet_address in (select lower(b.wallet_address) from source_file_upload_history b where b.source_file_id=?)"
+	err := db.Raw(sql, constants.WEBHOOK_STATUS_ACTIVE, sourceFile.ID).Scan(&webhooks).Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		if webhook.Events != "" && !strings.Contains(","+webhook.Events+",", ","+event+",") {
+			continue
+		}
+
+		webhookEvent := WebhookEvent{
+			Event:         event,
+			WalletAddress: webhook.WalletAddress,
+			SourceFileId:  sourceFile.ID,
+			PayloadCid:    sourceFile.PayloadCid,
+			FileStatus:    toStatus,
+			Reason:        reason,
+			CreateAt:      createAt,
+		}
+
+		payload, err := json.Marshal(webhookEvent)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+
+		webhookDelivery := WebhookDelivery{
+			WebhookId:     webhook.ID,
+			Event:         event,
+			SourceFileId:  sourceFile.ID,
+			Payload:       string(payload),
+			Status:        constants.WEBHOOK_DELIVERY_STATUS_PENDING,
+			NextAttemptAt: createAt,
+			CreateAt:      createAt,
+			UpdateAt:      createAt,
+		}
+
+		err = database.SaveOneInTransaction(db, &webhookDelivery)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
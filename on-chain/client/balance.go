@@ -0,0 +1,31 @@
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/shopspring/decimal"
+)
+
+const maticDecimals = 18
+
+// GetBalance returns the MATIC balance of the address
+func GetBalance(ethClient *ethclient.Client, address common.Address) (decimal.Decimal, error) {
+	balance, err := ethClient.BalanceAt(context.Background(), address, nil)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return decimal.Zero, err
+	}
+
+	return decimal.NewFromBigInt(balance, -maticDecimals), nil
+}
+
+// GetTxFee returns the MATIC paid for gas by the transaction, failed transactions pay for gas too
+func GetTxFee(tx *types.Transaction, txReceipt *types.Receipt) decimal.Decimal {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(txReceipt.GasUsed), tx.GasPrice())
+	return decimal.NewFromBigInt(fee, -maticDecimals)
+}
@@ -83,6 +83,28 @@ func GetSwanPaymentTransactor(ethClient *ethclient.Client) (*goBind.SwanPaymentT
 	return swanPaymentTransactor, nil
 }
 
+func GetFilswanOracleTransactor(ethClient *ethclient.Client) (*goBind.FilswanOracleTransactor, error) {
+	daoContractAddress := common.HexToAddress(config.GetConfig().Polygon.DaoContractAddress)
+	filswanOracleTransactor, err := goBind.NewFilswanOracleTransactor(daoContractAddress, ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return filswanOracleTransactor, nil
+}
+
+func GetFilswanOracleFilterer(ethClient *ethclient.Client) (*goBind.FilswanOracleFilterer, error) {
+	daoContractAddress := common.HexToAddress(config.GetConfig().Polygon.DaoContractAddress)
+	filswanOracleFilterer, err := goBind.NewFilswanOracleFilterer(daoContractAddress, ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return filswanOracleFilterer, nil
+}
+
 func GetFilswanOracleSession(ethClient *ethclient.Client) (*goBind.FilswanOracleSession, error) {
 	daoContractAddress := common.HexToAddress(config.GetConfig().Polygon.DaoContractAddress)
 	filswanOracle, err := goBind.NewFilswanOracle(daoContractAddress, ethClient)
@@ -98,6 +120,21 @@ func GetFilswanOracleSession(ethClient *ethclient.Client) (*goBind.FilswanOracle
 	return filswanOracleSession, nil
 }
 
+func GetFilinkConsumerSession(ethClient *ethclient.Client) (*goBind.FilinkConsumerSession, error) {
+	filinkConsumerAddress := common.HexToAddress(config.GetConfig().Polygon.FilinkConsumerAddress)
+	filinkConsumer, err := goBind.NewFilinkConsumer(filinkConsumerAddress, ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	filinkConsumerSession := &goBind.FilinkConsumerSession{
+		Contract: filinkConsumer,
+	}
+
+	return filinkConsumerSession, nil
+}
+
 func GetTransactOpts(ethClient *ethclient.Client, privateKey *ecdsa.PrivateKey, publicKeyAddress common.Address) (*bind.TransactOpts, error) {
 	nonce, err := ethClient.PendingNonceAt(context.Background(), publicKeyAddress)
 	if err != nil {
@@ -159,11 +196,11 @@ retry:
 	rp, err := client.TransactionReceipt(context.Background(), tx.Hash())
 	if err != nil {
 		if err == ethereum.NotFound {
-			logs.GetLogger().Error("tx %v not found, check it later", tx.Hash().String())
+			logs.GetLogger().Error("tx ", tx.Hash().String(), " not found, check it later")
 			time.Sleep(1 * time.Second)
 			goto retry
 		} else {
-			logs.GetLogger().Error("TransactionReceipt fail: %s", err)
+			logs.GetLogger().Error("TransactionReceipt fail: ", err)
 			return nil, err
 		}
 	}
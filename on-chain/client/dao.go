@@ -2,11 +2,13 @@ package client
 
 import (
 	"context"
+	"multi-chain-storage/common/constants"
 	"multi-chain-storage/config"
 	"multi-chain-storage/on-chain/goBind"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/filswan/go-swan-lib/logs"
 )
 
@@ -36,3 +38,72 @@ func GetThreshHold() (uint8, error) {
 	logs.GetLogger().Info("dao threshHold is : ", threshHold)
 	return threshHold, nil
 }
+
+// GetDaoRoleMembers returns the addresses granted DAO_ROLE since fromBlock and still having it,
+// the contract does not enumerate role members, so they are collected from RoleGranted logs
+func GetDaoRoleMembers(ethClient *ethclient.Client, fromBlock uint64) ([]common.Address, error) {
+	filswanOracleSession, err := GetFilswanOracleSession(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	filswanOracleFilterer, err := GetFilswanOracleFilterer(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	daoRole, err := filswanOracleSession.DAOROLE()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	currentBlockNo, err := ethClient.BlockNumber(context.Background())
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	grantedAccounts := map[common.Address]bool{}
+	for start := fromBlock; start <= currentBlockNo; start = start + constants.DAO_ROLE_LOG_BLOCK_RANGE {
+		end := start + constants.DAO_ROLE_LOG_BLOCK_RANGE - 1
+		if end > currentBlockNo {
+			end = currentBlockNo
+		}
+
+		filterOpts := &bind.FilterOpts{Start: start, End: &end, Context: context.Background()}
+		roleGrantedIterator, err := filswanOracleFilterer.FilterRoleGranted(filterOpts, [][32]byte{daoRole}, nil, nil)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		for roleGrantedIterator.Next() {
+			grantedAccounts[roleGrantedIterator.Event.Account] = true
+		}
+
+		err = roleGrantedIterator.Error()
+		roleGrantedIterator.Close()
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+	}
+
+	members := []common.Address{}
+	for account := range grantedAccounts {
+		hasRole, err := filswanOracleSession.HasRole(daoRole, account)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		if hasRole {
+			members = append(members, account)
+		}
+	}
+
+	return members, nil
+}
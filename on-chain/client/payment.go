@@ -2,10 +2,13 @@ package client
 
 import (
 	"fmt"
+	"math/big"
 	"multi-chain-storage/config"
 	"multi-chain-storage/on-chain/goBind"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/filswan/go-swan-lib/logs"
 	"github.com/shopspring/decimal"
 )
@@ -18,6 +21,7 @@ type LockedPayment struct {
 	AddressTo    string
 	Deadline     string
 	Size         int64
+	CopyLimit    int
 }
 
 func IsLockedPaymentExists(srcFilePayloadCid string) (*bool, error) {
@@ -69,6 +73,7 @@ func GetLockedPaymentInfo(srcFilePayloadCid string) (*LockedPayment, error) {
 		AddressTo:    paymentInfo.Recipient.String(),
 		Deadline:     paymentInfo.Deadline.String(),
 		Size:         paymentInfo.Size.Int64(),
+		CopyLimit:    int(paymentInfo.CopyLimit),
 	}
 
 	return &lockedPayment, nil
@@ -95,3 +100,122 @@ func GetSwanPaymentSession() (*goBind.SwanPaymentSession, error) {
 
 	return swanPaymentSession, nil
 }
+
+// UnlockCarPaymentTransfer is the UnlockCarPayment event of a deal with the ERC-20 Transfer to the recipient before it
+type UnlockCarPaymentTransfer struct {
+	DealId            string
+	Recipient         common.Address
+	TokenAmount       *big.Int
+	TokenAddress      common.Address
+	TransferredAmount *big.Int
+}
+
+var erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// GetUnlockCarPaymentTransfers decodes the unlocked deals of an unlock tx by deal id,
+// unlockCarPayment transfers right before emitting UnlockCarPayment, so the Transfer of a deal is the log before its event.
+// The payment contract deployed before unlockCarPayments emits no UnlockCarPayment, so when the tx unlocked only one deal and has no such event,
+// the deal is decoded from the Transfers of the payment contract to the recipient, and its token amount is the amount transferred
+func GetUnlockCarPaymentTransfers(txReceipt *types.Receipt, dealIds []string, recipient common.Address) (map[string]*UnlockCarPaymentTransfer, error) {
+	swanPaymentFilterer, err := GetSwanPaymentFilterer()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	paymentContractAddress := common.HexToAddress(config.GetConfig().Polygon.PaymentContractAddress)
+	unlockTransfers := map[string]*UnlockCarPaymentTransfer{}
+	for i, vLog := range txReceipt.Logs {
+		if vLog.Address != paymentContractAddress {
+			continue
+		}
+
+		unlocked, err := swanPaymentFilterer.ParseUnlockCarPayment(*vLog)
+		if err != nil {
+			continue
+		}
+
+		unlockTransfer := &UnlockCarPaymentTransfer{
+			DealId:            unlocked.DealId,
+			Recipient:         unlocked.Recipient,
+			TokenAmount:       unlocked.TokenAmount,
+			TransferredAmount: big.NewInt(0),
+		}
+
+		if i > 0 {
+			transferLog := txReceipt.Logs[i-1]
+			if isTransfer(transferLog, paymentContractAddress, unlocked.Recipient) {
+				unlockTransfer.TokenAddress = transferLog.Address
+				unlockTransfer.TransferredAmount = new(big.Int).SetBytes(transferLog.Data)
+			}
+		}
+
+		unlockTransfers[unlocked.DealId] = unlockTransfer
+	}
+
+	if len(unlockTransfers) > 0 || len(dealIds) != 1 {
+		return unlockTransfers, nil
+	}
+
+	for _, vLog := range txReceipt.Logs {
+		if !isTransfer(vLog, paymentContractAddress, recipient) {
+			continue
+		}
+
+		unlockTransfer, ok := unlockTransfers[dealIds[0]]
+		if !ok {
+			unlockTransfer = &UnlockCarPaymentTransfer{
+				DealId:            dealIds[0],
+				Recipient:         recipient,
+				TokenAmount:       big.NewInt(0),
+				TokenAddress:      vLog.Address,
+				TransferredAmount: big.NewInt(0),
+			}
+			unlockTransfers[dealIds[0]] = unlockTransfer
+		}
+
+		amount := new(big.Int).SetBytes(vLog.Data)
+		unlockTransfer.TokenAmount.Add(unlockTransfer.TokenAmount, amount)
+		unlockTransfer.TransferredAmount.Add(unlockTransfer.TransferredAmount, amount)
+	}
+
+	return unlockTransfers, nil
+}
+
+// isTransfer returns whether the log is an ERC-20 Transfer from one address to another
+func isTransfer(vLog *types.Log, from, to common.Address) bool {
+	return len(vLog.Topics) == 3 && vLog.Topics[0] == erc20TransferTopic &&
+		common.BytesToAddress(vLog.Topics[1].Bytes()) == from &&
+		common.BytesToAddress(vLog.Topics[2].Bytes()) == to
+}
+
+// RefundTransfer is an ERC-20 Transfer from the payment contract to the owner of a refunded payment
+type RefundTransfer struct {
+	Owner        common.Address
+	TokenAddress common.Address
+	Amount       *big.Int
+}
+
+// GetRefundTransfers decodes the Transfers of a refund tx in the order of its logs,
+// refund emits no event of its own, and transfers the locked fee of each cid existing with a locked fee in the order of cidList
+func GetRefundTransfers(txReceipt *types.Receipt) []*RefundTransfer {
+	paymentContractAddress := common.HexToAddress(config.GetConfig().Polygon.PaymentContractAddress)
+	refundTransfers := []*RefundTransfer{}
+	for _, vLog := range txReceipt.Logs {
+		if len(vLog.Topics) != 3 || vLog.Topics[0] != erc20TransferTopic {
+			continue
+		}
+
+		if common.BytesToAddress(vLog.Topics[1].Bytes()) != paymentContractAddress {
+			continue
+		}
+
+		refundTransfers = append(refundTransfers, &RefundTransfer{
+			Owner:        common.BytesToAddress(vLog.Topics[2].Bytes()),
+			TokenAddress: vLog.Address,
+			Amount:       new(big.Int).SetBytes(vLog.Data),
+		})
+	}
+
+	return refundTransfers
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"math/big"
+	"multi-chain-storage/config"
+	"multi-chain-storage/on-chain/goBind"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	testPaymentContract = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testToken           = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testRecipient       = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	testOwner           = common.HexToAddress("0x4000000000000000000000000000000000000004")
+)
+
+func setPaymentContract() {
+	configuration := config.Configuration{}
+	configuration.Polygon.PaymentContractAddress = testPaymentContract.Hex()
+	config.SetConfig(configuration)
+}
+
+func newTransferLog(from, to common.Address, amount int64) *types.Log {
+	return &types.Log{
+		Address: testToken,
+		Topics:  []common.Hash{erc20TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+	}
+}
+
+func newUnlockCarPaymentLog(t *testing.T, dealId string, recipient common.Address, tokenAmount int64) *types.Log {
+	swanPaymentAbi, err := abi.JSON(strings.NewReader(goBind.SwanPaymentABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := swanPaymentAbi.Events["UnlockCarPayment"]
+	data, err := event.Inputs.Pack(dealId, "calibration", recipient, big.NewInt(tokenAmount))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &types.Log{
+		Address: testPaymentContract,
+		Topics:  []common.Hash{event.ID},
+		Data:    data,
+	}
+}
+
+func TestGetUnlockCarPaymentTransfers(t *testing.T) {
+	setPaymentContract()
+
+	// unlockCarPayments of the upgraded contract, a Transfer before the UnlockCarPayment of each deal
+	txReceipt := &types.Receipt{Logs: []*types.Log{
+		newTransferLog(testPaymentContract, testRecipient, 100),
+		newUnlockCarPaymentLog(t, "11", testRecipient, 100),
+		newTransferLog(testPaymentContract, testRecipient, 70),
+		newUnlockCarPaymentLog(t, "12", testRecipient, 80),
+	}}
+
+	unlockTransfers, err := GetUnlockCarPaymentTransfers(txReceipt, []string{"11", "12", "13"}, testRecipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unlockTransfers) != 2 {
+		t.Fatalf("got %d unlocked deals, want 2", len(unlockTransfers))
+	}
+
+	expected := map[string][2]int64{"11": {100, 100}, "12": {80, 70}}
+	for dealId, amounts := range expected {
+		unlockTransfer := unlockTransfers[dealId]
+		if unlockTransfer == nil {
+			t.Errorf("deal %s not decoded", dealId)
+			continue
+		}
+
+		if unlockTransfer.TokenAmount.Int64() != amounts[0] || unlockTransfer.TransferredAmount.Int64() != amounts[1] ||
+			unlockTransfer.Recipient != testRecipient || unlockTransfer.TokenAddress != testToken {
+			t.Errorf("deal %s decoded as %+v", dealId, unlockTransfer)
+		}
+	}
+}
+
+func TestGetUnlockCarPaymentTransfersWithoutEvent(t *testing.T) {
+	setPaymentContract()
+
+	// unlockCarPayment of the contract deployed before, only the Transfers of the token
+	txReceipt := &types.Receipt{Logs: []*types.Log{
+		newTransferLog(testOwner, testPaymentContract, 5),
+		newTransferLog(testPaymentContract, testRecipient, 120),
+		newTransferLog(testPaymentContract, testOwner, 30),
+	}}
+
+	unlockTransfers, err := GetUnlockCarPaymentTransfers(txReceipt, []string{"21"}, testRecipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlockTransfer := unlockTransfers["21"]
+	if len(unlockTransfers) != 1 || unlockTransfer == nil {
+		t.Fatalf("got %+v, want deal 21", unlockTransfers)
+	}
+
+	if unlockTransfer.TokenAmount.Int64() != 120 || unlockTransfer.TransferredAmount.Int64() != 120 ||
+		unlockTransfer.Recipient != testRecipient || unlockTransfer.TokenAddress != testToken {
+		t.Errorf("deal 21 decoded as %+v", unlockTransfer)
+	}
+
+	// a tx of several deals can not be split without the events
+	unlockTransfers, err = GetUnlockCarPaymentTransfers(txReceipt, []string{"21", "22"}, testRecipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unlockTransfers) != 0 {
+		t.Errorf("got %+v from a tx of several deals without events", unlockTransfers)
+	}
+
+	// no Transfer to the recipient
+	unlockTransfers, err = GetUnlockCarPaymentTransfers(&types.Receipt{Logs: txReceipt.Logs[:1]}, []string{"21"}, testRecipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unlockTransfers) != 0 {
+		t.Errorf("got %+v from a tx without transfer to the recipient", unlockTransfers)
+	}
+}
+
+func TestGetRefundTransfers(t *testing.T) {
+	setPaymentContract()
+
+	txReceipt := &types.Receipt{Logs: []*types.Log{
+		newTransferLog(testPaymentContract, testOwner, 40),
+		newTransferLog(testOwner, testRecipient, 1),
+		newTransferLog(testPaymentContract, testRecipient, 60),
+	}}
+
+	refundTransfers := GetRefundTransfers(txReceipt)
+	if len(refundTransfers) != 2 {
+		t.Fatalf("got %d refund transfers, want 2", len(refundTransfers))
+	}
+
+	if refundTransfers[0].Owner != testOwner || refundTransfers[0].Amount.Int64() != 40 ||
+		refundTransfers[1].Owner != testRecipient || refundTransfers[1].Amount.Int64() != 60 {
+		t.Errorf("got refund transfers %+v %+v", refundTransfers[0], refundTransfers[1])
+	}
+}
@@ -96,6 +96,19 @@ contract SwanPayment is IPaymentMinimal, Initializable {
         address owner // who lock the token
     );
 
+    event UnlockCarPayment(
+        string dealId,
+        string network,
+        address recipient,
+        uint256 tokenAmount
+    );
+
+    event UnlockCarPaymentFailed(
+        string dealId,
+        string network,
+        string reason
+    );
+
     event ExpirePayment(
         string id,
         address token,
@@ -244,9 +257,32 @@ contract SwanPayment is IPaymentMinimal, Initializable {
             IERC20(_ERC20_TOKEN).transfer(recipient, tokenAmount);
         }
 
+        emit UnlockCarPayment(dealId, network, recipient, tokenAmount);
         return true;
     }
 
+    /// @notice unlock the payments of many deals in one transaction, a deal failed to unlock does not revert the others
+    /// @dev each deal emits either UnlockCarPayment or UnlockCarPaymentFailed with the revert reason
+    /// @return results whether the payment of each deal is unlocked
+    function unlockCarPayments(string[] calldata dealIds, string calldata network, address recipient)
+        public
+        override
+        returns (bool[] memory results)
+    {
+        results = new bool[](dealIds.length);
+        for (uint256 i = 0; i < dealIds.length; i++) {
+            try this.unlockCarPayment(dealIds[i], network, recipient) returns (bool result) {
+                results[i] = result;
+            } catch Error(string memory reason) {
+                emit UnlockCarPaymentFailed(dealIds[i], network, reason);
+            } catch {
+                emit UnlockCarPaymentFailed(dealIds[i], network, "unlock car payment reverted");
+            }
+        }
+
+        return results;
+    }
+
     function refund(string[] memory cidList) public {
         // todo add access control later
         for (uint8 i = 0; i < cidList.length; i++) {
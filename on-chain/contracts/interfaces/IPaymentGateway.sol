@@ -51,4 +51,8 @@ interface IPaymentMinimal {
     function unlockCarPayment(string calldata dealId, string calldata network, address recipient)
         external
         returns (bool);
+
+    function unlockCarPayments(string[] calldata dealIds, string calldata network, address recipient)
+        external
+        returns (bool[] memory results);
 }
@@ -0,0 +1,40 @@
+//SPDX-License-Identifier: Unlicense
+pragma solidity 0.8.4;
+
+// stand-ins of FilswanOracle, FilinkConsumer and the price feed, so that SwanPayment can unlock without dao signatures and chainlink
+
+contract MockFilswanOracle {
+    mapping(string => bool) private _available;
+    mapping(string => string[]) private _cidLists;
+
+    function setCarPayment(string calldata dealId, string[] calldata cidList, bool available) public {
+        _available[dealId] = available;
+        _cidLists[dealId] = cidList;
+    }
+
+    function isCarPaymentAvailable(string memory dealId, string memory, address) public view returns (bool) {
+        return _available[dealId];
+    }
+
+    function getCidList(string memory dealId, string memory) public view returns (string[] memory) {
+        return _cidLists[dealId];
+    }
+}
+
+contract MockFilinkConsumer {
+    mapping(string => uint256) private _prices;
+
+    function setPrice(string calldata dealId, uint256 price) public {
+        _prices[dealId] = price;
+    }
+
+    function getPrice(string calldata dealId, string calldata) public view returns (uint256) {
+        return _prices[dealId];
+    }
+}
+
+contract MockPriceFeed {
+    function consult(address, uint256 amount) external pure returns (uint256) {
+        return amount;
+    }
+}
@@ -0,0 +1,114 @@
+const { expect } = require("chai");
+
+describe("Batch unlock car payments", function () {
+  const network = "calibration";
+
+  let accounts;
+  let owner;
+  let payer;
+  let recipient;
+
+  let tokenInstance;
+  let oracleInstance;
+  let filinkInstance;
+  let paymentInstance;
+
+  const lockedFee = ethers.utils.parseEther("10");
+  const minPayment = ethers.utils.parseEther("1");
+  const cost = ethers.utils.parseEther("2");
+
+  async function lockCarPayment(cid) {
+    const tx = await paymentInstance.connect(payer).lockTokenPayment({
+      id: cid,
+      minPayment: minPayment,
+      amount: lockedFee,
+      lockTime: 86400, // one day
+      recipient: recipient.address,
+      size: 1024,
+      copyLimit: 5,
+    });
+    await tx.wait();
+  }
+
+  async function setDeal(dealId, cid, available) {
+    await (await oracleInstance.setCarPayment(dealId, [cid], available)).wait();
+    await (await filinkInstance.setPrice(dealId, cost)).wait();
+  }
+
+  before('Deploy payment contract with mock oracles', async () => {
+    accounts = await ethers.getSigners();
+    [owner, payer, recipient] = accounts;
+
+    const token = await ethers.getContractFactory("TestERC20");
+    tokenInstance = await token.deploy("USD Coin", "USDC");
+    await tokenInstance.deployed();
+
+    const oracle = await ethers.getContractFactory("MockFilswanOracle");
+    oracleInstance = await oracle.deploy();
+    await oracleInstance.deployed();
+
+    const filink = await ethers.getContractFactory("MockFilinkConsumer");
+    filinkInstance = await filink.deploy();
+    await filinkInstance.deployed();
+
+    const priceFeed = await ethers.getContractFactory("MockPriceFeed");
+    const priceFeedInstance = await priceFeed.deploy();
+    await priceFeedInstance.deployed();
+
+    const payment = await ethers.getContractFactory("SwanPayment");
+    paymentInstance = await payment.deploy();
+    await paymentInstance.deployed();
+    await paymentInstance.initialize(owner.address, tokenInstance.address, oracleInstance.address, priceFeedInstance.address, filinkInstance.address);
+
+    await tokenInstance.mint(payer.address, ethers.utils.parseEther("100"));
+    await tokenInstance.connect(payer).approve(paymentInstance.address, ethers.utils.parseEther("100"));
+  });
+
+  it("Test unlock many deals in one transaction", async function () {
+    await lockCarPayment("cid-batch-1");
+    await lockCarPayment("cid-batch-2");
+    await setDeal("1001", "cid-batch-1", true);
+    await setDeal("1002", "cid-batch-2", true);
+
+    const tx = await paymentInstance.unlockCarPayments(["1001", "1002"], network, recipient.address);
+    const receipt = await tx.wait();
+
+    const unlocked = receipt.events.filter(e => e.event === "UnlockCarPayment").map(e => e.args.dealId);
+    expect(unlocked).to.deep.equal(["1001", "1002"]);
+    expect(await tokenInstance.balanceOf(recipient.address)).to.equal(cost.mul(2));
+
+    const result = await paymentInstance.getLockedPaymentInfo("cid-batch-1");
+    expect(result.lockedFee).to.equal(lockedFee.sub(cost));
+  });
+
+  it("Test a deal failed to unlock does not revert the others", async function () {
+    await lockCarPayment("cid-batch-3");
+    await lockCarPayment("cid-batch-4");
+    await setDeal("1003", "cid-batch-3", false);
+    await setDeal("1004", "cid-batch-4", true);
+
+    const balanceBefore = await tokenInstance.balanceOf(recipient.address);
+    const tx = await paymentInstance.unlockCarPayments(["1003", "1004"], network, recipient.address);
+    const receipt = await tx.wait();
+
+    const failed = receipt.events.filter(e => e.event === "UnlockCarPaymentFailed");
+    expect(failed.length).to.equal(1);
+    expect(failed[0].args.dealId).to.equal("1003");
+    expect(failed[0].args.reason).to.equal("illegal unlock car action");
+
+    const unlocked = receipt.events.filter(e => e.event === "UnlockCarPayment").map(e => e.args.dealId);
+    expect(unlocked).to.deep.equal(["1004"]);
+    expect(await tokenInstance.balanceOf(recipient.address)).to.equal(balanceBefore.add(cost));
+
+    const result = await paymentInstance.getLockedPaymentInfo("cid-batch-3");
+    expect(result.lockedFee).to.equal(lockedFee);
+  });
+
+  it("Test results of a batch are returned per deal", async function () {
+    await lockCarPayment("cid-batch-5");
+    await setDeal("1005", "cid-batch-5", true);
+
+    const results = await paymentInstance.callStatic.unlockCarPayments(["1005", "1006"], network, recipient.address);
+    expect(results).to.deep.equal([true, false]);
+  });
+});
@@ -0,0 +1,350 @@
+package retrieval
+
+import (
+	"encoding/json"
+	"fmt"
+	"multi-chain-storage/car"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/disk"
+	"multi-chain-storage/models"
+	"os"
+	"path/filepath"
+
+	"github.com/filswan/go-swan-lib/client/lotus"
+	"github.com/filswan/go-swan-lib/client/web"
+	"github.com/filswan/go-swan-lib/logs"
+	libutils "github.com/filswan/go-swan-lib/utils"
+)
+
+const (
+	LOTUS_CLIENT_MINER_QUERY_OFFER = "Filecoin.ClientMinerQueryOffer"
+	LOTUS_CLIENT_RETRIEVE          = "Filecoin.ClientRetrieve"
+)
+
+type queryOffer struct {
+	Err                     string
+	Root                    lotus.Cid
+	Piece                   *lotus.Cid
+	Size                    uint64
+	MinPrice                string
+	UnsealPrice             string
+	PaymentInterval         uint64
+	PaymentIntervalIncrease uint64
+	Miner                   string
+	MinerPeer               retrievalPeer
+}
+
+type retrievalPeer struct {
+	Address  string
+	ID       string
+	PieceCID *lotus.Cid
+}
+
+type clientMinerQueryOffer struct {
+	lotus.LotusJsonRpcResult
+	Result queryOffer `json:"result"`
+}
+
+type retrievalOrder struct {
+	Root                    lotus.Cid
+	Piece                   *lotus.Cid
+	Size                    uint64
+	Total                   string
+	UnsealPrice             string
+	PaymentInterval         uint64
+	PaymentIntervalIncrease uint64
+	Client                  string
+	Miner                   string
+	MinerPeer               *retrievalPeer
+}
+
+type fileRef struct {
+	Path  string
+	IsCAR bool
+}
+
+// RetrieveQueuedSourceFiles runs the queued retrieval jobs one by one, each source file is cut out of the car file
+// retrieved from one of its active deals, and kept under retrieveDir to be downloaded by its owner
+func RetrieveQueuedSourceFiles(retrieveDir string) error {
+	retrievalJobs, err := models.GetRetrievalJobsByStatus(constants.RETRIEVAL_STATUS_QUEUED)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	for _, retrievalJob := range retrievalJobs {
+		claimed, err := models.ClaimRetrievalJob(retrievalJob.ID)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		if !claimed {
+			continue
+		}
+
+		status := constants.RETRIEVAL_STATUS_SUCCEEDED
+		note := ""
+		filePath, err := retrieveSourceFile(retrievalJob.SourceFileId, retrieveDir)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			status = constants.RETRIEVAL_STATUS_FAILED
+			note = err.Error()
+		}
+
+		err = models.UpdateRetrievalJob(retrievalJob.ID, status, filePath, note)
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+	}
+
+	return nil
+}
+
+func retrieveSourceFile(sourceFileId int64, retrieveDir string) (string, error) {
+	sourceFile, err := models.GetSourceFileById(sourceFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	offlineDeals, err := models.GetOfflineDealsBySourceFileId(sourceFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	err = libutils.CreateDir(retrieveDir)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	var carFilePath *string
+	for _, offlineDeal := range offlineDeals {
+		if offlineDeal.Status != constants.DEAL_STATUS_ACTIVE {
+			continue
+		}
+
+		carFilePath, err = retrieveCarFile(sourceFile, offlineDeal, retrieveDir)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		break
+	}
+
+	if carFilePath == nil {
+		err := fmt.Errorf("source file:%d cannot be retrieved from any of its active deals", sourceFileId)
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	filePath := filepath.Join(retrieveDir, sourceFile.PayloadCid)
+	if libutils.IsFileExistsFullPath(filePath) {
+		return filePath, nil
+	}
+
+	err = disk.CheckFreeSize(retrieveDir, sourceFile.FileSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	// the source file is a sub dag of the car file, written to a temporary file first so a failed extraction is not served
+	tmpFilePath := filePath + ".tmp"
+	err = extractSourceFile(*carFilePath, sourceFile.PayloadCid, tmpFilePath)
+	if err != nil {
+		os.Remove(tmpFilePath)
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	err = os.Rename(tmpFilePath, filePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	err = disk.TrackFile(constants.LOCAL_FILE_TYPE_RETRIEVE, filePath, &sourceFile.ID, nil)
+	if err != nil {
+		logs.GetLogger().Error(err)
+	}
+
+	return filePath, nil
+}
+
+func extractSourceFile(carFilePath, payloadCid, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+	defer file.Close()
+
+	err = car.ExtractFile(carFilePath, payloadCid, file)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func retrieveCarFile(sourceFile *models.SourceFile, offlineDeal *models.OfflineDeal, retrieveDir string) (*string, error) {
+	dealFile, err := models.GetDealFileById(offlineDeal.DealFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	carFilePath := filepath.Join(retrieveDir, dealFile.PayloadCid+".car")
+	if libutils.IsFileExistsFullPath(carFilePath) {
+		return &carFilePath, nil
+	}
+
+	err = disk.CheckFreeSize(retrieveDir, dealFile.CarFileSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	startAt := utils.GetCurrentUtcMilliSecond()
+	err = lotusClientRetrieve(dealFile, offlineDeal.MinerFid, carFilePath)
+
+	status := constants.RETRIEVAL_STATUS_SUCCEEDED
+	note := ""
+	if err != nil {
+		status = constants.RETRIEVAL_STATUS_FAILED
+		note = err.Error()
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	retrievalHistory := models.RetrievalHistory{
+		SourceFileId:    sourceFile.ID,
+		DealFileId:      dealFile.ID,
+		MinerFid:        offlineDeal.MinerFid,
+		Status:          status,
+		Note:            note,
+		ElapsedMilliSec: currentUtcMilliSec - startAt,
+		CreateAt:        currentUtcMilliSec,
+		UpdateAt:        currentUtcMilliSec,
+	}
+
+	errSave := database.SaveOne(&retrievalHistory)
+	if errSave != nil {
+		logs.GetLogger().Error(errSave)
+	}
+
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	err = disk.TrackFile(constants.LOCAL_FILE_TYPE_RETRIEVE, carFilePath, &sourceFile.ID, &dealFile.ID)
+	if err != nil {
+		logs.GetLogger().Error(err)
+	}
+
+	return &carFilePath, nil
+}
+
+// lotus writes the retrieved car file to carFilePath, so lotus should run on the same machine as mcs
+func lotusClientRetrieve(dealFile *models.DealFile, minerFid, carFilePath string) error {
+	apiUrl := config.GetConfig().Lotus.ClientApiUrl
+	accessToken := config.GetConfig().Lotus.ClientAccessToken
+
+	var params []interface{}
+	params = append(params, minerFid)
+	params = append(params, lotus.Cid{Cid: dealFile.PayloadCid})
+	params = append(params, lotus.Cid{Cid: dealFile.PieceCid})
+
+	jsonRpcParams := lotus.LotusJsonRpcParams{
+		JsonRpc: lotus.LOTUS_JSON_RPC_VERSION,
+		Method:  LOTUS_CLIENT_MINER_QUERY_OFFER,
+		Params:  params,
+		Id:      lotus.LOTUS_JSON_RPC_ID,
+	}
+
+	response, err := web.HttpGet(apiUrl, accessToken, jsonRpcParams)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	offer := &clientMinerQueryOffer{}
+	err = json.Unmarshal(response, offer)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if offer.Error != nil {
+		err := fmt.Errorf("query offer from miner:%s failed, code:%d, message:%s", minerFid, offer.Error.Code, offer.Error.Message)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if offer.Result.Err != "" {
+		err := fmt.Errorf("miner:%s offer error:%s", minerFid, offer.Result.Err)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	order := retrievalOrder{
+		Root:                    offer.Result.Root,
+		Piece:                   offer.Result.Piece,
+		Size:                    offer.Result.Size,
+		Total:                   offer.Result.MinPrice,
+		UnsealPrice:             offer.Result.UnsealPrice,
+		PaymentInterval:         offer.Result.PaymentInterval,
+		PaymentIntervalIncrease: offer.Result.PaymentIntervalIncrease,
+		Client:                  config.GetConfig().SwanPlatformFilWallet,
+		Miner:                   offer.Result.Miner,
+		MinerPeer:               &offer.Result.MinerPeer,
+	}
+
+	params = []interface{}{}
+	params = append(params, order)
+	params = append(params, fileRef{Path: carFilePath, IsCAR: true})
+
+	jsonRpcParams = lotus.LotusJsonRpcParams{
+		JsonRpc: lotus.LOTUS_JSON_RPC_VERSION,
+		Method:  LOTUS_CLIENT_RETRIEVE,
+		Params:  params,
+		Id:      lotus.LOTUS_JSON_RPC_ID,
+	}
+
+	logs.GetLogger().Info("retrieving payload_cid:", dealFile.PayloadCid, " from miner:", minerFid)
+	response, err = web.HttpGet(apiUrl, accessToken, jsonRpcParams)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	retrieveResult := &lotus.LotusJsonRpcResult{}
+	err = json.Unmarshal(response, retrieveResult)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if retrieveResult.Error != nil {
+		err := fmt.Errorf("retrieve from miner:%s failed, code:%d, message:%s", minerFid, retrieveResult.Error.Code, retrieveResult.Error.Message)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if !libutils.IsFileExistsFullPath(carFilePath) {
+		err := fmt.Errorf("retrieved car file:%s not found, please check lotus runs on the same machine", carFilePath)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	logs.GetLogger().Info("payload_cid:", dealFile.PayloadCid, " retrieved from miner:", minerFid, " to:", carFilePath)
+	return nil
+}
@@ -0,0 +1,476 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"multi-chain-storage/alert"
+	"multi-chain-storage/common"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/errorinfo"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/disk"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+	"multi-chain-storage/scheduler"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/gin-gonic/gin"
+)
+
+func AdminManager(router *gin.RouterGroup) {
+	router.Use(checkAdminToken)
+	router.GET("/disk", GetDiskUsage)
+	router.GET("/alerts", GetAlerts)
+	router.POST("/alerts/test", SendTestAlert)
+	router.GET("/wallets", GetWalletStatus)
+	router.GET("/dao/deals/verifications", GetDaoDealVerifications)
+	router.GET("/dao/members", GetDaoMembers)
+	router.GET("/dao/members/statistics", GetDaoMemberStatistics)
+	router.POST("/dao/proposals", CreateDaoProposal)
+	router.GET("/dao/proposals", GetDaoProposals)
+	router.POST("/dao/proposals/:proposal_id/approve", ApproveDaoProposal)
+	router.POST("/dao/proposals/:proposal_id/reject", RejectDaoProposal)
+	router.GET("/ledger/discrepancies", GetLedgerDiscrepancies)
+	router.POST("/ledger/discrepancies/:discrepancy_id/apply", ApplyLedgerDiscrepancy)
+	router.POST("/ledger/discrepancies/:discrepancy_id/dismiss", DismissLedgerDiscrepancy)
+	router.GET("/ledger/reconciliations", GetReconciliationRuns)
+}
+
+const ADMIN_OPERATOR_CONTEXT_KEY = "admin_operator"
+
+type daoProposalParam struct {
+	Action string `json:"action"`
+	daoProposalParams
+}
+
+// admin apis are disabled when neither adminToken nor adminOperatorTokens is set in .env,
+// the operator of a request is the name of its operator token, adminToken has no operator
+func checkAdminToken(c *gin.Context) {
+	authorization := c.GetHeader(constants.HTTP_REQUEST_HEADER_AUTHRORIZATION)
+	token := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer"))
+	if token != "" {
+		adminToken := os.Getenv(constants.ADMIN_TOKEN)
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			c.Next()
+			return
+		}
+
+		for operator, operatorToken := range getOperatorTokens() {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(operatorToken)) == 1 {
+				c.Set(ADMIN_OPERATOR_CONTEXT_KEY, operator)
+				c.Next()
+				return
+			}
+		}
+	}
+
+	logs.GetLogger().Error("invalid admin token from ", c.ClientIP())
+	c.AbortWithStatusJSON(http.StatusUnauthorized, common.CreateErrorResponse(errorinfo.ADMIN_TOKEN_ERROR_CODE))
+}
+
+// getOperatorTokens parses adminOperatorTokens in the form of operator1:token1,operator2:token2,
+// an operator or a token given more than once is ignored, so that a token never maps to 2 operators
+func getOperatorTokens() map[string]string {
+	operatorTokens := map[string]string{}
+	operatorCounts := map[string]int{}
+	tokenCounts := map[string]int{}
+	for _, operatorToken := range strings.Split(os.Getenv(constants.ADMIN_OPERATOR_TOKENS), ",") {
+		fields := strings.SplitN(operatorToken, ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		operator := strings.TrimSpace(fields[0])
+		token := strings.TrimSpace(fields[1])
+		if operator == "" || token == "" {
+			continue
+		}
+
+		operatorTokens[operator] = token
+		operatorCounts[operator]++
+		tokenCounts[token]++
+	}
+
+	for operator, token := range operatorTokens {
+		if operatorCounts[operator] > 1 || tokenCounts[token] > 1 {
+			logs.GetLogger().Error("operator:", operator, " or its token is given more than once in ", constants.ADMIN_OPERATOR_TOKENS)
+			delete(operatorTokens, operator)
+		}
+	}
+
+	return operatorTokens
+}
+
+// getOperator returns the operator of the token of the request, changes to the dao contract and the ledger are only made by operators
+func getOperator(c *gin.Context) (string, bool) {
+	operator := c.GetString(ADMIN_OPERATOR_CONTEXT_KEY)
+	if operator == "" {
+		errMsg := "an operator token in " + constants.ADMIN_OPERATOR_TOKENS + " is required"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusForbidden, common.CreateErrorResponse(errorinfo.ADMIN_OPERATOR_ERROR_CODE, errMsg))
+		return "", false
+	}
+
+	return operator, true
+}
+
+func GetDiskUsage(c *gin.Context) {
+	diskUsage, err := disk.GetDiskUsage(scheduler.GetSrcDir(), scheduler.GetCarDir())
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_DISK_USAGE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(diskUsage))
+}
+
+func GetWalletStatus(c *gin.Context) {
+	ethClient, _, err := client.GetEthClient()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_WALLET_STATUS_ERROR_CODE, err.Error()))
+		return
+	}
+	defer ethClient.Close()
+
+	walletStatus, err := scheduler.GetWalletStatus(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_WALLET_STATUS_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(walletStatus))
+}
+
+func GetAlerts(c *gin.Context) {
+	URL := c.Request.URL.Query()
+	pageNumber := strings.Trim(URL.Get("page_number"), " ")
+	if pageNumber == "" || pageNumber == "0" {
+		pageNumber = "1"
+	}
+
+	pageSize := strings.Trim(URL.Get("page_size"), " ")
+	if pageSize == "" {
+		pageSize = constants.PAGE_SIZE_DEFAULT_VALUE
+	}
+
+	offset, err := utils.GetOffsetByPagenumber(pageNumber, pageSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.PAGE_NUMBER_OR_SIZE_FORMAT_ERROR_CODE))
+		return
+	}
+
+	limit, _ := strconv.Atoi(pageSize)
+	alerts, err := models.GetAlerts(limit, int(offset))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(alerts))
+}
+
+// SendTestAlert sends a test notification by all the notifiers configured, to check the smtp server and urls
+func SendTestAlert(c *gin.Context) {
+	notifiers := alert.GetNotifiers()
+	if len(notifiers) == 0 {
+		errMsg := "no notifier configured in [alert]"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.ALERT_NOTIFY_ERROR_CODE, errMsg))
+		return
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	errs := alert.Notify(&alert.Notification{
+		RuleName: "test",
+		Status:   constants.ALERT_STATUS_FIRING,
+		Message:  "test notification from multi-chain-storage",
+		FireAt:   currentUtcMilliSec,
+		CreateAt: currentUtcMilliSec,
+	})
+
+	results := map[string]string{}
+	for _, notifier := range notifiers {
+		results[notifier.Name()] = "sent"
+		if err, ok := errs[notifier.Name()]; ok {
+			results[notifier.Name()] = err.Error()
+		}
+	}
+
+	if len(errs) > 0 {
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.ALERT_NOTIFY_ERROR_CODE, fmt.Sprint(results)))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(results))
+}
+
+// GetDaoDealVerifications lists the deals not served to dao members and why, status is Mismatched by default
+func GetDaoDealVerifications(c *gin.Context) {
+	URL := c.Request.URL.Query()
+	status := strings.Trim(URL.Get("status"), " ")
+	if status == "" {
+		status = constants.DAO_DEAL_VERIFICATION_STATUS_MISMATCHED
+	}
+
+	pageNumber := strings.Trim(URL.Get("page_number"), " ")
+	if pageNumber == "" || pageNumber == "0" {
+		pageNumber = "1"
+	}
+
+	pageSize := strings.Trim(URL.Get("page_size"), " ")
+	if pageSize == "" {
+		pageSize = constants.PAGE_SIZE_DEFAULT_VALUE
+	}
+
+	offset, err := utils.GetOffsetByPagenumber(pageNumber, pageSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.PAGE_NUMBER_OR_SIZE_FORMAT_ERROR_CODE))
+		return
+	}
+
+	limit, _ := strconv.Atoi(pageSize)
+	verifications, err := models.GetDaoDealVerificationsByStatus(status, limit, int(offset))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(verifications))
+}
+
+func GetDaoMembers(c *gin.Context) {
+	daoMembers, err := getDaoMembers()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_DAO_MEMBERS_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(daoMembers))
+}
+
+func GetDaoMemberStatistics(c *gin.Context) {
+	days := constants.DAO_STATS_DAYS_DEFAULT
+	daysStr := strings.Trim(c.Query("days"), " ")
+	if daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			errMsg := "days should be a positive number"
+			logs.GetLogger().Error(errMsg)
+			c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+			return
+		}
+	}
+
+	statistics, err := getDaoMemberStatistics(days)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(statistics))
+}
+
+// CreateDaoProposal proposes setDAOUsers or updateThreshold, it is not sent to the dao contract until another operator approves it
+func CreateDaoProposal(c *gin.Context) {
+	operator, ok := getOperator(c)
+	if !ok {
+		return
+	}
+
+	var param daoProposalParam
+	err := c.BindJSON(&param)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARSER_RESPONSE_TO_STRUCT_ERROR_CODE, err.Error()))
+		return
+	}
+
+	daoProposal, err := createDaoProposal(param.Action, operator, param.daoProposalParams)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.DAO_PROPOSAL_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(daoProposal))
+}
+
+func GetDaoProposals(c *gin.Context) {
+	URL := c.Request.URL.Query()
+	pageNumber := strings.Trim(URL.Get("page_number"), " ")
+	if pageNumber == "" || pageNumber == "0" {
+		pageNumber = "1"
+	}
+
+	pageSize := strings.Trim(URL.Get("page_size"), " ")
+	if pageSize == "" {
+		pageSize = constants.PAGE_SIZE_DEFAULT_VALUE
+	}
+
+	offset, err := utils.GetOffsetByPagenumber(pageNumber, pageSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.PAGE_NUMBER_OR_SIZE_FORMAT_ERROR_CODE))
+		return
+	}
+
+	limit, _ := strconv.Atoi(pageSize)
+	daoProposals, err := models.GetDaoProposals(limit, int(offset))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(daoProposals))
+}
+
+func ApproveDaoProposal(c *gin.Context) {
+	reviewDaoProposalByOperator(c, true)
+}
+
+func RejectDaoProposal(c *gin.Context) {
+	reviewDaoProposalByOperator(c, false)
+}
+
+func reviewDaoProposalByOperator(c *gin.Context, approve bool) {
+	operator, ok := getOperator(c)
+	if !ok {
+		return
+	}
+
+	proposalId, err := strconv.ParseInt(strings.Trim(c.Params.ByName("proposal_id"), " "), 10, 64)
+	if err != nil {
+		errMsg := "proposal id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	daoProposal, err := reviewDaoProposal(proposalId, operator, approve)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.DAO_PROPOSAL_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(daoProposal))
+}
+
+// GetLedgerDiscrepancies lists the discrepancies found by the ledger reconciliation, status is Open by default
+func GetLedgerDiscrepancies(c *gin.Context) {
+	URL := c.Request.URL.Query()
+	status := strings.Trim(URL.Get("status"), " ")
+	if status == "" {
+		status = constants.LEDGER_DISCREPANCY_STATUS_OPEN
+	}
+
+	pageNumber := strings.Trim(URL.Get("page_number"), " ")
+	if pageNumber == "" || pageNumber == "0" {
+		pageNumber = "1"
+	}
+
+	pageSize := strings.Trim(URL.Get("page_size"), " ")
+	if pageSize == "" {
+		pageSize = constants.PAGE_SIZE_DEFAULT_VALUE
+	}
+
+	offset, err := utils.GetOffsetByPagenumber(pageNumber, pageSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.PAGE_NUMBER_OR_SIZE_FORMAT_ERROR_CODE))
+		return
+	}
+
+	limit, _ := strconv.Atoi(pageSize)
+	ledgerDiscrepancies, err := models.GetLedgerDiscrepancies(status, limit, int(offset))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(ledgerDiscrepancies))
+}
+
+// ApplyLedgerDiscrepancy sets the database to the value on chain, it is done only when the discrepancy is still on chain
+func ApplyLedgerDiscrepancy(c *gin.Context) {
+	reviewLedgerDiscrepancyByOperator(c, true)
+}
+
+func DismissLedgerDiscrepancy(c *gin.Context) {
+	reviewLedgerDiscrepancyByOperator(c, false)
+}
+
+func reviewLedgerDiscrepancyByOperator(c *gin.Context, apply bool) {
+	operator, ok := getOperator(c)
+	if !ok {
+		return
+	}
+
+	discrepancyId, err := strconv.ParseInt(strings.Trim(c.Params.ByName("discrepancy_id"), " "), 10, 64)
+	if err != nil {
+		errMsg := "discrepancy id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	var ledgerDiscrepancy *models.LedgerDiscrepancy
+	if apply {
+		ledgerDiscrepancy, err = applyLedgerDiscrepancy(discrepancyId, operator)
+	} else {
+		ledgerDiscrepancy, err = dismissLedgerDiscrepancy(discrepancyId, operator)
+	}
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.LEDGER_DISCREPANCY_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(ledgerDiscrepancy))
+}
+
+func GetReconciliationRuns(c *gin.Context) {
+	URL := c.Request.URL.Query()
+	pageNumber := strings.Trim(URL.Get("page_number"), " ")
+	if pageNumber == "" || pageNumber == "0" {
+		pageNumber = "1"
+	}
+
+	pageSize := strings.Trim(URL.Get("page_size"), " ")
+	if pageSize == "" {
+		pageSize = constants.PAGE_SIZE_DEFAULT_VALUE
+	}
+
+	offset, err := utils.GetOffsetByPagenumber(pageNumber, pageSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.PAGE_NUMBER_OR_SIZE_FORMAT_ERROR_CODE))
+		return
+	}
+
+	limit, _ := strconv.Atoi(pageSize)
+	reconciliationRuns, err := models.GetReconciliationRuns(limit, int(offset))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(reconciliationRuns))
+}
@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"multi-chain-storage/common/constants"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetOperatorTokens(t *testing.T) {
+	os.Setenv(constants.ADMIN_OPERATOR_TOKENS, " alice:token-a , bob:token-b,carol:token-a,dave:,:token-e,erin:token-f,erin:token-g,frank")
+	defer os.Unsetenv(constants.ADMIN_OPERATOR_TOKENS)
+
+	operatorTokens := getOperatorTokens()
+	expected := map[string]string{"bob": "token-b"}
+	if !reflect.DeepEqual(operatorTokens, expected) {
+		t.Errorf("getOperatorTokens() = %v, expected %v", operatorTokens, expected)
+	}
+}
+
+func TestCheckAdminToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv(constants.ADMIN_TOKEN, "admin-token")
+	os.Setenv(constants.ADMIN_OPERATOR_TOKENS, "alice:token-a,bob:token-b")
+	defer os.Unsetenv(constants.ADMIN_TOKEN)
+	defer os.Unsetenv(constants.ADMIN_OPERATOR_TOKENS)
+
+	router := gin.New()
+	router.Use(checkAdminToken)
+	router.POST("/operator", func(c *gin.Context) {
+		operator, ok := getOperator(c)
+		if !ok {
+			return
+		}
+		c.String(http.StatusOK, operator)
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		operator      string
+	}{
+		{"no token", "", http.StatusUnauthorized, ""},
+		{"wrong token", "Bearer token-c", http.StatusUnauthorized, ""},
+		{"admin token has no operator", "Bearer admin-token", http.StatusForbidden, ""},
+		{"operator token", "Bearer token-a", http.StatusOK, "alice"},
+		{"another operator token", "Bearer token-b", http.StatusOK, "bob"},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/operator", nil)
+		if test.authorization != "" {
+			request.Header.Set(constants.HTTP_REQUEST_HEADER_AUTHRORIZATION, test.authorization)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.status {
+			t.Errorf("%s: status %d, expected %d", test.name, recorder.Code, test.status)
+			continue
+		}
+
+		if test.operator != "" && recorder.Body.String() != test.operator {
+			t.Errorf("%s: operator %s, expected %s", test.name, recorder.Body.String(), test.operator)
+		}
+	}
+}
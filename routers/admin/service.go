@@ -0,0 +1,546 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+	"multi-chain-storage/scheduler"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/filswan/go-swan-lib/logs"
+)
+
+type daoMember struct {
+	DaoName     string `json:"dao_name"`
+	DaoAddress  string `json:"dao_address"`
+	Description string `json:"description"`
+}
+
+type daoProposalParams struct {
+	DaoMembers []*daoMember `json:"dao_members,omitempty"`
+	Threshold  uint8        `json:"threshold,omitempty"`
+}
+
+type DaoMembers struct {
+	Threshold       uint8             `json:"threshold"`
+	ContractMembers []string          `json:"contract_members"`
+	DaoInfos        []*models.DaoInfo `json:"dao_infos"`
+	NotOnContract   []string          `json:"not_on_contract"`
+	NotInDaoInfo    []string          `json:"not_in_dao_info"`
+}
+
+type DaoMemberStatistics struct {
+	DaoName           string  `json:"dao_name"`
+	DaoAddress        string  `json:"dao_address"`
+	SignedDealCount   int64   `json:"signed_deal_count"`
+	Participation     float64 `json:"participation"`
+	AvgLatencyMinutes float64 `json:"avg_latency_minutes"`
+	MaxLatencyMinutes float64 `json:"max_latency_minutes"`
+	LastSignedAt      int64   `json:"last_signed_at"`
+}
+
+// getDaoMembers diffs the members having DAO_ROLE on the dao contract against table dao_info
+func getDaoMembers() (*DaoMembers, error) {
+	ethClient, _, err := client.GetEthClient()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+	defer ethClient.Close()
+
+	threshold, err := client.GetThreshHold()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	contractMembers, err := client.GetDaoRoleMembers(ethClient, config.GetConfig().Polygon.DaoContractStartBlock)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	daoInfos, err := models.GetDaoInfos()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	daoMembers := &DaoMembers{
+		Threshold:       threshold,
+		ContractMembers: []string{},
+		DaoInfos:        daoInfos,
+		NotOnContract:   []string{},
+		NotInDaoInfo:    []string{},
+	}
+
+	contractMemberMap := map[string]bool{}
+	for _, contractMember := range contractMembers {
+		daoMembers.ContractMembers = append(daoMembers.ContractMembers, contractMember.Hex())
+		contractMemberMap[strings.ToLower(contractMember.Hex())] = true
+	}
+	sort.Strings(daoMembers.ContractMembers)
+
+	daoInfoMap := map[string]bool{}
+	for _, daoInfo := range daoInfos {
+		daoInfoMap[strings.ToLower(daoInfo.DaoAddress)] = true
+		if !contractMemberMap[strings.ToLower(daoInfo.DaoAddress)] {
+			daoMembers.NotOnContract = append(daoMembers.NotOnContract, daoInfo.DaoAddress)
+		}
+	}
+
+	for _, contractMember := range daoMembers.ContractMembers {
+		if !daoInfoMap[strings.ToLower(contractMember)] {
+			daoMembers.NotInDaoInfo = append(daoMembers.NotInDaoInfo, contractMember)
+		}
+	}
+
+	return daoMembers, nil
+}
+
+func createDaoProposal(action, proposedBy string, params daoProposalParams) (*models.DaoProposal, error) {
+	switch action {
+	case constants.DAO_PROPOSAL_ACTION_SET_DAO_USERS:
+		if len(params.DaoMembers) == 0 {
+			err := fmt.Errorf("dao_members is required by %s", action)
+			return nil, err
+		}
+
+		daoAddresses := map[string]bool{}
+		for _, member := range params.DaoMembers {
+			if !common.IsHexAddress(member.DaoAddress) {
+				err := fmt.Errorf("invalid dao address:%s", member.DaoAddress)
+				return nil, err
+			}
+
+			daoAddress := strings.ToLower(member.DaoAddress)
+			if daoAddresses[daoAddress] {
+				err := fmt.Errorf("duplicated dao address:%s", member.DaoAddress)
+				return nil, err
+			}
+			daoAddresses[daoAddress] = true
+		}
+		params.Threshold = 0
+	case constants.DAO_PROPOSAL_ACTION_UPDATE_THRESHOLD:
+		if params.Threshold == 0 {
+			err := fmt.Errorf("threshold should be above 0")
+			return nil, err
+		}
+		params.DaoMembers = nil
+	default:
+		err := fmt.Errorf("action should be %s or %s", constants.DAO_PROPOSAL_ACTION_SET_DAO_USERS, constants.DAO_PROPOSAL_ACTION_UPDATE_THRESHOLD)
+		return nil, err
+	}
+
+	paramsJson, err := json.Marshal(params)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	daoProposal := &models.DaoProposal{
+		Action:     action,
+		Params:     string(paramsJson),
+		Status:     constants.DAO_PROPOSAL_STATUS_PENDING,
+		ProposedBy: proposedBy,
+		CreateAt:   currentUtcMilliSec,
+		UpdateAt:   currentUtcMilliSec,
+	}
+
+	err = database.SaveOne(daoProposal)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return daoProposal, nil
+}
+
+// reviewDaoProposal approves or rejects a pending proposal, an approved proposal is submitted to the dao contract at once
+func reviewDaoProposal(id int64, reviewedBy string, approve bool) (*models.DaoProposal, error) {
+	daoProposal, err := models.GetDaoProposalById(id)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if daoProposal == nil {
+		err := fmt.Errorf("dao proposal:%d not found", id)
+		return nil, err
+	}
+
+	if strings.EqualFold(daoProposal.ProposedBy, reviewedBy) {
+		err := fmt.Errorf("dao proposal:%d should be reviewed by an operator other than %s who proposed it", id, daoProposal.ProposedBy)
+		return nil, err
+	}
+
+	status := constants.DAO_PROPOSAL_STATUS_REJECTED
+	if approve {
+		status = constants.DAO_PROPOSAL_STATUS_APPROVED
+	}
+
+	reviewed, err := models.ReviewDaoProposal(id, status, reviewedBy)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if !reviewed {
+		err := fmt.Errorf("dao proposal:%d is %s, not %s", id, daoProposal.Status, constants.DAO_PROPOSAL_STATUS_PENDING)
+		return nil, err
+	}
+
+	if approve {
+		err = submitDaoProposal(daoProposal)
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+	}
+
+	return models.GetDaoProposalById(id)
+}
+
+// dao_info is synced only after all the txs of the proposal succeeded
+func submitDaoProposal(daoProposal *models.DaoProposal) error {
+	txHashes, err := sendDaoProposalTxs(daoProposal)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		errUpdate := models.UpdateDaoProposalResult(daoProposal.ID, constants.DAO_PROPOSAL_STATUS_FAILED, strings.Join(txHashes, ","), err.Error())
+		if errUpdate != nil {
+			logs.GetLogger().Error(errUpdate)
+		}
+		return err
+	}
+
+	err = models.UpdateDaoProposalResult(daoProposal.ID, constants.DAO_PROPOSAL_STATUS_SUBMITTED, strings.Join(txHashes, ","), "")
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if daoProposal.Action != constants.DAO_PROPOSAL_ACTION_SET_DAO_USERS {
+		return nil
+	}
+
+	params := daoProposalParams{}
+	err = json.Unmarshal([]byte(daoProposal.Params), &params)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	daoInfos := []*models.DaoInfo{}
+	for _, member := range params.DaoMembers {
+		daoInfos = append(daoInfos, &models.DaoInfo{
+			DaoName:     member.DaoName,
+			DaoAddress:  common.HexToAddress(member.DaoAddress).Hex(),
+			Description: member.Description,
+		})
+	}
+
+	err = models.SyncDaoInfos(daoInfos)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// sendDaoProposalTxs returns the hashes of the txs sent, setDAOUsers only grants DAO_ROLE to the members in the list,
+// so DAO_ROLE is revoked from each member on the contract but not in the list by one more tx
+func sendDaoProposalTxs(daoProposal *models.DaoProposal) ([]string, error) {
+	txHashes := []string{}
+	params := daoProposalParams{}
+	err := json.Unmarshal([]byte(daoProposal.Params), &params)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return txHashes, err
+	}
+
+	ethClient, _, err := client.GetEthClient()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return txHashes, err
+	}
+	defer ethClient.Close()
+
+	filswanOracleTransactor, err := client.GetFilswanOracleTransactor(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return txHashes, err
+	}
+
+	switch daoProposal.Action {
+	case constants.DAO_PROPOSAL_ACTION_SET_DAO_USERS:
+		daoUsers := []common.Address{}
+		daoUserMap := map[common.Address]bool{}
+		for _, member := range params.DaoMembers {
+			daoUser := common.HexToAddress(member.DaoAddress)
+			daoUsers = append(daoUsers, daoUser)
+			daoUserMap[daoUser] = true
+		}
+
+		contractMembers, err := client.GetDaoRoleMembers(ethClient, config.GetConfig().Polygon.DaoContractStartBlock)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return txHashes, err
+		}
+
+		filswanOracleSession, err := client.GetFilswanOracleSession(ethClient)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return txHashes, err
+		}
+
+		daoRole, err := filswanOracleSession.DAOROLE()
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return txHashes, err
+		}
+
+		txHash, err := sendDaoProposalTx(ethClient, "setDAOUsers", func(transactOpts *bind.TransactOpts) (*types.Transaction, error) {
+			return filswanOracleTransactor.SetDAOUsers(transactOpts, daoUsers)
+		})
+		if txHash != "" {
+			txHashes = append(txHashes, txHash)
+		}
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return txHashes, err
+		}
+
+		for _, contractMember := range contractMembers {
+			if daoUserMap[contractMember] {
+				continue
+			}
+
+			member := contractMember
+			txHash, err := sendDaoProposalTx(ethClient, "revokeRole of "+member.Hex(), func(transactOpts *bind.TransactOpts) (*types.Transaction, error) {
+				return filswanOracleTransactor.RevokeRole(transactOpts, daoRole, member)
+			})
+			if txHash != "" {
+				txHashes = append(txHashes, txHash)
+			}
+			if err != nil {
+				logs.GetLogger().Error(err)
+				return txHashes, err
+			}
+		}
+	case constants.DAO_PROPOSAL_ACTION_UPDATE_THRESHOLD:
+		txHash, err := sendDaoProposalTx(ethClient, "updateThreshold", func(transactOpts *bind.TransactOpts) (*types.Transaction, error) {
+			return filswanOracleTransactor.UpdateThreshold(transactOpts, params.Threshold)
+		})
+		if txHash != "" {
+			txHashes = append(txHashes, txHash)
+		}
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return txHashes, err
+		}
+	default:
+		err := fmt.Errorf("unknown dao proposal action:%s", daoProposal.Action)
+		logs.GetLogger().Error(err)
+		return txHashes, err
+	}
+
+	return txHashes, nil
+}
+
+// sendDaoProposalTx sends one tx with the key of privateKeyOnPolygon and waits for it to succeed
+func sendDaoProposalTx(ethClient *ethclient.Client, method string, send func(transactOpts *bind.TransactOpts) (*types.Transaction, error)) (string, error) {
+	privateKey, publicKeyAddress, err := client.GetPrivateKeyPublicKey(constants.PRIVATE_KEY_ON_POLYGON)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	tansactOpts, err := client.GetTransactOpts(ethClient, privateKey, *publicKeyAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	tx, err := send(tansactOpts)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	txHash := tx.Hash().Hex()
+	txReceipt, err := client.CheckTx(ethClient, tx)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return txHash, err
+	}
+
+	if txReceipt.Status != uint64(1) {
+		err := fmt.Errorf("%s failed! txHash=%s", method, txHash)
+		logs.GetLogger().Error(err)
+		return txHash, err
+	}
+
+	return txHash, nil
+}
+
+// getDaoMemberStatistics returns the signing stats of each member in dao_info within the last days,
+// participation is the share of the deals signed by any member that were also signed by this member
+func getDaoMemberStatistics(days int) ([]*DaoMemberStatistics, error) {
+	blockTimeMin := time.Now().AddDate(0, 0, -days).Unix()
+	statistics, dealCount, err := models.GetDaoSignatureStatistics(blockTimeMin)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	statisticsMap := map[string]*models.DaoSignatureStatistics{}
+	for _, statistic := range statistics {
+		statisticsMap[strings.ToLower(statistic.DaoAddress)] = statistic
+	}
+
+	daoInfos, err := models.GetDaoInfos()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	memberStatistics := []*DaoMemberStatistics{}
+	for _, daoInfo := range daoInfos {
+		memberStatistic := &DaoMemberStatistics{
+			DaoName:    daoInfo.DaoName,
+			DaoAddress: daoInfo.DaoAddress,
+		}
+
+		if statistic, ok := statisticsMap[strings.ToLower(daoInfo.DaoAddress)]; ok {
+			memberStatistic.SignedDealCount = statistic.SignedDealCount
+			memberStatistic.AvgLatencyMinutes = statistic.AvgLatencyMinutes
+			memberStatistic.MaxLatencyMinutes = statistic.MaxLatencyMinutes
+			memberStatistic.LastSignedAt = statistic.LastSignedAt
+			if dealCount > 0 {
+				memberStatistic.Participation = float64(statistic.SignedDealCount) / float64(dealCount)
+			}
+		}
+
+		memberStatistics = append(memberStatistics, memberStatistic)
+	}
+
+	return memberStatistics, nil
+}
+
+// applyLedgerDiscrepancy sets the database to the value on chain after checking the discrepancy is still there,
+// a lock payment on chain but not in database is created from chain, and one in database but not on chain can only be dismissed
+func applyLedgerDiscrepancy(id int64, reviewedBy string) (*models.LedgerDiscrepancy, error) {
+	ledgerDiscrepancy, err := models.GetLedgerDiscrepancyById(id)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if ledgerDiscrepancy == nil {
+		err := fmt.Errorf("ledger discrepancy:%d not found", id)
+		return nil, err
+	}
+
+	if ledgerDiscrepancy.Status != constants.LEDGER_DISCREPANCY_STATUS_OPEN {
+		err := fmt.Errorf("ledger discrepancy:%d is %s, not %s", id, ledgerDiscrepancy.Status, constants.LEDGER_DISCREPANCY_STATUS_OPEN)
+		return nil, err
+	}
+
+	if ledgerDiscrepancy.Field == constants.LEDGER_FIELD_LOCK_PAYMENT && ledgerDiscrepancy.ChainValue != constants.LEDGER_VALUE_EXISTS {
+		err := fmt.Errorf("ledger discrepancy:%d, lock payment not on chain can only be dismissed", id)
+		return nil, err
+	}
+
+	isOpen, err := scheduler.IsLedgerDiscrepancyOpen(ledgerDiscrepancy)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if !isOpen {
+		err := fmt.Errorf("ledger discrepancy:%d, %s has changed on chain, please wait for the next reconciliation", id, ledgerDiscrepancy.Field)
+		return nil, err
+	}
+
+	if ledgerDiscrepancy.Field != constants.LEDGER_FIELD_LOCK_PAYMENT {
+		err = models.ApplyLedgerDiscrepancy(ledgerDiscrepancy, reviewedBy)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		return models.GetLedgerDiscrepancyById(id)
+	}
+
+	lockedPayment, err := client.GetLockedPaymentInfo(ledgerDiscrepancy.PayloadCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	coin, err := models.FindCoinByCoinAddress(lockedPayment.TokenAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	eventLockPayment := models.EventLockPayment{
+		PayloadCid:      ledgerDiscrepancy.PayloadCid,
+		TokenAddress:    lockedPayment.TokenAddress,
+		MinPayment:      lockedPayment.MinPayment,
+		LockedFee:       lockedPayment.LockedFee,
+		Deadline:        lockedPayment.Deadline,
+		AddressFrom:     lockedPayment.AddressFrom,
+		AddressTo:       lockedPayment.AddressTo,
+		CoinId:          coin.ID,
+		NetworkId:       coin.NetworkId,
+		LockPaymentTime: utils.GetCurrentUtcMilliSecond(),
+		SourceFileId:    ledgerDiscrepancy.SourceFileId,
+	}
+
+	err = models.CreateEventLockPayment(eventLockPayment)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	reviewed, err := models.ReviewLedgerDiscrepancy(id, constants.LEDGER_DISCREPANCY_STATUS_APPLIED, reviewedBy)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if !reviewed {
+		err := fmt.Errorf("ledger discrepancy:%d is not %s any more", id, constants.LEDGER_DISCREPANCY_STATUS_OPEN)
+		return nil, err
+	}
+
+	return models.GetLedgerDiscrepancyById(id)
+}
+
+func dismissLedgerDiscrepancy(id int64, reviewedBy string) (*models.LedgerDiscrepancy, error) {
+	reviewed, err := models.ReviewLedgerDiscrepancy(id, constants.LEDGER_DISCREPANCY_STATUS_DISMISSED, reviewedBy)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if !reviewed {
+		err := fmt.Errorf("ledger discrepancy:%d not found or not %s", id, constants.LEDGER_DISCREPANCY_STATUS_OPEN)
+		return nil, err
+	}
+
+	return models.GetLedgerDiscrepancyById(id)
+}
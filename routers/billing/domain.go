@@ -1,5 +1,11 @@
 package billing
 
+import (
+	"multi-chain-storage/models"
+
+	"github.com/shopspring/decimal"
+)
+
 type BillingResult struct {
 	TxHash              string `json:"tx_hash"`
 	LockedFee           string `json:"locked_fee"`
@@ -29,3 +35,95 @@ type PriceResult struct {
 		Usd float64 `json:"usd"`
 	} `json:"filecoin"`
 }
+
+// DealAudit explains how the locked fees of the source files in a deal were spent on unlock, each figure comes with the tx backing it
+type DealAudit struct {
+	DealId               int64                    `json:"deal_id"`
+	Network              string                   `json:"network"`
+	MinerFid             string                   `json:"miner_fid"`
+	CarPayloadCid        string                   `json:"car_payload_cid"`
+	PieceCid             string                   `json:"piece_cid"`
+	UnlockStatus         string                   `json:"unlock_status"`
+	TokenAddress         string                   `json:"token_address"`
+	LockedFee            decimal.Decimal          `json:"locked_fee"`
+	LockPayments         []*DealAuditLockPayment  `json:"lock_payments"`
+	DaoSignatures        []*DealAuditDaoSignature `json:"dao_signatures"`
+	ServiceCost          string                   `json:"service_cost"`
+	ServiceCostTxHash    string                   `json:"service_cost_tx_hash"`
+	CurrentPrice         string                   `json:"current_price"`
+	CurrentPriceAt       int64                    `json:"current_price_at"`
+	CurrentPriceContract string                   `json:"current_price_contract"`
+	Unlock               *DealAuditUnlock         `json:"unlock"`
+	FileShares           []*DealAuditFileShare    `json:"file_shares"`
+	Notes                []string                 `json:"notes"`
+	GeneratedAt          int64                    `json:"generated_at"`
+}
+
+type DealAuditLockPayment struct {
+	SourceFileId    int64           `json:"source_file_id"`
+	PayloadCid      string          `json:"payload_cid"`
+	AddressFrom     string          `json:"address_from"`
+	LockedFee       decimal.Decimal `json:"locked_fee"`
+	TxHash          string          `json:"tx_hash"`
+	BlockNo         uint64          `json:"block_no"`
+	LockPaymentTime int64           `json:"lock_payment_time"`
+}
+
+type DealAuditDaoSignature struct {
+	DaoAddress            string `json:"dao_address"`
+	BlockNo               uint64 `json:"block_no"`
+	BlockTime             string `json:"block_time"`
+	SignatureUnlockStatus string `json:"signature_unlock_status"`
+	TxHash                string `json:"tx_hash"`
+}
+
+// DealAuditUnlock is read from the unlock tx, token amount is the service cost of the UnlockCarPayment event,
+// or of the Transfer to the recipient when the contract emits no event, and transferred amount is what the recipient received
+type DealAuditUnlock struct {
+	TxHash            string `json:"tx_hash"`
+	BlockNo           uint64 `json:"block_no"`
+	Recipient         string `json:"recipient"`
+	TokenAmount       string `json:"token_amount"`
+	TransferredAmount string `json:"transferred_amount"`
+}
+
+// DealAuditFileShare is the cost charged to a source file, the locked fee taken from it by the unlock tx,
+// cost by size splits the service cost by the sizes on the payment contract now, and is only a cross-check
+type DealAuditFileShare struct {
+	SourceFileId          *int64           `json:"source_file_id"`
+	PayloadCid            string           `json:"payload_cid"`
+	Size                  int64            `json:"size"`
+	Cost                  string           `json:"cost"`
+	CostBySize            string           `json:"cost_by_size"`
+	LockTxHash            string           `json:"lock_tx_hash"`
+	LockedFeeBeforeUnlock *decimal.Decimal `json:"locked_fee_before_unlock"`
+	LockedFeeAfterUnlock  *decimal.Decimal `json:"locked_fee_after_unlock"`
+}
+
+type QuoteRequest struct {
+	FileSize     int64  `json:"file_size"`
+	Duration     int    `json:"duration"`
+	Replicas     int    `json:"replicas"`
+	Verified     bool   `json:"verified"`
+	TokenAddress string `json:"token_address"`
+}
+
+// Quote is the amount to lock for a file, priced at the max price of the deals, it is valid until expire_at.
+// Lock amount, min payment and lock time are the params of lockTokenPayment, and deadline is the one expected on chain if locked now
+type Quote struct {
+	QuoteRequest
+	Rate       string `json:"rate"`
+	MaxPrice   string `json:"max_price"`
+	LockAmount string `json:"lock_amount"`
+	MinPayment string `json:"min_payment"`
+	LockTime   int64  `json:"lock_time"`
+	Deadline   int64  `json:"deadline"`
+	ExpireAt   int64  `json:"expire_at"`
+	Signature  string `json:"signature"`
+}
+
+// LockPaymentParam is the lock payment with the quote it is locked for, the quote is checked when it is given or required
+type LockPaymentParam struct {
+	models.EventLockPayment
+	Quote *Quote `json:"quote"`
+}
@@ -1,6 +1,7 @@
 package billing
 
 import (
+	"fmt"
 	common "multi-chain-storage/common"
 	"multi-chain-storage/common/constants"
 	"multi-chain-storage/common/errorinfo"
@@ -19,18 +20,40 @@ import (
 func BillingManager(router *gin.RouterGroup) {
 	router.GET("", GetUserBillingHistory)
 	router.GET("/price/filecoin", GetFileCoinLastestPrice)
+	router.POST("/quote", GetQuote)
 	router.GET("/deal/lockpayment/info", GetLockPaymentInfoByPayloadCid)
 	router.POST("/deal/lockpayment", WriteLockPayment)
+	router.GET("/deal/:deal_id/audit", GetDealAudit)
+}
+
+func GetQuote(c *gin.Context) {
+	var quoteRequest QuoteRequest
+	err := c.BindJSON(&quoteRequest)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	quote, err := getQuote(quoteRequest)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusOK, common.CreateErrorResponse(errorinfo.QUOTE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(quote))
 }
 
 func WriteLockPayment(c *gin.Context) {
-	var eventLockPayment models.EventLockPayment
-	err := c.BindJSON(&eventLockPayment)
+	var lockPaymentParam LockPaymentParam
+	err := c.BindJSON(&lockPaymentParam)
 	if err != nil {
 		logs.GetLogger().Error(err)
 		c.JSON(http.StatusOK, common.CreateErrorResponse(err.Error()))
 		return
 	}
+	eventLockPayment := lockPaymentParam.EventLockPayment
 
 	lockedPayment, err := client.GetLockedPaymentInfo(eventLockPayment.PayloadCid)
 	if err != nil {
@@ -66,6 +89,25 @@ func WriteLockPayment(c *gin.Context) {
 		eventLockPayment.SourceFileId = srcFile.ID
 	}
 
+	// the lock payment is saved whatever the quote check result, rejected ones are not aggregated, lock payments without quote are counted by the lock_without_quote alert
+	eventLockPayment.QuoteSignature = ""
+	if lockPaymentParam.Quote != nil {
+		eventLockPayment.QuoteSignature = lockPaymentParam.Quote.Signature
+	}
+
+	quoteErr := checkLockPaymentQuote(lockPaymentParam.Quote, lockedPayment, srcFile)
+	switch {
+	case quoteErr != nil:
+		logs.GetLogger().Error("payload_cid:", eventLockPayment.PayloadCid, ", ", quoteErr)
+		eventLockPayment.QuoteStatus = constants.LOCK_PAYMENT_QUOTE_STATUS_REJECTED
+		eventLockPayment.QuoteNote = quoteErr.Error()
+	case lockPaymentParam.Quote == nil:
+		logs.GetLogger().Warn("payload_cid:", eventLockPayment.PayloadCid, ", lock payment written without quote")
+		eventLockPayment.QuoteStatus = constants.LOCK_PAYMENT_QUOTE_STATUS_NOT_QUOTED
+	default:
+		eventLockPayment.QuoteStatus = constants.LOCK_PAYMENT_QUOTE_STATUS_ACCEPTED
+	}
+
 	err = models.CreateEventLockPayment(eventLockPayment)
 	if err != nil {
 		logs.GetLogger().Error(err)
@@ -73,6 +115,11 @@ func WriteLockPayment(c *gin.Context) {
 		return
 	}
 
+	if quoteErr != nil {
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.LOCK_PAYMENT_QUOTE_ERROR_CODE, quoteErr.Error()))
+		return
+	}
+
 	c.JSON(http.StatusOK, common.CreateSuccessResponse(""))
 }
 
@@ -177,3 +224,49 @@ func GetFileCoinLastestPrice(c *gin.Context) {
 
 	c.JSON(http.StatusOK, common.CreateSuccessResponse(*latestPrice))
 }
+
+// GetDealAudit returns the unlock audit report of a deal, as json by default or as a pdf file by format=pdf
+func GetDealAudit(c *gin.Context) {
+	dealId, err := strconv.ParseInt(strings.Trim(c.Params.ByName("deal_id"), " "), 10, 64)
+	if err != nil || dealId <= 0 {
+		errMsg := "deal id should be a positive number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	format := strings.ToLower(strings.Trim(c.Query("format"), " "))
+	if format == "" {
+		format = constants.DEAL_AUDIT_FORMAT_JSON
+	}
+
+	if format != constants.DEAL_AUDIT_FORMAT_JSON && format != constants.DEAL_AUDIT_FORMAT_PDF {
+		errMsg := fmt.Sprintf("format should be %s or %s", constants.DEAL_AUDIT_FORMAT_JSON, constants.DEAL_AUDIT_FORMAT_PDF)
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	dealAudit, err := getDealAudit(dealId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.DEAL_AUDIT_ERROR_CODE, err.Error()))
+		return
+	}
+
+	if dealAudit == nil {
+		errMsg := fmt.Sprintf("deal:%d not found", dealId)
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusNotFound, common.CreateErrorResponse(errorinfo.DEAL_NOT_FOUND_ERROR_CODE, errMsg))
+		return
+	}
+
+	if format == constants.DEAL_AUDIT_FORMAT_PDF {
+		title := fmt.Sprintf("Unlock audit of deal %d", dealId)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=deal_%d_audit.pdf", dealId))
+		c.Data(http.StatusOK, "application/pdf", utils.CreateTextPdf(title, getDealAuditLines(dealAudit)))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(dealAudit))
+}
@@ -1,13 +1,30 @@
 package billing
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"math/big"
+	"multi-chain-storage/common/constants"
 	"multi-chain-storage/common/httpClient"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
 	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/filswan/go-swan-lib/logs"
+	"github.com/shopspring/decimal"
 )
 
 func getBillHistoryList(walletAddress, limit, offset string, txHash string, fileName string, orderByColumn int, ascdesc string) ([]*BillingResult, error) {
@@ -70,3 +87,561 @@ func GetTaskDealsService(url string) (*PriceResult, error) {
 	}
 	return price, nil
 }
+
+// getDealAudit joins what was locked, signed and unlocked for the deal, the service cost is the token amount decoded from the unlock tx receipt,
+// and the share of each source file is the locked fee the unlock tx took from it, the current price of the chainlink consumer is only given
+// for reference since it may have changed after the unlock, it returns nil when the deal is not found
+func getDealAudit(dealId int64) (*DealAudit, error) {
+	offlineDeals, err := models.GetOfflineDealByDealId(dealId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(offlineDeals) == 0 {
+		return nil, nil
+	}
+	offlineDeal := offlineDeals[0]
+
+	dealFile, err := models.GetDealFileById(offlineDeal.DealFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	srcFiles, err := models.GetSourceFilesByDealFileId(offlineDeal.DealFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	dealIdStr := strconv.FormatInt(dealId, 10)
+	filecoinNetwork := config.GetConfig().FilecoinNetwork
+	audit := &DealAudit{
+		DealId:        dealId,
+		Network:       filecoinNetwork,
+		MinerFid:      offlineDeal.MinerFid,
+		CarPayloadCid: dealFile.PayloadCid,
+		PieceCid:      dealFile.PieceCid,
+		UnlockStatus:  offlineDeal.UnlockStatus,
+		LockedFee:     decimal.Zero,
+		LockPayments:  []*DealAuditLockPayment{},
+		DaoSignatures: []*DealAuditDaoSignature{},
+		FileShares:    []*DealAuditFileShare{},
+		Notes:         []string{},
+		GeneratedAt:   utils.GetCurrentUtcMilliSecond(),
+	}
+
+	srcFileIds := map[string]int64{}
+	lockTxHashes := map[string]string{}
+	for _, srcFile := range srcFiles {
+		if _, ok := srcFileIds[srcFile.PayloadCid]; ok {
+			continue
+		}
+		srcFileIds[srcFile.PayloadCid] = srcFile.ID
+
+		lockPayments, err := models.GetEventLockPaymentByPayloadCid(srcFile.PayloadCid)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		for _, lockPayment := range lockPayments {
+			audit.LockPayments = append(audit.LockPayments, &DealAuditLockPayment{
+				SourceFileId:    srcFile.ID,
+				PayloadCid:      lockPayment.PayloadCid,
+				AddressFrom:     lockPayment.AddressFrom,
+				LockedFee:       lockPayment.LockedFee,
+				TxHash:          lockPayment.TxHash,
+				BlockNo:         lockPayment.BlockNo,
+				LockPaymentTime: lockPayment.LockPaymentTime,
+			})
+			audit.LockedFee = audit.LockedFee.Add(lockPayment.LockedFee)
+			audit.TokenAddress = lockPayment.TokenAddress
+			lockTxHashes[lockPayment.PayloadCid] = lockPayment.TxHash
+		}
+	}
+
+	daoSignatures, err := models.GetAllEventDaoSignaturesByDealId(dealId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	for _, daoSignature := range daoSignatures {
+		audit.DaoSignatures = append(audit.DaoSignatures, &DealAuditDaoSignature{
+			DaoAddress:            daoSignature.DaoAddress,
+			BlockNo:               daoSignature.BlockNo,
+			BlockTime:             daoSignature.BlockTime,
+			SignatureUnlockStatus: daoSignature.SignatureUnlockStatus,
+			TxHash:                daoSignature.TxHash,
+		})
+	}
+
+	ethClient, _, err := client.GetEthClient()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+	defer ethClient.Close()
+
+	unlockPayments, err := models.GetEventUnlockPaymentsByDealId(dealId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	tokenAmount, err := setDealAuditUnlock(audit, ethClient, unlockPayments)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if tokenAmount != nil {
+		audit.ServiceCost = tokenAmount.String()
+		audit.ServiceCostTxHash = audit.Unlock.TxHash
+	} else {
+		audit.Notes = append(audit.Notes, "no service cost before the deal is unlocked")
+	}
+
+	filinkConsumerAddress := config.GetConfig().Polygon.FilinkConsumerAddress
+	if strings.Trim(filinkConsumerAddress, " ") == "" {
+		audit.Notes = append(audit.Notes, "current price is not read, filink_consumer_address is not configured")
+	} else {
+		filinkConsumerSession, err := client.GetFilinkConsumerSession(ethClient)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		currentPrice, err := filinkConsumerSession.GetPrice(dealIdStr, filecoinNetwork)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+		audit.CurrentPrice = currentPrice.String()
+		audit.CurrentPriceAt = utils.GetCurrentUtcMilliSecond()
+		audit.CurrentPriceContract = filinkConsumerAddress
+	}
+
+	filswanOracleSession, err := client.GetFilswanOracleSession(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	cidList, err := filswanOracleSession.GetCidList(dealIdStr, filecoinNetwork)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(cidList) == 0 {
+		audit.Notes = append(audit.Notes, "no cid list of the deal on the dao contract, the cost is not split to source files")
+		return audit, nil
+	}
+
+	swanPaymentSession, err := client.GetSwanPaymentSession()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	unlockPaymentMap := map[string]*models.EventUnlockPayment{}
+	for _, unlockPayment := range unlockPayments {
+		unlockPaymentMap[unlockPayment.PayloadCid] = unlockPayment
+	}
+
+	// the same as unlockCarPayment: files whose payment no longer exists are left out of the total size
+	totalSize := big.NewInt(0)
+	for _, cid := range cidList {
+		paymentInfo, err := swanPaymentSession.GetLockedPaymentInfo(cid)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		fileShare := &DealAuditFileShare{
+			PayloadCid: cid,
+			LockTxHash: lockTxHashes[cid],
+		}
+
+		if srcFileId, ok := srcFileIds[cid]; ok {
+			fileShare.SourceFileId = &srcFileId
+		}
+
+		if paymentInfo.IsExisted {
+			fileShare.Size = paymentInfo.Size.Int64()
+			totalSize.Add(totalSize, paymentInfo.Size)
+		} else {
+			audit.Notes = append(audit.Notes, fmt.Sprintf("payment of %s no longer exists on the payment contract, its size is not counted", cid))
+		}
+
+		if unlockPayment, ok := unlockPaymentMap[cid]; ok {
+			lockedFeeBeforeUnlock := unlockPayment.LockedFeeBeforeUnlock
+			lockedFeeAfterUnlock := unlockPayment.LockedFeeAfterUnlock
+			fileShare.LockedFeeBeforeUnlock = &lockedFeeBeforeUnlock
+			fileShare.LockedFeeAfterUnlock = &lockedFeeAfterUnlock
+			fileShare.Cost = lockedFeeBeforeUnlock.Sub(lockedFeeAfterUnlock).String()
+		}
+
+		audit.FileShares = append(audit.FileShares, fileShare)
+	}
+
+	if tokenAmount == nil {
+		return audit, nil
+	}
+
+	checkDealAuditFileShares(audit, tokenAmount, totalSize)
+
+	return audit, nil
+}
+
+// checkDealAuditFileShares splits the service cost by the sizes on the payment contract as a cross-check of the shares taken by the unlock tx,
+// and notes when the shares taken do not add up to the service cost
+func checkDealAuditFileShares(audit *DealAudit, tokenAmount, totalSize *big.Int) {
+	totalCost := decimal.Zero
+	for _, fileShare := range audit.FileShares {
+		if totalSize.Sign() > 0 {
+			costBySize := new(big.Int).Div(new(big.Int).Mul(tokenAmount, big.NewInt(fileShare.Size)), totalSize)
+			fileShare.CostBySize = costBySize.String()
+		}
+
+		if fileShare.Cost == "" {
+			audit.Notes = append(audit.Notes, fmt.Sprintf("locked fee of %s before and after the unlock is not recorded, its cost is not known", fileShare.PayloadCid))
+			continue
+		}
+
+		cost, err := decimal.NewFromString(fileShare.Cost)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+		totalCost = totalCost.Add(cost)
+	}
+
+	if !totalCost.Equal(decimal.NewFromBigInt(tokenAmount, 0)) {
+		audit.Notes = append(audit.Notes, fmt.Sprintf("the costs taken from the source files add up to %s, not the service cost %s", totalCost.String(), tokenAmount.String()))
+	}
+}
+
+// setDealAuditUnlock decodes the unlock of the deal from the unlock txs recorded, by its UnlockCarPayment event,
+// or by the Transfer to mcs_payment_receiver_address when the contract emits no event, it returns the token amount of the service cost
+func setDealAuditUnlock(audit *DealAudit, ethClient *ethclient.Client, unlockPayments []*models.EventUnlockPayment) (*big.Int, error) {
+	txHashes := []string{}
+	txHashMap := map[string]bool{}
+	for _, unlockPayment := range unlockPayments {
+		if unlockPayment.TxHash == "" || txHashMap[unlockPayment.TxHash] {
+			continue
+		}
+		txHashMap[unlockPayment.TxHash] = true
+		txHashes = append(txHashes, unlockPayment.TxHash)
+	}
+
+	if len(txHashes) == 0 {
+		audit.Notes = append(audit.Notes, "no unlock tx of the deal yet")
+		return nil, nil
+	}
+
+	mcsPaymentReceiverAddress := common.HexToAddress(config.GetConfig().Polygon.McsPaymentReceiverAddress)
+	dealIdStr := strconv.FormatInt(audit.DealId, 10)
+	for _, txHash := range txHashes {
+		txReceipt, err := ethClient.TransactionReceipt(context.Background(), common.HexToHash(txHash))
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		if txReceipt.Status != uint64(1) {
+			audit.Notes = append(audit.Notes, fmt.Sprintf("unlock tx %s failed", txHash))
+			continue
+		}
+
+		// all the deals unlocked by the tx are needed to tell whether its transfers can be taken as the ones of this deal
+		dealIds, err := models.GetDealIdsByUnlockTxHash(txHash)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		unlockTransfers, err := client.GetUnlockCarPaymentTransfers(txReceipt, dealIds, mcsPaymentReceiverAddress)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		unlockTransfer, ok := unlockTransfers[dealIdStr]
+		if !ok {
+			continue
+		}
+
+		audit.Unlock = &DealAuditUnlock{
+			TxHash:            txHash,
+			BlockNo:           txReceipt.BlockNumber.Uint64(),
+			Recipient:         unlockTransfer.Recipient.Hex(),
+			TokenAmount:       unlockTransfer.TokenAmount.String(),
+			TransferredAmount: unlockTransfer.TransferredAmount.String(),
+		}
+
+		return unlockTransfer.TokenAmount, nil
+	}
+
+	audit.Notes = append(audit.Notes, "unlock of the deal is not decoded from unlock txs "+strings.Join(txHashes, ","))
+	return nil, nil
+}
+
+func getDealAuditLines(audit *DealAudit) []string {
+	lines := []string{
+		fmt.Sprintf("deal id: %d, network: %s, miner: %s", audit.DealId, audit.Network, audit.MinerFid),
+		fmt.Sprintf("car payload cid: %s", audit.CarPayloadCid),
+		fmt.Sprintf("piece cid: %s", audit.PieceCid),
+		fmt.Sprintf("unlock status: %s", audit.UnlockStatus),
+		fmt.Sprintf("token: %s, amounts are in its smallest unit", audit.TokenAddress),
+		fmt.Sprintf("generated at: %s", time.Unix(0, audit.GeneratedAt*int64(time.Millisecond)).UTC().Format(time.RFC3339)),
+		"",
+		fmt.Sprintf("locked fee: %s", audit.LockedFee.String()),
+	}
+
+	for _, lockPayment := range audit.LockPayments {
+		lines = append(lines, fmt.Sprintf("  %s locked %s by %s, block %d, tx %s", lockPayment.PayloadCid, lockPayment.LockedFee.String(), lockPayment.AddressFrom, lockPayment.BlockNo, lockPayment.TxHash))
+	}
+
+	lines = append(lines, "", fmt.Sprintf("dao signatures: %d", len(audit.DaoSignatures)))
+	for _, daoSignature := range audit.DaoSignatures {
+		lines = append(lines, fmt.Sprintf("  %s signed at block %d, time %s, status %s, tx %s", daoSignature.DaoAddress, daoSignature.BlockNo, daoSignature.BlockTime, daoSignature.SignatureUnlockStatus, daoSignature.TxHash))
+	}
+
+	lines = append(lines, "")
+	if audit.Unlock != nil {
+		lines = append(lines, fmt.Sprintf("service cost: %s, the token amount unlocked by tx %s", audit.ServiceCost, audit.ServiceCostTxHash))
+		lines = append(lines, fmt.Sprintf("unlocked: %s, %s transferred to %s, block %d, tx %s", audit.Unlock.TokenAmount, audit.Unlock.TransferredAmount, audit.Unlock.Recipient, audit.Unlock.BlockNo, audit.Unlock.TxHash))
+	} else {
+		lines = append(lines, "service cost: not unlocked yet")
+	}
+
+	if audit.CurrentPrice != "" {
+		currentPriceAt := time.Unix(0, audit.CurrentPriceAt*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+		lines = append(lines, fmt.Sprintf("current price: %s, read from %s at %s, not the price charged at unlock", audit.CurrentPrice, audit.CurrentPriceContract, currentPriceAt))
+	}
+
+	lines = append(lines, "", "cost of each source file, the locked fee taken by the unlock tx:")
+	for _, fileShare := range audit.FileShares {
+		line := fmt.Sprintf("  %s size %d, cost %s, cost by size %s, lock tx %s", fileShare.PayloadCid, fileShare.Size, fileShare.Cost, fileShare.CostBySize, fileShare.LockTxHash)
+		if fileShare.LockedFeeBeforeUnlock != nil && fileShare.LockedFeeAfterUnlock != nil {
+			line = line + fmt.Sprintf(", locked fee %s before unlock, %s after", fileShare.LockedFeeBeforeUnlock.String(), fileShare.LockedFeeAfterUnlock.String())
+		}
+		lines = append(lines, line)
+	}
+
+	if len(audit.Notes) > 0 {
+		lines = append(lines, "", "notes:")
+		for _, note := range audit.Notes {
+			lines = append(lines, "  "+note)
+		}
+	}
+
+	return lines
+}
+
+// getQuote prices the replicas of the file at the max price of the deals by utils.GetPaymentByMaxPrice, the same as the renewals are priced,
+// min payment is one replica, and lock amount is replicas * lock_amount_multiple of it
+func getQuote(quoteRequest QuoteRequest) (*Quote, error) {
+	secret := os.Getenv(constants.QUOTE_SECRET)
+	if secret == "" {
+		err := fmt.Errorf("%s is not set in .env", constants.QUOTE_SECRET)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if quoteRequest.FileSize <= 0 {
+		err := fmt.Errorf("file size should be greater than 0")
+		return nil, err
+	}
+
+	if quoteRequest.Duration == 0 {
+		quoteRequest.Duration = constants.DURATION_DAYS_DEFAULT
+	}
+
+	// deals are always priced and proposed for the default duration
+	if quoteRequest.Duration != constants.DURATION_DAYS_DEFAULT {
+		err := fmt.Errorf("duration should be %d days, the duration of the deals", constants.DURATION_DAYS_DEFAULT)
+		return nil, err
+	}
+
+	maxReplicas := config.GetConfig().SwanTask.MaxAutoBidCopyNumber
+	if quoteRequest.Replicas == 0 {
+		quoteRequest.Replicas = maxReplicas
+	}
+
+	if quoteRequest.Replicas <= 0 || quoteRequest.Replicas > maxReplicas {
+		err := fmt.Errorf("replicas should be between 1 and %d", maxReplicas)
+		return nil, err
+	}
+
+	if quoteRequest.Verified != config.GetConfig().SwanTask.VerifiedDeal {
+		err := fmt.Errorf("deals are sent with verified:%t", config.GetConfig().SwanTask.VerifiedDeal)
+		return nil, err
+	}
+
+	var coin *models.Coin
+	var err error
+	if strings.Trim(quoteRequest.TokenAddress, " ") == "" {
+		coin, err = models.FindCoinByFullName(constants.COIN_NAME_USDC)
+	} else {
+		coin, err = models.FindCoinByCoinAddress(strings.Trim(quoteRequest.TokenAddress, " "))
+	}
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+	quoteRequest.TokenAddress = coin.Address
+
+	rate, err := client.GetWfilPriceFromSushiPrice("1")
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if rate.Sign() <= 0 {
+		err := fmt.Errorf("FIL rate:%s should be greater than 0", rate.String())
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	maxPrice := config.GetConfig().SwanTask.MaxPrice
+	minPayment := utils.GetPaymentByMaxPrice(quoteRequest.FileSize, maxPrice, rate).Ceil()
+
+	// lock amount should be greater than min payment for lockTokenPayment
+	lockAmountMultiple := config.GetConfig().Quote.LockAmountMultiple
+	if lockAmountMultiple.LessThanOrEqual(decimal.NewFromInt(1)) {
+		lockAmountMultiple = decimal.NewFromFloat(constants.QUOTE_LOCK_AMOUNT_MULTIPLE_DEFAULT)
+	}
+	lockAmount := minPayment.Mul(decimal.NewFromInt(int64(quoteRequest.Replicas))).Mul(lockAmountMultiple).Ceil()
+
+	ttlMinutes := config.GetConfig().Quote.TtlMinutes
+	if ttlMinutes <= 0 {
+		ttlMinutes = constants.QUOTE_TTL_MINUTES_DEFAULT
+	}
+
+	now := time.Now().Unix()
+	lockTime := int64(quoteRequest.Duration) * 24 * 60 * 60
+	quote := &Quote{
+		QuoteRequest: quoteRequest,
+		Rate:         rate.String(),
+		MaxPrice:     maxPrice.String(),
+		LockAmount:   lockAmount.String(),
+		MinPayment:   minPayment.String(),
+		LockTime:     lockTime,
+		Deadline:     now + lockTime,
+		ExpireAt:     now + int64(ttlMinutes)*60,
+	}
+	quote.Signature = getQuoteSignature(secret, quote)
+
+	return quote, nil
+}
+
+func getQuoteSignature(secret string, quote *Quote) string {
+	fields := []string{
+		strconv.FormatInt(quote.FileSize, 10),
+		strconv.Itoa(quote.Duration),
+		strconv.Itoa(quote.Replicas),
+		strconv.FormatBool(quote.Verified),
+		strings.ToLower(quote.TokenAddress),
+		quote.Rate,
+		quote.MaxPrice,
+		quote.LockAmount,
+		quote.MinPayment,
+		strconv.FormatInt(quote.LockTime, 10),
+		strconv.FormatInt(quote.Deadline, 10),
+		strconv.FormatInt(quote.ExpireAt, 10),
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(fields, ":")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkLockPaymentQuote checks the quote is signed by this mcs and not expired, and the payment locked on chain covers it
+// and is locked while the quote is valid, a lock payment without quote is only rejected when quote is required
+func checkLockPaymentQuote(quote *Quote, lockedPayment *client.LockedPayment, srcFile *models.SourceFile) error {
+	if quote == nil {
+		if config.GetConfig().Quote.Required {
+			err := fmt.Errorf("quote is required")
+			return err
+		}
+		return nil
+	}
+
+	if lockedPayment == nil {
+		err := fmt.Errorf("locked payment not read from chain, quote can not be checked")
+		return err
+	}
+
+	secret := os.Getenv(constants.QUOTE_SECRET)
+	if secret == "" || !hmac.Equal([]byte(getQuoteSignature(secret, quote)), []byte(quote.Signature)) {
+		err := fmt.Errorf("invalid quote signature")
+		return err
+	}
+
+	if time.Now().Unix() > quote.ExpireAt {
+		err := fmt.Errorf("quote expired at:%d", quote.ExpireAt)
+		return err
+	}
+
+	if srcFile != nil && srcFile.FileSize > quote.FileSize {
+		err := fmt.Errorf("file size:%d is greater than the quote:%d", srcFile.FileSize, quote.FileSize)
+		return err
+	}
+
+	if !strings.EqualFold(lockedPayment.TokenAddress, quote.TokenAddress) {
+		err := fmt.Errorf("token:%s locked is not the quote:%s", lockedPayment.TokenAddress, quote.TokenAddress)
+		return err
+	}
+
+	if lockedPayment.CopyLimit < quote.Replicas {
+		err := fmt.Errorf("copy limit:%d locked is less than the quote replicas:%d", lockedPayment.CopyLimit, quote.Replicas)
+		return err
+	}
+
+	lockAmount, err := decimal.NewFromString(quote.LockAmount)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if lockedPayment.LockedFee.LessThan(lockAmount) {
+		err := fmt.Errorf("locked fee:%s does not cover the quote:%s", lockedPayment.LockedFee.String(), quote.LockAmount)
+		return err
+	}
+
+	minPayment, err := decimal.NewFromString(lockedPayment.MinPayment)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	quoteMinPayment, err := decimal.NewFromString(quote.MinPayment)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if minPayment.LessThan(quoteMinPayment) {
+		err := fmt.Errorf("min payment:%s locked is less than the quote:%s", lockedPayment.MinPayment, quote.MinPayment)
+		return err
+	}
+
+	// the deadline on chain is the lock time after the block locking it, which is between the quote and its expiry
+	deadline, err := strconv.ParseInt(lockedPayment.Deadline, 10, 64)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if deadline < quote.Deadline || deadline > quote.ExpireAt+quote.LockTime {
+		err := fmt.Errorf("deadline:%d locked is not between the quote deadline:%d and %d", deadline, quote.Deadline, quote.ExpireAt+quote.LockTime)
+		return err
+	}
+
+	return nil
+}
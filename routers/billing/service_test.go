@@ -0,0 +1,125 @@
+package billing
+
+import (
+	"math/big"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/config"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCheckLockPaymentQuote(t *testing.T) {
+	os.Setenv(constants.QUOTE_SECRET, "quote-secret")
+	defer os.Unsetenv(constants.QUOTE_SECRET)
+	config.SetConfig(config.Configuration{})
+
+	now := time.Now().Unix()
+	lockTime := int64(constants.DURATION_DAYS_DEFAULT * 24 * 60 * 60)
+	quote := &Quote{
+		QuoteRequest: QuoteRequest{
+			FileSize:     1024,
+			Duration:     constants.DURATION_DAYS_DEFAULT,
+			Replicas:     2,
+			TokenAddress: "0x0000000000000000000000000000000000000001",
+		},
+		LockAmount: "300",
+		MinPayment: "100",
+		LockTime:   lockTime,
+		Deadline:   now + lockTime,
+		ExpireAt:   now + 30*60,
+	}
+	quote.Signature = getQuoteSignature("quote-secret", quote)
+
+	newLockedPayment := func(deadline int64) *client.LockedPayment {
+		return &client.LockedPayment{
+			TokenAddress: quote.TokenAddress,
+			MinPayment:   "100",
+			LockedFee:    decimal.NewFromInt(300),
+			Deadline:     strconv.FormatInt(deadline, 10),
+			CopyLimit:    2,
+		}
+	}
+	srcFile := &models.SourceFile{FileSize: 1024}
+
+	tests := []struct {
+		name     string
+		deadline int64
+		valid    bool
+	}{
+		{"locked when quoted", quote.Deadline, true},
+		{"locked before quote expired", quote.ExpireAt + lockTime, true},
+		{"locked for less than the lock time", quote.Deadline - 1, false},
+		{"locked after quote expired", quote.ExpireAt + lockTime + 1, false},
+	}
+
+	for _, test := range tests {
+		err := checkLockPaymentQuote(quote, newLockedPayment(test.deadline), srcFile)
+		if test.valid && err != nil {
+			t.Errorf("%s: error %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+
+	changedQuote := *quote
+	changedQuote.Deadline = quote.Deadline - 60
+	err := checkLockPaymentQuote(&changedQuote, newLockedPayment(quote.Deadline-60), srcFile)
+	if err == nil {
+		t.Errorf("quote with a changed deadline is accepted")
+	}
+
+	err = checkLockPaymentQuote(nil, newLockedPayment(quote.Deadline), srcFile)
+	if err != nil {
+		t.Errorf("lock payment without quote refused when quote is not required: %v", err)
+	}
+
+	configuration := config.Configuration{}
+	configuration.Quote.Required = true
+	config.SetConfig(configuration)
+	err = checkLockPaymentQuote(nil, newLockedPayment(quote.Deadline), srcFile)
+	if err == nil {
+		t.Errorf("lock payment without quote accepted when quote is required")
+	}
+}
+
+func TestCheckDealAuditFileShares(t *testing.T) {
+	tests := []struct {
+		name        string
+		costs       []string
+		sizes       []int64
+		costsBySize []string
+		notes       int
+	}{
+		{"costs add up", []string{"70", "30"}, []int64{3, 1}, []string{"75", "25"}, 0},
+		{"costs do not add up", []string{"70", "20"}, []int64{3, 1}, []string{"75", "25"}, 1},
+		{"cost not recorded", []string{"100", ""}, []int64{3, 1}, []string{"75", "25"}, 1},
+		{"cost not recorded and not adding up", []string{"70", ""}, []int64{3, 1}, []string{"75", "25"}, 2},
+	}
+
+	for _, test := range tests {
+		audit := &DealAudit{}
+		totalSize := big.NewInt(0)
+		for i, cost := range test.costs {
+			audit.FileShares = append(audit.FileShares, &DealAuditFileShare{Cost: cost, Size: test.sizes[i]})
+			totalSize.Add(totalSize, big.NewInt(test.sizes[i]))
+		}
+
+		checkDealAuditFileShares(audit, big.NewInt(100), totalSize)
+
+		for i, fileShare := range audit.FileShares {
+			if fileShare.CostBySize != test.costsBySize[i] {
+				t.Errorf("%s: cost by size of file %d is %s, expected %s", test.name, i, fileShare.CostBySize, test.costsBySize[i])
+			}
+		}
+		if len(audit.Notes) != test.notes {
+			t.Errorf("%s: %d notes, expected %d: %v", test.name, len(audit.Notes), test.notes, audit.Notes)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package storage
 
+import "multi-chain-storage/car"
+
 type SourceFileAndDealFileInfo struct {
 	ID                int64  `json:"id"`
 	WalletAddress     string `json:"wallet_address"`
@@ -108,18 +110,30 @@ type LockFound struct {
 }
 
 type DealForDaoSignResult struct {
-	DealFileId            int64    `json:"deal_file_id"`
-	PayloadCid            string   `json:"payload_cid"`
-	DealCid               string   `json:"deal_cid"`
-	DealId                int64    `json:"deal_id"`
-	PieceCid              string   `json:"piece_cid"`
-	MinerFid              string   `json:"miner_fid"`
-	Duration              int      `json:"duration"`
-	Cost                  string   `json:"cost"`
-	CreateAt              string   `json:"create_at"`
-	Verified              bool     `json:"verified"`
-	ClientWalletAddress   string   `json:"client_wallet_address"`
-	SourceFilePayloadCids []string `json:"payload_cids_source"`
+	DealFileId            int64                 `json:"deal_file_id"`
+	PayloadCid            string                `json:"payload_cid"`
+	DealCid               string                `json:"deal_cid"`
+	DealId                int64                 `json:"deal_id"`
+	PieceCid              string                `json:"piece_cid"`
+	MinerFid              string                `json:"miner_fid"`
+	Duration              int                   `json:"duration"`
+	Cost                  string                `json:"cost"`
+	CreateAt              string                `json:"create_at"`
+	Verified              bool                  `json:"verified"`
+	ClientWalletAddress   string                `json:"client_wallet_address"`
+	SourceFilePayloadCids []string              `json:"payload_cids_source"`
+	InclusionProofs       []*car.InclusionProof `json:"inclusion_proofs"`
+	CarFileUrl            string                `json:"car_file_url"`
+	ChainProvider         string                `json:"chain_provider"`
+	ChainClient           string                `json:"chain_client"`
+	ChainPieceCid         string                `json:"chain_piece_cid"`
+	ChainVerifiedDeal     bool                  `json:"chain_verified_deal"`
+	StartEpoch            int64                 `json:"start_epoch"`
+	EndEpoch              int64                 `json:"end_epoch"`
+	StoragePricePerEpoch  string                `json:"storage_price_per_epoch"`
+	SectorStartEpoch      int64                 `json:"sector_start_epoch"`
+	SlashEpoch            int64                 `json:"slash_epoch"`
+	ChainVerifiedAt       int64                 `json:"chain_verified_at"`
 }
 
 type DealIdList struct {
@@ -148,3 +162,11 @@ type DaoInfoResult struct {
 	TxHash          string `json:"tx_hash"`
 	Status          string `json:"status"`
 }
+
+type SourceFileInclusionProof struct {
+	DealFileId     int64               `json:"deal_file_id"`
+	PayloadCid     string              `json:"payload_cid"`
+	PieceCid       string              `json:"piece_cid"`
+	FileIndex      int                 `json:"file_index"`
+	InclusionProof *car.InclusionProof `json:"inclusion_proof"`
+}
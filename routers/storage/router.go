@@ -10,11 +10,16 @@ import (
 	"multi-chain-storage/common/utils"
 	"multi-chain-storage/config"
 	"multi-chain-storage/database"
+	"multi-chain-storage/events"
 	"multi-chain-storage/models"
 	"multi-chain-storage/on-chain/client"
+	"multi-chain-storage/scheduler"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/filswan/go-swan-lib/logs"
 	"github.com/shopspring/decimal"
@@ -26,12 +31,33 @@ import (
 func SendDealManager(router *gin.RouterGroup) {
 	router.POST("/ipfs/upload", UploadFile)
 	router.GET("/tasks/deals", GetDealListFromLocal)
+	router.GET("/tasks/car_workers", GetCarWorkerMetrics)
 	router.GET("/deal/detail/:deal_id", GetDealListFromFilink)
 	router.GET("/deal/file/:source_file_id", GetDeals4SourceFile)
+	router.GET("/deal/file/:source_file_id/renewals", GetDealRenewals4SourceFile)
+	router.GET("/deal/file/:source_file_id/proof", GetInclusionProofs4SourceFile)
+	router.PUT("/deal/file/:source_file_id/auto_renew", UpdateAutoRenew4SourceFile)
 	router.GET("/dao/signature/deals", GetDealListForDaoToSign)
 	router.PUT("/dao/signature/deals", RecordDealListThatHaveBeenSignedByDao)
 	router.POST("/mint/info", RecordMintInfo)
+	router.GET("/miners/reputation", GetMinerReputations)
+	router.GET("/car/:piece_cid", DownloadCarFile)
+	router.HEAD("/car/:piece_cid", DownloadCarFile)
+	router.GET("/car/:piece_cid/transfers", GetCarTransfers)
+	router.POST("/retrieve/:source_file_id", QueueRetrieval4SourceFile)
+	router.GET("/retrieve/:source_file_id", DownloadRetrievedSourceFile)
+	router.GET("/retrieve/:source_file_id/status", GetRetrievalJob4SourceFile)
 	router.POST("/deal/expire", RecordExpiredRefund)
+	router.POST("/api_keys", CreateApiKey4Wallet)
+	router.GET("/api_keys", GetApiKeys4Wallet)
+	router.DELETE("/api_keys/:api_key_id", DeleteApiKey4Wallet)
+	router.POST("/webhooks", RegisterWebhook)
+	router.GET("/webhooks", GetWebhooks)
+	router.DELETE("/webhooks/:webhook_id", DeleteWebhook)
+	router.GET("/webhooks/deliveries", GetWebhookDeliveries)
+	router.POST("/webhooks/deliveries/:delivery_id/replay", ReplayWebhookDelivery)
+	router.POST("/events/stream/token", CreateEventStreamToken)
+	router.GET("/events/stream", StreamEvents)
 }
 
 type UpdateSourceFileParam struct {
@@ -71,101 +97,744 @@ func GetDeals4SourceFile(c *gin.Context) {
 		return
 	}
 
+	fileStatusHistories, err := models.GetFileStatusHistoriesBySourceFileId(sourceFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
 	c.JSON(http.StatusOK, common.CreateSuccessResponse(gin.H{
 		"source_file": sourceFile,
 		"deals":       offlineDeals,
+		"timeline":    fileStatusHistories,
 	}))
 }
 
-func RecordDealListThatHaveBeenSignedByDao(c *gin.Context) {
-	var dealIdList []DealIdList
-	err := c.BindJSON(&dealIdList)
+func GetDealRenewals4SourceFile(c *gin.Context) {
+	sourceFileIdStr := strings.Trim(c.Params.ByName("source_file_id"), " ")
+	sourceFileId, err := strconv.ParseInt(sourceFileIdStr, 10, 64)
+	if err != nil {
+		errMsg := "source file id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	dealRenewals, err := GetDealRenewalsBySourceFileId(sourceFileId)
 	if err != nil {
 		logs.GetLogger().Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARSER_RESPONSE_TO_STRUCT_ERROR_CODE))
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
 		return
 	}
 
-	daoSignRes := []daoBackendResponse{}
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(gin.H{
+		"renewals": dealRenewals,
+	}))
+}
 
-	for _, v := range dealIdList {
-		daosignCount := 0
-		deal_id, err := strconv.ParseInt(v.DealId, 10, 64)
+func GetInclusionProofs4SourceFile(c *gin.Context) {
+	sourceFileIdStr := strings.Trim(c.Params.ByName("source_file_id"), " ")
+	sourceFileId, err := strconv.ParseInt(sourceFileIdStr, 10, 64)
+	if err != nil {
+		errMsg := "source file id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	inclusionProofs, err := GetInclusionProofsBySourceFileId(sourceFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(gin.H{
+		"proofs": inclusionProofs,
+	}))
+}
+
+type autoRenewParam struct {
+	walletSignatureParam
+	AutoRenew bool `json:"auto_renew"`
+}
+
+func UpdateAutoRenew4SourceFile(c *gin.Context) {
+	sourceFileIdStr := strings.Trim(c.Params.ByName("source_file_id"), " ")
+	sourceFileId, err := strconv.ParseInt(sourceFileIdStr, 10, 64)
+	if err != nil {
+		errMsg := "source file id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	var param autoRenewParam
+	err = c.BindJSON(&param)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARSER_RESPONSE_TO_STRUCT_ERROR_CODE))
+		return
+	}
+
+	if !checkWalletSignature(c, param.walletSignatureParam) {
+		return
+	}
+
+	err = UpdateSourceFileAutoRenew(sourceFileId, param.WalletAddress, param.AutoRenew)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.UPDATE_DATA_TO_DB_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(nil))
+}
+
+type walletSignatureParam struct {
+	WalletAddress string `json:"wallet_address"`
+	Timestamp     int64  `json:"timestamp"`
+	Signature     string `json:"signature"`
+}
+
+type webhookParam struct {
+	walletSignatureParam
+	Url    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// checkWalletSignature responds with an error and returns false when the request is not signed by the wallet
+func checkWalletSignature(c *gin.Context, param walletSignatureParam) bool {
+	if strings.Trim(param.WalletAddress, " ") == "" || strings.Trim(param.Signature, " ") == "" {
+		errMsg := "wallet_address, timestamp and signature can not be null"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAMS_NULL_ERROR_CODE, errMsg))
+		return false
+	}
+
+	err := utils.VerifyWalletSignature(c.Request.Method, c.Request.URL.Path, param.WalletAddress, param.Timestamp, param.Signature)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusUnauthorized, common.CreateErrorResponse(errorinfo.WALLET_SIGNATURE_ERROR_CODE, err.Error()))
+		return false
+	}
+
+	return true
+}
+
+// webhookOwner is the wallet calling the webhook apis, with the id of the api key when it is called by an api key
+type webhookOwner struct {
+	WalletAddress string
+	ApiKeyId      *int64
+}
+
+// checkWebhookOwner authorizes the webhook apis by the api key in the X-MCS-Api-Key header, or by the wallet signature when there is no api key,
+// it responds with an error and returns nil when the request is not authorized
+func checkWebhookOwner(c *gin.Context, param walletSignatureParam) *webhookOwner {
+	key := strings.Trim(c.GetHeader(constants.API_KEY_HEADER), " ")
+	if key == "" {
+		if !checkWalletSignature(c, param) {
+			return nil
+		}
+		return &webhookOwner{WalletAddress: param.WalletAddress}
+	}
+
+	apiKey, err := GetApiKey(key)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return nil
+	}
+
+	if apiKey == nil {
+		errMsg := "api key not found or deleted"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusUnauthorized, common.CreateErrorResponse(errorinfo.API_KEY_ERROR_CODE, errMsg))
+		return nil
+	}
+
+	return &webhookOwner{WalletAddress: apiKey.WalletAddress, ApiKeyId: &apiKey.ID}
+}
+
+func getWalletSignatureParam(c *gin.Context) walletSignatureParam {
+	URL := c.Request.URL.Query()
+	timestamp, _ := strconv.ParseInt(strings.Trim(URL.Get("timestamp"), " "), 10, 64)
+	return walletSignatureParam{
+		WalletAddress: strings.Trim(URL.Get("wallet_address"), " "),
+		Timestamp:     timestamp,
+		Signature:     strings.Trim(URL.Get("signature"), " "),
+	}
+}
+
+// CreateApiKey4Wallet creates an api key for the webhook apis, api keys are only managed by the wallet signature
+func CreateApiKey4Wallet(c *gin.Context) {
+	var param walletSignatureParam
+	err := c.BindJSON(&param)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARSER_RESPONSE_TO_STRUCT_ERROR_CODE))
+		return
+	}
+
+	if !checkWalletSignature(c, param) {
+		return
+	}
+
+	apiKey, key, err := CreateApiKey(param.WalletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusInternalServerError, common.CreateErrorResponse(errorinfo.SAVE_DATA_TO_DB_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(gin.H{"api_key": apiKey, "key": key}))
+}
+
+func GetApiKeys4Wallet(c *gin.Context) {
+	param := getWalletSignatureParam(c)
+	if !checkWalletSignature(c, param) {
+		return
+	}
+
+	apiKeys, err := models.GetApiKeysByWalletAddress(param.WalletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(apiKeys))
+}
+
+// DeleteApiKey4Wallet deletes the api key and the webhooks registered with it
+func DeleteApiKey4Wallet(c *gin.Context) {
+	apiKeyId, err := strconv.ParseInt(strings.Trim(c.Params.ByName("api_key_id"), " "), 10, 64)
+	if err != nil {
+		errMsg := "api key id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	param := getWalletSignatureParam(c)
+	if !checkWalletSignature(c, param) {
+		return
+	}
+
+	err = RemoveApiKey(apiKeyId, param.WalletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.API_KEY_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(nil))
+}
+
+func RegisterWebhook(c *gin.Context) {
+	var param webhookParam
+	err := c.BindJSON(&param)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARSER_RESPONSE_TO_STRUCT_ERROR_CODE))
+		return
+	}
+
+	owner := checkWebhookOwner(c, param.walletSignatureParam)
+	if owner == nil {
+		return
+	}
+
+	webhook, err := SaveWebhook(owner.WalletAddress, owner.ApiKeyId, strings.Trim(param.Url, " "), param.Events)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.WEBHOOK_URL_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(webhook))
+}
+
+func GetWebhooks(c *gin.Context) {
+	owner := checkWebhookOwner(c, getWalletSignatureParam(c))
+	if owner == nil {
+		return
+	}
+
+	webhooks, err := models.GetWebhooksByWalletAddress(owner.WalletAddress, owner.ApiKeyId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	// the secret is only returned when the webhook is registered
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(webhooks))
+}
+
+func DeleteWebhook(c *gin.Context) {
+	webhookId, err := strconv.ParseInt(strings.Trim(c.Params.ByName("webhook_id"), " "), 10, 64)
+	if err != nil {
+		errMsg := "webhook id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	owner := checkWebhookOwner(c, getWalletSignatureParam(c))
+	if owner == nil {
+		return
+	}
+
+	err = RemoveWebhook(webhookId, owner.WalletAddress, owner.ApiKeyId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.WEBHOOK_NOT_FOUND_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(nil))
+}
+
+func GetWebhookDeliveries(c *gin.Context) {
+	owner := checkWebhookOwner(c, getWalletSignatureParam(c))
+	if owner == nil {
+		return
+	}
+
+	URL := c.Request.URL.Query()
+	pageNumber := strings.Trim(URL.Get("page_number"), " ")
+	if pageNumber == "" || pageNumber == "0" {
+		pageNumber = "1"
+	}
+
+	pageSize := strings.Trim(URL.Get("page_size"), " ")
+	if pageSize == "" {
+		pageSize = constants.PAGE_SIZE_DEFAULT_VALUE
+	}
+
+	offset, err := utils.GetOffsetByPagenumber(pageNumber, pageSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.PAGE_NUMBER_OR_SIZE_FORMAT_ERROR_CODE))
+		return
+	}
+
+	limit, _ := strconv.Atoi(pageSize)
+	webhookDeliveries, err := models.GetWebhookDeliveriesByWalletAddress(owner.WalletAddress, owner.ApiKeyId, limit, int(offset))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(webhookDeliveries))
+}
+
+func ReplayWebhookDelivery(c *gin.Context) {
+	deliveryId, err := strconv.ParseInt(strings.Trim(c.Params.ByName("delivery_id"), " "), 10, 64)
+	if err != nil {
+		errMsg := "delivery id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	// the body is only needed for the wallet signature, requests with an api key may post no body
+	var param walletSignatureParam
+	if strings.Trim(c.GetHeader(constants.API_KEY_HEADER), " ") == "" {
+		err = c.BindJSON(&param)
 		if err != nil {
 			logs.GetLogger().Error(err)
+			c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARSER_RESPONSE_TO_STRUCT_ERROR_CODE))
+			return
 		}
+	}
 
-		if v.TxHash1 != "" {
-			verification1, err := VerifyDaoSigOnContract(v.TxHash1)
+	owner := checkWebhookOwner(c, param)
+	if owner == nil {
+		return
+	}
 
-			if err != nil {
-				logs.GetLogger().Error(err)
-			} else {
-				err = SaveDaoEventFromTxHash(v.TxHash1, v.PayloadCid, v.Recipent, deal_id, verification1)
-				if err != nil {
-					logs.GetLogger().Error(err)
-				}
-				if verification1 {
-					daosignCount++
-				}
-			}
+	webhookDelivery, err := ReplayWebhookDeliveryByWallet(deliveryId, owner.WalletAddress, owner.ApiKeyId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.WEBHOOK_NOT_FOUND_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(webhookDelivery))
+}
+
+// CreateEventStreamToken exchanges the wallet signature in the body for a token to open the event stream of the wallet,
+// so that the signature is not put in the query string of the stream
+func CreateEventStreamToken(c *gin.Context) {
+	var param walletSignatureParam
+	err := c.BindJSON(&param)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARSER_RESPONSE_TO_STRUCT_ERROR_CODE))
+		return
+	}
+
+	if !checkWalletSignature(c, param) {
+		return
+	}
+
+	token, expireAt := utils.GetEventStreamToken(param.WalletAddress)
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(gin.H{"token": token, "expire_at": expireAt}))
+}
+
+// StreamEvents pushes the status changes of the files of the wallet as server-sent events,
+// a client reconnecting with Last-Event-ID, or last_event_id for the first connection, receives the events it missed first
+// StreamEvents is opened by a short-lived token in the query string, since EventSource cannot set headers,
+// the token is created by CreateEventStreamToken, and clients create a new one each time they reconnect
+func StreamEvents(c *gin.Context) {
+	URL := c.Request.URL.Query()
+	walletAddress, err := utils.VerifyEventStreamToken(strings.Trim(URL.Get("token"), " "))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusUnauthorized, common.CreateErrorResponse(errorinfo.WALLET_SIGNATURE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	lastEventIdStr := strings.Trim(c.GetHeader("Last-Event-ID"), " ")
+	if lastEventIdStr == "" {
+		lastEventIdStr = strings.Trim(URL.Get("last_event_id"), " ")
+	}
+
+	lastEventId := int64(0)
+	if lastEventIdStr != "" {
+		lastEventId, err = strconv.ParseInt(lastEventIdStr, 10, 64)
+		if err != nil {
+			errMsg := "last event id should be a valid number"
+			logs.GetLogger().Error(errMsg)
+			c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+			return
 		}
+	}
 
-		if v.TxHash2 != "" {
-			verification2, err := VerifyDaoSigOnContract(v.TxHash2)
-			if err != nil {
-				logs.GetLogger().Error(err)
-			} else {
-				err = SaveDaoEventFromTxHash(v.TxHash2, v.PayloadCid, v.Recipent, deal_id, verification2)
-				if err != nil {
-					logs.GetLogger().Error(err)
-				}
-				if verification2 {
-					daosignCount++
-				}
+	// subscribe before replaying, so that no event is lost between the replay and the live events
+	fileStatusEvents, unsubscribe := events.Subscribe(walletAddress)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for {
+		missedEvents, err := models.GetFileStatusEventsByWalletAddress(walletAddress, lastEventId, constants.EVENT_STREAM_REPLAY_LIMIT)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return
+		}
+
+		for _, missedEvent := range missedEvents {
+			if !writeEvent(c, missedEvent) {
+				return
 			}
+			lastEventId = missedEvent.ID
 		}
 
-		if v.TxHash3 != "" {
-			verification3, err := VerifyDaoSigOnContract(v.TxHash3)
+		if len(missedEvents) < constants.EVENT_STREAM_REPLAY_LIMIT {
+			break
+		}
+	}
+
+	ticker := time.NewTicker(constants.EVENT_STREAM_PING_SECONDS * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case fileStatusEvent, ok := <-fileStatusEvents:
+			// the subscriber fell behind, the client resumes from the last event it received when it reconnects
+			if !ok {
+				return
+			}
+
+			if fileStatusEvent.ID <= lastEventId {
+				continue
+			}
+
+			if !writeEvent(c, fileStatusEvent) {
+				return
+			}
+			lastEventId = fileStatusEvent.ID
+		case <-ticker.C:
+			_, err := fmt.Fprint(c.Writer, ": ping\n\n")
 			if err != nil {
 				logs.GetLogger().Error(err)
-			} else {
-				err = SaveDaoEventFromTxHash(v.TxHash3, v.PayloadCid, v.Recipent, deal_id, verification3)
-				if err != nil {
-					logs.GetLogger().Error(err)
-				}
-				if verification3 {
-					daosignCount++
-				}
+				return
 			}
+			c.Writer.Flush()
 		}
+	}
+}
+
+func writeEvent(c *gin.Context, fileStatusEvent *events.FileStatusEvent) bool {
+	data, err := json.Marshal(fileStatusEvent)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false
+	}
+
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", fileStatusEvent.ID, constants.EVENT_STREAM_NAME, data)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false
+	}
+
+	c.Writer.Flush()
+	return true
+}
+
+func GetMinerReputations(c *gin.Context) {
+	minerReputations, err := models.GetMinerReputations()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(minerReputations))
+}
+
+func GetCarWorkerMetrics(c *gin.Context) {
+	carGroupStatusCounts, err := models.GetCarGroupStatusCounts()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
 
-		events, err := models.GetEventDaoSignaturesByDealId(deal_id)
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(gin.H{
+		"car_workers": scheduler.GetCarWorkerMetrics(),
+		"car_groups":  carGroupStatusCounts,
+	}))
+}
 
+func DownloadCarFile(c *gin.Context) {
+	pieceCid := strings.Trim(c.Params.ByName("piece_cid"), " ")
+	URL := c.Request.URL.Query()
+	minerFid := URL.Get("miner_fid")
+	signature := URL.Get("signature")
+	expireAt, err := strconv.ParseInt(URL.Get("expire_at"), 10, 64)
+	if err != nil {
+		errMsg := "expire_at should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	err = utils.VerifyCarUrlSignature(pieceCid, minerFid, expireAt, signature)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusForbidden, common.CreateErrorResponse(errorinfo.CAR_URL_SIGNATURE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	dealFile, err := GetDealFile4CarDownload(pieceCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusNotFound, common.CreateErrorResponse(errorinfo.CAR_FILE_NOT_FOUND_ERROR_CODE, err.Error()))
+		return
+	}
+
+	carFile, err := os.Open(dealFile.CarFilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusNotFound, common.CreateErrorResponse(errorinfo.CAR_FILE_NOT_FOUND_ERROR_CODE, err.Error()))
+		return
+	}
+	defer carFile.Close()
+
+	carFileInfo, err := carFile.Stat()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusNotFound, common.CreateErrorResponse(errorinfo.CAR_FILE_NOT_FOUND_ERROR_CODE, err.Error()))
+		return
+	}
+
+	// http.ServeContent handles range requests, so providers can resume interrupted transfers
+	http.ServeContent(c.Writer, c.Request, dealFile.CarFileName, carFileInfo.ModTime(), carFile)
+
+	bytesTransferred := c.Writer.Size()
+	if c.Request.Method == http.MethodGet && bytesTransferred > 0 {
+		err = models.SaveCarTransferProgress(dealFile, minerFid, int64(bytesTransferred))
 		if err != nil {
 			logs.GetLogger().Error(err)
-		} else {
-			if daosignCount >= 2 || len(events) >= 2 {
-				daoFetchedDeal := new(models.DaoFetchedDeal)
-				dealIdIntValue, err := strconv.ParseInt(v.DealId, 10, 64)
-				if err != nil {
-					logs.GetLogger().Error(err)
-					continue
-				}
-				daoFetchedDeal.DealId = dealIdIntValue
-				daoFetchedDeal.CreateAt = utils.GetCurrentUtcMilliSecond()
-				err = database.SaveOne(daoFetchedDeal)
-				if err != nil {
-					logs.GetLogger().Error(err)
-					continue
-				}
-				var response daoBackendResponse
-				response.DealId = v.DealId
-				response.PayloadCid = v.PayloadCid
-				response.SuccessDaoCount = len(events)
-				daoSignRes = append(daoSignRes, response)
-			}
+		}
+	}
+}
+
+// QueueRetrieval4SourceFile queues the retrieval of the source file for its owner, the retrieve file scheduler runs it
+func QueueRetrieval4SourceFile(c *gin.Context) {
+	sourceFileId, err := strconv.ParseInt(strings.Trim(c.Params.ByName("source_file_id"), " "), 10, 64)
+	if err != nil {
+		errMsg := "source file id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	var param walletSignatureParam
+	err = c.BindJSON(&param)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARSER_RESPONSE_TO_STRUCT_ERROR_CODE))
+		return
+	}
+
+	if !checkWalletSignature(c, param) {
+		return
+	}
+
+	retrievalJob, err := QueueRetrieval(sourceFileId, param.WalletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.RETRIEVE_FILE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(retrievalJob))
+}
+
+func GetRetrievalJob4SourceFile(c *gin.Context) {
+	sourceFileId, err := strconv.ParseInt(strings.Trim(c.Params.ByName("source_file_id"), " "), 10, 64)
+	if err != nil {
+		errMsg := "source file id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	param := getWalletSignatureParam(c)
+	if !checkWalletSignature(c, param) {
+		return
+	}
+
+	retrievalJob, err := GetRetrievalJob(sourceFileId, param.WalletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.RETRIEVE_FILE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(retrievalJob))
+}
+
+// DownloadRetrievedSourceFile queues the retrieval of the source file when it is not retrieved yet,
+// and streams the file once the retrieval succeeds, the request is kept open while the retrieval is running
+func DownloadRetrievedSourceFile(c *gin.Context) {
+	sourceFileId, err := strconv.ParseInt(strings.Trim(c.Params.ByName("source_file_id"), " "), 10, 64)
+	if err != nil {
+		errMsg := "source file id should be a valid number"
+		logs.GetLogger().Error(errMsg)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARAM_TYPE_ERROR_CODE, errMsg))
+		return
+	}
+
+	param := getWalletSignatureParam(c)
+	if !checkWalletSignature(c, param) {
+		return
+	}
+
+	_, err = QueueRetrieval(sourceFileId, param.WalletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.RETRIEVE_FILE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	retrievalJob, err := WaitRetrievalJob(c.Request.Context(), sourceFileId, param.WalletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.RETRIEVE_FILE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	file, err := os.Open(retrievalJob.FilePath)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		errMsg := fmt.Sprintf("retrieved source file:%d has been cleaned, please queue its retrieval again", sourceFileId)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.RETRIEVE_FILE_ERROR_CODE, errMsg))
+		return
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.RETRIEVE_FILE_ERROR_CODE, err.Error()))
+		return
+	}
+
+	extraHeaders := map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(retrievalJob.FilePath)),
+	}
+	c.DataFromReader(http.StatusOK, fileInfo.Size(), "application/octet-stream", file, extraHeaders)
+}
+
+func GetCarTransfers(c *gin.Context) {
+	pieceCid := strings.Trim(c.Params.ByName("piece_cid"), " ")
+	carTransfers, err := models.GetCarTransfersByPieceCid(pieceCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.JSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.GET_RECORD_lIST_ERROR_CODE, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.CreateSuccessResponse(carTransfers))
+}
+
+// RecordDealListThatHaveBeenSignedByDao is only a hint to index the dao signatures of the deals at once,
+// the signatures are read from the dao contract, and neither the tx hashes nor the payload cids in the request are trusted,
+// the payload cid of a deal is the one of its deal file
+func RecordDealListThatHaveBeenSignedByDao(c *gin.Context) {
+	var dealIdList []DealIdList
+	err := c.BindJSON(&dealIdList)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, common.CreateErrorResponse(errorinfo.HTTP_REQUEST_PARSER_RESPONSE_TO_STRUCT_ERROR_CODE))
+		return
+	}
+
+	daoSignRes := []daoBackendResponse{}
+
+	for _, v := range dealIdList {
+		dealId, err := strconv.ParseInt(v.DealId, 10, 64)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		dealFile, err := models.GetDealFileByDealId(dealId)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		ready, validCount, err := scheduler.IndexDaoSignaturesOfDeal(dealId, dealFile.PayloadCid)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		if ready {
+			var response daoBackendResponse
+			response.DealId = v.DealId
+			response.PayloadCid = dealFile.PayloadCid
+			response.SuccessDaoCount = validCount
+			daoSignRes = append(daoSignRes, response)
 		}
 	}
 
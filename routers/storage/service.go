@@ -2,13 +2,19 @@ package storage
 
 import (
 	"context"
-	"errors"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/big"
 	"mime/multipart"
+	"multi-chain-storage/car"
 	"multi-chain-storage/common/constants"
 	"multi-chain-storage/common/utils"
 	"multi-chain-storage/config"
 	"multi-chain-storage/database"
+	"multi-chain-storage/disk"
 	"multi-chain-storage/models"
 	"multi-chain-storage/on-chain/client"
 	"multi-chain-storage/scheduler"
@@ -93,6 +99,395 @@ func GetOfflineDealsBySourceFileId(sourceFileId int64) ([]*models.OfflineDeal, *
 	return offlineDeals, sourceFile, nil
 }
 
+func GetInclusionProofsBySourceFileId(sourceFileId int64) ([]*SourceFileInclusionProof, error) {
+	filepMaps, err := models.GetSourceFileDealFileMapsBySourceFileId(sourceFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	inclusionProofs := []*SourceFileInclusionProof{}
+	for _, filepMap := range filepMaps {
+		if filepMap.InclusionProof == nil {
+			continue
+		}
+
+		inclusionProof := &SourceFileInclusionProof{
+			DealFileId: filepMap.DealFileId,
+			PayloadCid: filepMap.PayloadCid,
+			PieceCid:   filepMap.PieceCid,
+			FileIndex:  filepMap.FileIndex,
+		}
+
+		err = json.Unmarshal([]byte(*filepMap.InclusionProof), &inclusionProof.InclusionProof)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		inclusionProofs = append(inclusionProofs, inclusionProof)
+	}
+
+	if len(inclusionProofs) == 0 {
+		err := fmt.Errorf("no inclusion proof found for source file:%d", sourceFileId)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return inclusionProofs, nil
+}
+
+func GetDealRenewalsBySourceFileId(sourceFileId int64) ([]*models.DealRenewalExt, error) {
+	dealRenewals, err := models.GetDealRenewalsBySourceFileId(sourceFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	renewedDealFileIds := []int64{}
+	for _, dealRenewal := range dealRenewals {
+		dealRenewal.RenewedDeals = []*models.OfflineDeal{}
+		if dealRenewal.RenewedDealFileId != nil {
+			renewedDealFileIds = append(renewedDealFileIds, *dealRenewal.RenewedDealFileId)
+		}
+	}
+
+	if len(renewedDealFileIds) == 0 {
+		return dealRenewals, nil
+	}
+
+	offlineDeals, err := models.GetOfflineDealsByDealFileIds(renewedDealFileIds)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	for _, dealRenewal := range dealRenewals {
+		if dealRenewal.RenewedDealFileId == nil {
+			continue
+		}
+
+		for _, offlineDeal := range offlineDeals {
+			if offlineDeal.DealFileId == *dealRenewal.RenewedDealFileId {
+				dealRenewal.RenewedDeals = append(dealRenewal.RenewedDeals, offlineDeal)
+			}
+		}
+	}
+
+	return dealRenewals, nil
+}
+
+func checkSourceFileOwner(sourceFileId int64, walletAddress string) error {
+	sourceFileUploadHistories, err := models.GetSourceFileUploadHistoryBySourceFileIdWallet(sourceFileId, walletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if len(sourceFileUploadHistories) == 0 {
+		err := fmt.Errorf("source file:%d not uploaded by wallet:%s", sourceFileId, walletAddress)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func UpdateSourceFileAutoRenew(sourceFileId int64, walletAddress string, autoRenew bool) error {
+	err := checkSourceFileOwner(sourceFileId, walletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = models.UpdateSourceFileAutoRenew(sourceFileId, autoRenew)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// QueueRetrieval queues a retrieval of the source file, unless one is queued, running,
+// or has succeeded and its file is still kept
+func QueueRetrieval(sourceFileId int64, walletAddress string) (*models.RetrievalJob, error) {
+	err := checkSourceFileOwner(sourceFileId, walletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	retrievalJob, err := models.GetLatestRetrievalJobBySourceFileId(sourceFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if retrievalJob != nil {
+		switch retrievalJob.Status {
+		case constants.RETRIEVAL_STATUS_QUEUED, constants.RETRIEVAL_STATUS_RETRIEVING:
+			return retrievalJob, nil
+		case constants.RETRIEVAL_STATUS_SUCCEEDED:
+			if libutils.IsFileExistsFullPath(retrievalJob.FilePath) {
+				return retrievalJob, nil
+			}
+		}
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	retrievalJob = &models.RetrievalJob{
+		SourceFileId:  sourceFileId,
+		WalletAddress: walletAddress,
+		Status:        constants.RETRIEVAL_STATUS_QUEUED,
+		CreateAt:      currentUtcMilliSec,
+		UpdateAt:      currentUtcMilliSec,
+	}
+
+	err = database.SaveOne(retrievalJob)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return retrievalJob, nil
+}
+
+func GetRetrievalJob(sourceFileId int64, walletAddress string) (*models.RetrievalJob, error) {
+	err := checkSourceFileOwner(sourceFileId, walletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	retrievalJob, err := models.GetLatestRetrievalJobBySourceFileId(sourceFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if retrievalJob == nil {
+		err := fmt.Errorf("retrieval of source file:%d not queued", sourceFileId)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return retrievalJob, nil
+}
+
+// WaitRetrievalJob waits for the latest retrieval of the source file to succeed or fail, until ctx is done
+func WaitRetrievalJob(ctx context.Context, sourceFileId int64, walletAddress string) (*models.RetrievalJob, error) {
+	for {
+		retrievalJob, err := GetRetrievalJob(sourceFileId, walletAddress)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		switch retrievalJob.Status {
+		case constants.RETRIEVAL_STATUS_SUCCEEDED:
+			return retrievalJob, nil
+		case constants.RETRIEVAL_STATUS_FAILED:
+			err := fmt.Errorf("retrieval of source file:%d failed, %s", sourceFileId, retrievalJob.Note)
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(constants.RETRIEVAL_POLL_SECONDS * time.Second):
+		}
+	}
+}
+
+// CreateApiKey creates an api key for the wallet, the key is only returned here, and only its sha256 is saved
+func CreateApiKey(walletAddress string) (*models.ApiKey, string, error) {
+	keyBytes := make([]byte, 32)
+	_, err := rand.Read(keyBytes)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, "", err
+	}
+
+	key := "mcs_" + hex.EncodeToString(keyBytes)
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	apiKey := models.ApiKey{
+		WalletAddress: strings.ToLower(walletAddress),
+		KeyHash:       getApiKeyHash(key),
+		KeyPrefix:     key[:12],
+		Status:        constants.API_KEY_STATUS_ACTIVE,
+		CreateAt:      currentUtcMilliSec,
+		UpdateAt:      currentUtcMilliSec,
+	}
+
+	err = database.SaveOne(&apiKey)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, "", err
+	}
+
+	return &apiKey, key, nil
+}
+
+// GetApiKey returns the active api key, nil when the key is not found or deleted
+func GetApiKey(key string) (*models.ApiKey, error) {
+	apiKey, err := models.GetApiKeyByKeyHash(getApiKeyHash(key))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func getApiKeyHash(key string) string {
+	keyHash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(keyHash[:])
+}
+
+func RemoveApiKey(apiKeyId int64, walletAddress string) error {
+	deleted, err := models.DeleteApiKey(apiKeyId, walletAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if !deleted {
+		err := fmt.Errorf("api key:%d not found for wallet:%s", apiKeyId, walletAddress)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// SaveWebhook registers the webhook for the wallet, and for the api key when apiKeyId is given
+func SaveWebhook(walletAddress string, apiKeyId *int64, webhookUrl string, events []string) (*models.Webhook, error) {
+	err := utils.CheckWebhookUrl(webhookUrl)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	for _, event := range events {
+		if !models.IsWebhookEventValid(event) {
+			err := fmt.Errorf("webhook event:%s not supported", event)
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+	}
+
+	secretBytes := make([]byte, 32)
+	_, err = rand.Read(secretBytes)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	webhook := models.Webhook{
+		WalletAddress: strings.ToLower(walletAddress),
+		ApiKeyId:      apiKeyId,
+		Url:           webhookUrl,
+		Secret:        hex.EncodeToString(secretBytes),
+		Events:        strings.Join(events, ","),
+		Status:        constants.WEBHOOK_STATUS_ACTIVE,
+		CreateAt:      currentUtcMilliSec,
+		UpdateAt:      currentUtcMilliSec,
+	}
+
+	err = database.SaveOne(&webhook)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// isWebhookOwned returns whether the webhook belongs to the wallet, and was registered with the api key when apiKeyId is given
+func isWebhookOwned(walletAddress string, apiKeyId *int64, webhookWalletAddress string, webhookApiKeyId *int64) bool {
+	if !strings.EqualFold(webhookWalletAddress, walletAddress) {
+		return false
+	}
+
+	return apiKeyId == nil || (webhookApiKeyId != nil && *webhookApiKeyId == *apiKeyId)
+}
+
+func RemoveWebhook(webhookId int64, walletAddress string, apiKeyId *int64) error {
+	webhook, err := models.GetWebhookById(webhookId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if webhook == nil || !isWebhookOwned(walletAddress, apiKeyId, webhook.WalletAddress, webhook.ApiKeyId) {
+		err := fmt.Errorf("webhook:%d not found for wallet:%s", webhookId, walletAddress)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = models.DeleteWebhook(webhookId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func ReplayWebhookDeliveryByWallet(deliveryId int64, walletAddress string, apiKeyId *int64) (*models.WebhookDelivery, error) {
+	webhookDelivery, err := models.GetWebhookDeliveryById(deliveryId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if webhookDelivery == nil || !isWebhookOwned(walletAddress, apiKeyId, webhookDelivery.WalletAddress, webhookDelivery.ApiKeyId) {
+		err := fmt.Errorf("webhook delivery:%d not found for wallet:%s", deliveryId, walletAddress)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	webhook, err := models.GetWebhookById(webhookDelivery.WebhookId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if webhook == nil {
+		err := fmt.Errorf("webhook:%d of delivery:%d has been deleted", webhookDelivery.WebhookId, deliveryId)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	replay, err := models.ReplayWebhookDelivery(&webhookDelivery.WebhookDelivery)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+func GetDealFile4CarDownload(pieceCid string) (*models.DealFile, error) {
+	dealFiles, err := models.GetDealFilesByPieceCid(pieceCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	for _, dealFile := range dealFiles {
+		if libutils.IsFileExistsFullPath(dealFile.CarFilePath) {
+			return dealFile, nil
+		}
+	}
+
+	err = fmt.Errorf("car file with piece_cid:%s not exists", pieceCid)
+	logs.GetLogger().Error(err)
+	return nil, err
+}
+
 func SaveFile(c *gin.Context, srcFile *multipart.FileHeader, duration, fileType int, walletAddress string) (*int64, *string, *string, *int, *int64, error) {
 	srcDir := scheduler.GetSrcDir()
 
@@ -106,9 +501,15 @@ func SaveFile(c *gin.Context, srcFile *multipart.FileHeader, duration, fileType
 		}
 	}
 
+	err := disk.CheckFreeSize(srcDir, srcFile.Size)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, nil, nil, nil, nil, err
+	}
+
 	srcFilepath := filepath.Join(srcDir, filename)
 	logs.GetLogger().Info("saving source file to ", srcFilepath)
-	err := c.SaveUploadedFile(srcFile, srcFilepath)
+	err = c.SaveUploadedFile(srcFile, srcFilepath)
 	if err != nil {
 		logs.GetLogger().Error(err)
 		return nil, nil, nil, nil, nil, err
@@ -179,6 +580,16 @@ func SaveFile(c *gin.Context, srcFile *multipart.FileHeader, duration, fileType
 			return nil, nil, nil, nil, nil, err
 		}
 
+		err = disk.TrackFile(constants.LOCAL_FILE_TYPE_SOURCE, srcFilepath, &sourceFileCreated.ID, nil)
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+
+		err = models.TransitFileStatus(sourceFileCreated.ID, constants.FILE_STATUS_UPLOADED, "uploaded by wallet:"+walletAddress)
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+
 		return &sourceFileCreated.ID, ipfsFileHash, &ipfsUrl, &needPay, &sourceFileCreated.FileSize, nil
 	}
 
@@ -286,8 +697,12 @@ func GetLockFoundInfoByPayloadCid(payloadCid string) (*LockFound, error) {
 	return lockFound, nil
 }
 
+// only the deals verified on chain by the verify dao deal scheduler are returned, along with the facts read from chain
 func GetShoulBeSignDealListFromDB() ([]*DealForDaoSignResult, error) {
-	finalSql := "select a.id as deal_file_id, b.deal_id,a.deal_cid,a.piece_cid,a.payload_cid,a.cost,a.verified,a.miner_fid,duration,a.client_wallet_address,a.create_at from deal_file a left join offline_deal b on a.id = b.deal_file_id left join event_lock_payment c on a.payload_cid=c.payload_cid " +
+	finalSql := "select a.id as deal_file_id, b.deal_id,a.deal_cid,a.piece_cid,a.payload_cid,a.cost,a.verified,a.miner_fid,duration,a.client_wallet_address,a.create_at, " +
+		" d.provider as chain_provider,d.client as chain_client,d.piece_cid as chain_piece_cid,d.verified_deal as chain_verified_deal,d.start_epoch,d.end_epoch,d.storage_price_per_epoch,d.sector_start_epoch,d.slash_epoch,d.update_at as chain_verified_at " +
+		" from deal_file a left join offline_deal b on a.id = b.deal_file_id left join event_lock_payment c on a.payload_cid=c.payload_cid " +
+		" inner join dao_deal_verification d on b.deal_id=d.deal_id and d.status='" + constants.DAO_DEAL_VERIFICATION_STATUS_VERIFIED + "' " +
 		" where b.deal_id not in  ( " +
 		" select  deal_id from dao_fetched_deal ) " +
 		" and b.deal_id > 0 and IFNULL(c.deadline,0) < " + strconv.FormatInt(time.Now().Unix(), 10) +
@@ -299,24 +714,43 @@ func GetShoulBeSignDealListFromDB() ([]*DealForDaoSignResult, error) {
 		return nil, err
 
 	}
-	sourceSQL := "select a.id, a.payload_cid, b.deal_file_id from source_file a, source_file_deal_file_map b where a.id = b.source_file_id"
-	var sourceFileExt []*models.SourceFileExt
-	err = database.GetDB().Raw(sourceSQL).Scan(&sourceFileExt).Limit(0).Offset(constants.DEFAULT_SELECT_LIMIT).Error
+	sourceSQL := "select b.*, a.payload_cid from source_file a, source_file_deal_file_map b where a.id = b.source_file_id"
+	var filepMaps []*models.SourceFileDealFileMapExt
+	err = database.GetDB().Raw(sourceSQL).Scan(&filepMaps).Limit(0).Offset(constants.DEFAULT_SELECT_LIMIT).Error
 	if err != nil {
 		logs.GetLogger().Error(err)
 		return nil, err
 
 	}
+	// dao signers check each source file is in the piece of the deal with its inclusion proof and the car bytes before signing
 	for _, deal := range dealForDaoSignResultList {
 		var cids []string
-		for _, file := range sourceFileExt {
-			if deal.DealFileId == file.DealFileId && file.PayloadCid != "" {
-				cids = append(cids, file.PayloadCid)
+		inclusionProofs := []*car.InclusionProof{}
+		for _, filepMap := range filepMaps {
+			if deal.DealFileId != filepMap.DealFileId || filepMap.PayloadCid == "" {
+				continue
+			}
+
+			cids = append(cids, filepMap.PayloadCid)
+			if filepMap.InclusionProof == nil {
+				continue
+			}
+
+			var inclusionProof *car.InclusionProof
+			err = json.Unmarshal([]byte(*filepMap.InclusionProof), &inclusionProof)
+			if err != nil {
+				logs.GetLogger().Error(err)
+				return nil, err
 			}
+			inclusionProofs = append(inclusionProofs, inclusionProof)
 		}
 		if len(cids) > 0 {
 			deal.SourceFilePayloadCids = cids
 		}
+		deal.InclusionProofs = inclusionProofs
+		if utils.IsCarServerEnabled() {
+			deal.CarFileUrl = utils.GetCarUrl(deal.PieceCid, deal.MinerFid)
+		}
 	}
 	return dealForDaoSignResultList, nil
 }
@@ -339,91 +773,6 @@ func GetDaoSignEventByDealId(dealId int64) ([]*DaoInfoResult, error) {
 	return daoInfoResult, nil
 }
 
-func SaveDaoEventFromTxHash(txHash string, payload_cid string, recipent string, deal_id int64, verification bool) error {
-	ethClient, rpcClient, err := client.GetEthClient()
-	if err != nil {
-		logs.GetLogger().Error(err)
-		return err
-	}
-
-	if txHash != "" && strings.HasPrefix(txHash, "0x") {
-		var rpcTransaction *models.RpcTransaction
-		err = rpcClient.CallContext(context.Background(), &rpcTransaction, "eth_getTransactionByHash", common.HexToHash(txHash))
-		if err != nil {
-			logs.GetLogger().Error(err)
-			return err
-		}
-
-		transaction, _, err := ethClient.TransactionByHash(context.Background(), common.HexToHash(txHash))
-		if err != nil {
-			logs.GetLogger().Error(err)
-			return err
-		}
-		transReceipt, err := ethClient.TransactionReceipt(context.Background(), common.HexToHash(txHash))
-		if err != nil {
-			logs.GetLogger().Error(err)
-			return err
-		}
-		eventDaoSignature, err := models.GetEventDaoSignatures(&models.EventDaoSignature{PayloadCid: payload_cid, TxHash: txHash})
-		if err != nil {
-			logs.GetLogger().Error(err)
-			return err
-		}
-
-		if eventDaoSignature == nil {
-			eventDaoSignature = &models.EventDaoSignature{}
-		}
-
-		eventDaoSignature.TxHash = txHash
-		eventDaoSignature.Recipient = recipent
-		eventDaoSignature.PayloadCid = payload_cid
-		wfilCoinId, err := models.FindCoinByFullName(constants.COIN_NAME_USDC)
-		if err != nil {
-			logs.GetLogger().Error(err)
-		} else {
-			eventDaoSignature.CoinId = wfilCoinId.ID
-			eventDaoSignature.NetworkId = wfilCoinId.NetworkId
-		}
-		eventDaoSignature.DealId = deal_id
-		block, err := ethClient.BlockByHash(context.Background(), *rpcTransaction.BlockHash)
-		if err != nil {
-			logs.GetLogger().Error(err)
-		} else {
-			eventDaoSignature.BlockTime = strconv.FormatUint(block.Time(), 10)
-			eventDaoSignature.DaoPassTime = strconv.FormatUint(block.Time(), 10)
-		}
-		blockNumberStr := strings.Replace(*rpcTransaction.BlockNumber, "0x", "", -1)
-		blockNumberInt64, err := strconv.ParseUint(blockNumberStr, 16, 64)
-		if err != nil {
-			logs.GetLogger().Error(err)
-			return err
-		}
-		eventDaoSignature.BlockNo = blockNumberInt64
-		if transReceipt.Status == 1 {
-			eventDaoSignature.Status = true
-		} else {
-			eventDaoSignature.Status = false
-		}
-
-		if verification {
-			eventDaoSignature.SignatureUnlockStatus = constants.SIGNATURE_SUCCESS_VALUE
-		} else {
-			eventDaoSignature.SignatureUnlockStatus = constants.SIGNATURE_FAILED_VALUE
-		}
-		addrInfo, err := client.GetFromAndToAddressByTxHash(ethClient, transaction.ChainId(), common.HexToHash(txHash))
-		if err != nil {
-			logs.GetLogger().Error(err)
-		} else {
-			eventDaoSignature.DaoAddress = addrInfo.AddrFrom
-		}
-		err = database.SaveOneWithTransaction(eventDaoSignature)
-		if err != nil {
-			logs.GetLogger().Error(err)
-		}
-	}
-	return nil
-}
-
 func SaveExpirePaymentEvent(txHash string) (*models.EventExpirePayment, error) {
 
 	ethClient, rpcClient, err := client.GetEthClient()
@@ -507,27 +856,3 @@ func SaveExpirePaymentEvent(txHash string) (*models.EventExpirePayment, error) {
 	}
 	return nil, nil
 }
-
-func VerifyDaoSigOnContract(tx_hash string) (bool, error) {
-	client, _, err := client.GetEthClient()
-	if err != nil {
-		logs.GetLogger().Error(err)
-		return false, err
-	}
-	if tx_hash != "" && strings.HasPrefix(tx_hash, "0x") {
-		transaction, err := client.TransactionReceipt(context.Background(), common.HexToHash(tx_hash))
-		if err != nil {
-			logs.GetLogger().Error(err)
-			return false, err
-		}
-		if transaction.Status == 1 {
-			return true, nil
-		} else {
-			return false, nil
-		}
-	} else {
-		err := errors.New("invalid transaction hash:" + tx_hash)
-		logs.GetLogger().Error(err)
-		return false, err
-	}
-}
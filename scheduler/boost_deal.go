@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/models"
+
+	"github.com/filswan/go-swan-lib/client/lotus"
+	"github.com/filswan/go-swan-lib/client/web"
+	"github.com/filswan/go-swan-lib/logs"
+	libutils "github.com/filswan/go-swan-lib/utils"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// json layout of DealParams accepted by boost storage providers over /fil/storage/mk/1.2.0
+type BoostDealParams struct {
+	DealUUID           string                  `json:"DealUUID"`
+	IsOffline          bool                    `json:"IsOffline"`
+	ClientDealProposal BoostClientDealProposal `json:"ClientDealProposal"`
+	DealDataRoot       lotus.Cid               `json:"DealDataRoot"`
+	Transfer           BoostTransfer           `json:"Transfer"`
+	RemoveUnsealedCopy bool                    `json:"RemoveUnsealedCopy"`
+	SkipIPNIAnnounce   bool                    `json:"SkipIPNIAnnounce"`
+}
+
+type BoostClientDealProposal struct {
+	Proposal        BoostDealProposal `json:"Proposal"`
+	ClientSignature *BoostSignature   `json:"ClientSignature"`
+}
+
+type BoostDealProposal struct {
+	PieceCID             lotus.Cid `json:"PieceCID"`
+	PieceSize            int64     `json:"PieceSize"`
+	VerifiedDeal         bool      `json:"VerifiedDeal"`
+	Client               string    `json:"Client"`
+	Provider             string    `json:"Provider"`
+	Label                string    `json:"Label"`
+	StartEpoch           int64     `json:"StartEpoch"`
+	EndEpoch             int64     `json:"EndEpoch"`
+	StoragePricePerEpoch string    `json:"StoragePricePerEpoch"`
+	ProviderCollateral   string    `json:"ProviderCollateral"`
+	ClientCollateral     string    `json:"ClientCollateral"`
+}
+
+type BoostSignature struct {
+	Type int    `json:"Type"`
+	Data []byte `json:"Data"`
+}
+
+type BoostTransfer struct {
+	Type     string `json:"Type"`
+	ClientID string `json:"ClientID"`
+	Params   []byte `json:"Params"`
+	Size     int64  `json:"Size"`
+}
+
+type BoostHttpRequest struct {
+	URL     string            `json:"URL"`
+	Headers map[string]string `json:"Headers"`
+}
+
+type providerCollateralBounds struct {
+	lotus.LotusJsonRpcResult
+	Result struct {
+		Min string
+		Max string
+	} `json:"result"`
+}
+
+// the proposal is generated unsigned, it has to be signed by the client wallet before being submitted to a boost provider
+func getBoostDealParams(dealFile *models.DealFile, minerFid, carUrl string, askPrice decimal.Decimal, startEpoch int64) (*BoostDealParams, error) {
+	_, sectorSize := libutils.CalculatePieceSize(dealFile.CarFileSize)
+	pieceSize := int64(sectorSize)
+	verifiedDeal := config.GetConfig().SwanTask.VerifiedDeal
+
+	providerCollateral, err := getProviderCollateralMin(pieceSize, verifiedDeal)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	storagePricePerEpoch := libutils.CalculateRealCost(sectorSize, askPrice).Mul(decimal.NewFromFloat(constants.LOTUS_PRICE_MULTIPLE_1E18))
+
+	httpRequest, err := json.Marshal(BoostHttpRequest{
+		URL:     carUrl,
+		Headers: map[string]string{},
+	})
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	boostDealParams := &BoostDealParams{
+		DealUUID:  uuid.NewString(),
+		IsOffline: false,
+		ClientDealProposal: BoostClientDealProposal{
+			Proposal: BoostDealProposal{
+				PieceCID:             lotus.Cid{Cid: dealFile.PieceCid},
+				PieceSize:            pieceSize,
+				VerifiedDeal:         verifiedDeal,
+				Client:               config.GetConfig().SwanPlatformFilWallet,
+				Provider:             minerFid,
+				Label:                dealFile.PayloadCid,
+				StartEpoch:           startEpoch,
+				EndEpoch:             startEpoch + int64(constants.DURATION_DAYS_DEFAULT*constants.EPOCH_PER_DAY),
+				StoragePricePerEpoch: storagePricePerEpoch.BigInt().String(),
+				ProviderCollateral:   *providerCollateral,
+				ClientCollateral:     "0",
+			},
+		},
+		DealDataRoot: lotus.Cid{Cid: dealFile.PayloadCid},
+		Transfer: BoostTransfer{
+			Type:   "http",
+			Params: httpRequest,
+			Size:   dealFile.CarFileSize,
+		},
+	}
+
+	return boostDealParams, nil
+}
+
+func getProviderCollateralMin(pieceSize int64, verifiedDeal bool) (*string, error) {
+	var params []interface{}
+	params = append(params, pieceSize)
+	params = append(params, verifiedDeal)
+	params = append(params, nil)
+
+	jsonRpcParams := lotus.LotusJsonRpcParams{
+		JsonRpc: lotus.LOTUS_JSON_RPC_VERSION,
+		Method:  "Filecoin.StateDealProviderCollateralBounds",
+		Params:  params,
+		Id:      lotus.LOTUS_JSON_RPC_ID,
+	}
+
+	response, err := web.HttpGet(config.GetConfig().Lotus.ClientApiUrl, config.GetConfig().Lotus.ClientAccessToken, jsonRpcParams)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	collateralBounds := &providerCollateralBounds{}
+	err = json.Unmarshal(response, collateralBounds)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if collateralBounds.Error != nil {
+		err := fmt.Errorf("get provider collateral bounds failed, code:%d, message:%s", collateralBounds.Error.Code, collateralBounds.Error.Message)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return &collateralBounds.Result.Min, nil
+}
+
+func setDealProposalTransfer(dealProposal *models.DealProposal, dealFile *models.DealFile) {
+	if !utils.IsCarServerEnabled() {
+		return
+	}
+
+	dealProposal.TransferUrl = utils.GetCarUrl(dealFile.PieceCid, dealProposal.MinerFid)
+
+	boostDealParams, err := getBoostDealParams(dealFile, dealProposal.MinerFid, dealProposal.TransferUrl, dealProposal.AskPrice, dealProposal.StartEpoch)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return
+	}
+
+	boostDealParamsJson, err := json.Marshal(boostDealParams)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return
+	}
+
+	dealProposal.BoostDealParams = string(boostDealParamsJson)
+}
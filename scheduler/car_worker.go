@@ -0,0 +1,398 @@
+package scheduler
+
+import (
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/disk"
+	"multi-chain-storage/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/filswan/go-swan-lib/logs"
+	libmodel "github.com/filswan/go-swan-lib/model"
+	libutils "github.com/filswan/go-swan-lib/utils"
+)
+
+type CarStageMetric struct {
+	Stage          string `json:"stage"`
+	Count          int64  `json:"count"`
+	FailedCount    int64  `json:"failed_count"`
+	TotalMilliSec  int64  `json:"total_milli_sec"`
+	MaxMilliSec    int64  `json:"max_milli_sec"`
+	LastMilliSec   int64  `json:"last_milli_sec"`
+	LastFinishedAt int64  `json:"last_finished_at"`
+}
+
+type CarWorkerMetrics struct {
+	WorkerNumber int               `json:"worker_number"`
+	BusyWorkers  int               `json:"busy_workers"`
+	GroupEvents  map[string]int64  `json:"group_events"`
+	Stages       []*CarStageMetric `json:"stages"`
+	mutex        sync.Mutex
+	stageMap     map[string]*CarStageMetric
+}
+
+var carWorkerMetrics = &CarWorkerMetrics{
+	GroupEvents: map[string]int64{},
+	stageMap:    map[string]*CarStageMetric{},
+}
+
+var carWorkerNotify = make(chan struct{}, 1)
+
+// CreateCarWorkers starts the car workers, each of them claims planned car groups one by one,
+// so several mcs instances can share the same database without creating the same car file twice
+func CreateCarWorkers() {
+	workerNumber := config.GetConfig().SwanTask.CarWorkerNumber
+	if workerNumber <= 0 {
+		workerNumber = constants.CAR_WORKER_NUMBER_DEFAULT
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		hostname = "mcs"
+	}
+
+	carWorkerMetrics.mutex.Lock()
+	carWorkerMetrics.WorkerNumber = workerNumber
+	for _, stage := range []string{constants.CAR_STAGE_COPY, constants.CAR_STAGE_CAR, constants.CAR_STAGE_UPLOAD, constants.CAR_STAGE_SAVE} {
+		stageMetric := &CarStageMetric{Stage: stage}
+		carWorkerMetrics.Stages = append(carWorkerMetrics.Stages, stageMetric)
+		carWorkerMetrics.stageMap[stage] = stageMetric
+	}
+	carWorkerMetrics.mutex.Unlock()
+
+	for i := 0; i < workerNumber; i++ {
+		workerId := hostname + "-" + strconv.Itoa(os.Getpid()) + "-" + strconv.Itoa(i)
+		go runCarWorker(workerId)
+	}
+
+	logs.GetLogger().Info(workerNumber, " car workers started")
+}
+
+func runCarWorker(workerId string) {
+	for {
+		processed, err := processCarGroup(workerId)
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+
+		if processed {
+			continue
+		}
+
+		select {
+		case <-carWorkerNotify:
+		case <-time.After(constants.CAR_WORKER_IDLE_SECONDS * time.Second):
+		}
+	}
+}
+
+func notifyCarWorkers() {
+	select {
+	case carWorkerNotify <- struct{}{}:
+	default:
+	}
+}
+
+func reapCarGroups() {
+	claimTimeoutMinutes := config.GetConfig().SwanTask.CarClaimTimeoutMinutes
+	if claimTimeoutMinutes <= 0 {
+		claimTimeoutMinutes = constants.CAR_CLAIM_TIMEOUT_MINUTES_DEFAULT
+	}
+
+	claimedAtMax := utils.GetCurrentUtcMilliSecond() - int64(claimTimeoutMinutes)*60*1000
+	reaped, err := models.ReapCarGroups(claimedAtMax)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return
+	}
+
+	if reaped > 0 {
+		logs.GetLogger().Info(reaped, " car groups claimed more than ", claimTimeoutMinutes, " minutes ago are reaped")
+		carWorkerMetrics.mutex.Lock()
+		carWorkerMetrics.GroupEvents["reaped"] += reaped
+		carWorkerMetrics.mutex.Unlock()
+	}
+}
+
+// processCarGroup returns false when there is no car group to claim
+func processCarGroup(workerId string) (bool, error) {
+	carGroupIds, err := models.GetCarGroupIdsByStatus(constants.CAR_GROUP_STATUS_PLANNED, constants.CAR_QUEUE_SIZE_DEFAULT)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	for _, carGroupId := range carGroupIds {
+		carGroup, err := models.GetCarGroupById(carGroupId)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return false, err
+		}
+
+		// the source files are copied and then merged to the car file, so twice of their size is needed
+		err = disk.CheckFreeSize(carDir, carGroup.TotalSize*2)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return false, err
+		}
+
+		claimed, err := models.ClaimCarGroup(carGroupId, workerId)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return false, err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		carWorkerMetrics.addGroupEvent(constants.CAR_GROUP_STATUS_CLAIMED)
+		logs.GetLogger().Info("car group:", carGroupId, " claimed by car worker:", workerId)
+
+		carWorkerMetrics.setBusy(1)
+		err = createCarGroup(carGroupId, workerId)
+		carWorkerMetrics.setBusy(-1)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			errRelease := models.ReleaseCarGroup(carGroupId, workerId, err.Error(), constants.CAR_GROUP_ATTEMPTS_MAX)
+			if errRelease != nil {
+				logs.GetLogger().Error(errRelease)
+			}
+			carWorkerMetrics.addGroupEvent("released")
+			return true, err
+		}
+
+		err = models.UpdateCarGroupCreated(carGroupId, workerId)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return true, err
+		}
+
+		carWorkerMetrics.addGroupEvent(constants.CAR_GROUP_STATUS_CREATED)
+		logs.GetLogger().Info("car group:", carGroupId, " created by car worker:", workerId)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func createCarGroup(carGroupId int64, workerId string) error {
+	carGroup, err := models.GetCarGroupById(carGroupId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if carGroup == nil {
+		err := fmt.Errorf("car group:%d not found", carGroupId)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	srcFiles, err := models.GetSourceFilesByCarGroupId(carGroupId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if len(srcFiles) == 0 {
+		err := fmt.Errorf("no source file in car group:%d", carGroupId)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	currentTimeStr := time.Now().Format("2006-01-02T15:04:05")
+	// a reaped car group can be claimed by another worker while this one is still running, so the dirs are not shared between workers
+	carSrcDir := filepath.Join(carDir, "src_group_"+strconv.FormatInt(carGroupId, 10)+"_"+workerId)
+	carDestDir := filepath.Join(carDir, "car_group_"+strconv.FormatInt(carGroupId, 10)+"_"+workerId+"_"+currentTimeStr)
+	defer os.RemoveAll(carSrcDir)
+
+	err = runCarStage(constants.CAR_STAGE_COPY, func() error {
+		return copySrcFiles(srcFiles, carSrcDir)
+	})
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = libutils.CreateDir(carDestDir)
+	if err != nil {
+		logs.GetLogger().Error("creating dir:", carDestDir, " failed,", err)
+		return err
+	}
+
+	err = renewCarGroupClaim(carGroupId, workerId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	var fileDesc *libmodel.FileDesc
+	err = runCarStage(constants.CAR_STAGE_CAR, func() error {
+		fileDesc, err = createCarFile(carSrcDir, carDestDir)
+		return err
+	})
+	if err != nil {
+		os.RemoveAll(carDestDir)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	fileSizeMin := config.GetConfig().SwanTask.MinFileSize
+	if !isCarCreatedAnyway(srcFiles) && fileDesc.CarFileSize < fileSizeMin {
+		os.RemoveAll(carDestDir)
+		err := fmt.Errorf("car file size:%d is less than min file size:%d", fileDesc.CarFileSize, fileSizeMin)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	// the swan task can not be taken back, so it is only created while this worker still owns the car group
+	err = renewCarGroupClaim(carGroupId, workerId)
+	if err != nil {
+		os.RemoveAll(carDestDir)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = runCarStage(constants.CAR_STAGE_UPLOAD, func() error {
+		fileDesc, err = uploadCarAndCreateTask(carDestDir, carGroup.MaxPrice, 5)
+		return err
+	})
+	if err != nil {
+		os.RemoveAll(carDestDir)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = runCarStage(constants.CAR_STAGE_SAVE, func() error {
+		// the claim may have been reaped during the upload, then another worker owns the car group
+		err := renewCarGroupClaim(carGroupId, workerId)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+
+		return saveCarInfo2DB(fileDesc, srcFiles, carGroup.MaxPrice)
+	})
+	if err != nil {
+		os.RemoveAll(carDestDir)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// renewCarGroupClaim fails when the claim has been reaped since the last stage, then another worker owns the car group
+func renewCarGroupClaim(carGroupId int64, workerId string) error {
+	claimed, err := models.RenewCarGroupClaim(carGroupId, workerId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if !claimed {
+		err := fmt.Errorf("car group:%d is no longer claimed by car worker:%s", carGroupId, workerId)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func copySrcFiles(srcFiles []*models.SourceFileExt, carSrcDir string) error {
+	err := os.RemoveAll(carSrcDir)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = libutils.CreateDir(carSrcDir)
+	if err != nil {
+		logs.GetLogger().Error("creating dir:", carSrcDir, " failed,", err)
+		return err
+	}
+
+	for _, srcFile := range srcFiles {
+		srcFilepathTemp := filepath.Join(carSrcDir, filepath.Base(srcFile.ResourceUri))
+		_, err := libutils.CopyFile(srcFile.ResourceUri, srcFilepathTemp)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runCarStage(stage string, func2Run func() error) error {
+	startAt := utils.GetCurrentUtcMilliSecond()
+	err := func2Run()
+	finishedAt := utils.GetCurrentUtcMilliSecond()
+	elapsedMilliSec := finishedAt - startAt
+
+	carWorkerMetrics.mutex.Lock()
+	defer carWorkerMetrics.mutex.Unlock()
+
+	stageMetric, ok := carWorkerMetrics.stageMap[stage]
+	if !ok {
+		stageMetric = &CarStageMetric{Stage: stage}
+		carWorkerMetrics.Stages = append(carWorkerMetrics.Stages, stageMetric)
+		carWorkerMetrics.stageMap[stage] = stageMetric
+	}
+
+	stageMetric.Count++
+	if err != nil {
+		stageMetric.FailedCount++
+	}
+	stageMetric.TotalMilliSec = stageMetric.TotalMilliSec + elapsedMilliSec
+	if elapsedMilliSec > stageMetric.MaxMilliSec {
+		stageMetric.MaxMilliSec = elapsedMilliSec
+	}
+	stageMetric.LastMilliSec = elapsedMilliSec
+	stageMetric.LastFinishedAt = finishedAt
+
+	return err
+}
+
+func (metrics *CarWorkerMetrics) addGroupEvent(event string) {
+	metrics.mutex.Lock()
+	metrics.GroupEvents[event]++
+	metrics.mutex.Unlock()
+}
+
+func (metrics *CarWorkerMetrics) setBusy(delta int) {
+	metrics.mutex.Lock()
+	metrics.BusyWorkers = metrics.BusyWorkers + delta
+	metrics.mutex.Unlock()
+}
+
+// GetCarWorkerMetrics returns a copy of the car worker metrics of this mcs instance
+func GetCarWorkerMetrics() *CarWorkerMetrics {
+	carWorkerMetrics.mutex.Lock()
+	defer carWorkerMetrics.mutex.Unlock()
+
+	metrics := &CarWorkerMetrics{
+		WorkerNumber: carWorkerMetrics.WorkerNumber,
+		BusyWorkers:  carWorkerMetrics.BusyWorkers,
+		GroupEvents:  map[string]int64{},
+	}
+
+	for event, count := range carWorkerMetrics.GroupEvents {
+		metrics.GroupEvents[event] = count
+	}
+
+	for _, stageMetric := range carWorkerMetrics.Stages {
+		stageMetricCopy := *stageMetric
+		metrics.Stages = append(metrics.Stages, &stageMetricCopy)
+	}
+
+	return metrics
+}
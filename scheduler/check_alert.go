@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"multi-chain-storage/alert"
+	"multi-chain-storage/config"
+	"sync"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+)
+
+func CreateScheduler4CheckAlert() {
+	c := cron.New()
+	name := "check alert"
+	rule := config.GetConfig().ScheduleRule.CheckAlertRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := alert.CheckAlerts()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
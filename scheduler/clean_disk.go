@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/disk"
+	"multi-chain-storage/models"
+	"sync"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+)
+
+func CreateScheduler4CleanDisk() {
+	c := cron.New()
+	name := "clean disk"
+	rule := config.GetConfig().ScheduleRule.CleanDiskRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := CleanDisk()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
+
+// CleanDisk deletes the local files no longer needed according to the disk policy,
+// files pinned to ipfs server can be exported from it again when they are needed
+func CleanDisk() error {
+	diskPolicy := config.GetConfig().DiskPolicy
+
+	if diskPolicy.CarActiveDealsToDelete > 0 {
+		localFiles, err := models.GetCarLocalFiles2Delete(diskPolicy.CarActiveDealsToDelete)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+
+		note := fmt.Sprintf("car file pinned and has at least %d active deals", diskPolicy.CarActiveDealsToDelete)
+		deleteLocalFiles(localFiles, note)
+	}
+
+	if diskPolicy.SourceActiveDealsToDelete > 0 {
+		localFiles, err := models.GetSourceLocalFiles2Delete(diskPolicy.SourceActiveDealsToDelete)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+
+		note := fmt.Sprintf("source file pinned and its car file has at least %d active deals", diskPolicy.SourceActiveDealsToDelete)
+		deleteLocalFiles(localFiles, note)
+	}
+
+	retrieveCacheHours := diskPolicy.RetrieveCacheHours
+	if retrieveCacheHours <= 0 {
+		retrieveCacheHours = constants.DISK_RETRIEVE_CACHE_HOURS_DEFAULT
+	}
+
+	createAtMax := utils.GetCurrentUtcMilliSecond() - int64(retrieveCacheHours)*60*60*1000
+	localFiles, err := models.GetLocalFilesByTypeCreatedBefore(constants.LOCAL_FILE_TYPE_RETRIEVE, createAtMax)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	note := fmt.Sprintf("retrieved more than %d hours ago", retrieveCacheHours)
+	deleteLocalFiles(localFiles, note)
+
+	return nil
+}
+
+func deleteLocalFiles(localFiles []*models.LocalFile, note string) {
+	for _, localFile := range localFiles {
+		err := disk.DeleteFile(localFile, note)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+	}
+}
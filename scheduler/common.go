@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"fmt"
+	"multi-chain-storage/alert"
 	"multi-chain-storage/config"
 	"os"
 	"path/filepath"
@@ -26,14 +27,29 @@ func GetSrcDir() string {
 	return srcDir
 }
 
+func GetCarDir() string {
+	return carDir
+}
+
 func InitScheduler() {
 	createDir()
 	//createScheduleJob()
+	CreateCarWorkers()
 	CreateScheduler4CreateTask()
 	CreateScheduler4Refund()
 	CreateScheduler4ScanDeal()
 	CreateScheduler4SendDeal()
 	CreateScheduler4UnlockPayment()
+	CreateScheduler4RenewDeal()
+	CreateScheduler4ScoreMiner()
+	CreateScheduler4CleanDisk()
+	CreateScheduler4DeliverWebhook()
+	CreateScheduler4CheckAlert()
+	CreateScheduler4VerifyDaoDeal()
+	CreateScheduler4IndexDaoSignature()
+	CreateScheduler4ReconcileLedger()
+	CreateScheduler4ExpireRefund()
+	CreateScheduler4RetrieveFile()
 }
 
 func createScheduleJob() {
@@ -44,6 +60,16 @@ func createScheduleJob() {
 		{Name: "scan deal", Rule: confScheduleRule.ScanDealStatusRule, Func: ScanDeal, Mutex: &sync.Mutex{}},
 		{Name: "unlock payment", Rule: confScheduleRule.UnlockPaymentRule, Func: UnlockPayment, Mutex: &sync.Mutex{}},
 		{Name: "refund", Rule: confScheduleRule.RefundRule, Func: Refund, Mutex: &sync.Mutex{}},
+		{Name: "renew deal", Rule: confScheduleRule.RenewDealRule, Func: RenewDeal, Mutex: &sync.Mutex{}},
+		{Name: "score miner", Rule: confScheduleRule.ScoreMinerRule, Func: ScoreMiner, Mutex: &sync.Mutex{}},
+		{Name: "clean disk", Rule: confScheduleRule.CleanDiskRule, Func: CleanDisk, Mutex: &sync.Mutex{}},
+		{Name: "deliver webhook", Rule: confScheduleRule.DeliverWebhookRule, Func: DeliverWebhook, Mutex: &sync.Mutex{}},
+		{Name: "check alert", Rule: confScheduleRule.CheckAlertRule, Func: alert.CheckAlerts, Mutex: &sync.Mutex{}},
+		{Name: "verify dao deal", Rule: confScheduleRule.VerifyDaoDealRule, Func: VerifyDaoDeals, Mutex: &sync.Mutex{}},
+		{Name: "index dao signature", Rule: confScheduleRule.IndexDaoSignatureRule, Func: IndexDaoSignatures, Mutex: &sync.Mutex{}},
+		{Name: "reconcile ledger", Rule: confScheduleRule.ReconcileLedgerRule, Func: ReconcileLedger, Mutex: &sync.Mutex{}},
+		{Name: "expire refund", Rule: confScheduleRule.ExpireRefundRule, Func: ExpireRefund, Mutex: &sync.Mutex{}},
+		{Name: "retrieve file", Rule: confScheduleRule.RetrieveFileRule, Func: RetrieveFiles, Mutex: &sync.Mutex{}},
 	}
 
 	for _, scheduleJob := range scheduleJobs {
@@ -101,7 +127,7 @@ func createDir() {
 	}
 
 	carDir = filepath.Join(dealDir, "car")
-	err = libutils.CreateDir(srcDir)
+	err = libutils.CreateDir(carDir)
 	if err != nil {
 		logs.GetLogger().Error(err)
 		logs.GetLogger().Fatal("creating dir:", carDir, " failed")
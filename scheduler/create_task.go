@@ -1,18 +1,19 @@
 package scheduler
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"multi-chain-storage/car"
 	"multi-chain-storage/common/constants"
 	"multi-chain-storage/common/utils"
 	"multi-chain-storage/config"
 	"multi-chain-storage/database"
+	"multi-chain-storage/disk"
 	"multi-chain-storage/models"
 	"multi-chain-storage/on-chain/client"
-	"os"
 	"path/filepath"
 	"sync"
-	"time"
 
 	"github.com/filswan/go-swan-client/command"
 	libconstants "github.com/filswan/go-swan-lib/constants"
@@ -55,48 +56,55 @@ func CreateTask() error {
 		logs.GetLogger().Error(err)
 	}
 
+	reapCarGroups()
+	defer notifyCarWorkers()
+
 	for {
-		numSrcFiles, err := createTask()
+		numSrcFiles, err := planCarGroup()
 		if err != nil {
 			logs.GetLogger().Error(err)
 			return err
 		}
 
 		if numSrcFiles == nil || *numSrcFiles == 0 {
-			logs.GetLogger().Info("0 source file created to car file")
+			logs.GetLogger().Info("0 source file planned to car file")
 			return nil
 		}
 
-		logs.GetLogger().Info(*numSrcFiles, " source file(s) created to car file")
+		logs.GetLogger().Info(*numSrcFiles, " source file(s) planned to car file")
 	}
-
 }
 
-func createTask() (*int, error) {
-	srcFiles, err := models.GetSourceFilesNeed2Car()
+// source files are planned into car groups here, and car files and tasks are created from the car groups by car workers
+func planCarGroup() (*int, error) {
+	carQueueSize := config.GetConfig().SwanTask.CarQueueSize
+	if carQueueSize <= 0 {
+		carQueueSize = constants.CAR_QUEUE_SIZE_DEFAULT
+	}
+
+	carGroupIds, err := models.GetCarGroupIdsByStatus(constants.CAR_GROUP_STATUS_PLANNED, carQueueSize)
 	if err != nil {
 		logs.GetLogger().Error(err)
 		return nil, err
 	}
 
-	if len(srcFiles) == 0 {
-		logs.GetLogger().Info("0 source file to be created to car file")
+	if len(carGroupIds) >= carQueueSize {
+		logs.GetLogger().Info(len(carGroupIds), " car groups are waiting for car workers, car queue is full")
 		return nil, nil
 	}
 
-	currentTimeStr := time.Now().Format("2006-01-02T15:04:05")
-	carSrcDir := filepath.Join(carDir, "src_"+currentTimeStr)
-	carDestDir := filepath.Join(carDir, "car_"+currentTimeStr)
-
-	err = libutils.CreateDir(carSrcDir)
+	srcFiles, err := models.GetSourceFilesNeed2Car()
 	if err != nil {
-		logs.GetLogger().Error("creating dir:", carSrcDir, " failed,", err)
+		logs.GetLogger().Error(err)
 		return nil, err
 	}
 
+	if len(srcFiles) == 0 {
+		logs.GetLogger().Info("0 source file to be created to car file")
+		return nil, nil
+	}
+
 	totalSize := int64(0)
-	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
-	createdTimeMin := currentUtcMilliSec
 	var maxPrice *decimal.Decimal
 
 	fileCoinPriceInUsdc, err := client.GetWfilPriceFromSushiPrice("1")
@@ -108,27 +116,13 @@ func createTask() (*int, error) {
 	fileSizeMin := config.GetConfig().SwanTask.MinFileSize
 	var srcFiles2Merged []*models.SourceFileExt
 	for _, srcFile := range srcFiles {
-		srcFilepathTemp := filepath.Join(carSrcDir, filepath.Base(srcFile.ResourceUri))
-
-		bytesCopied, err := libutils.CopyFile(srcFile.ResourceUri, srcFilepathTemp)
-		if err != nil {
-			os.Remove(srcFilepathTemp)
-			logs.GetLogger().Error(err)
-			continue
-		}
-
 		maxPriceTemp, err := getMaxPrice(*srcFile, fileCoinPriceInUsdc)
 		if err != nil {
-			os.Remove(srcFilepathTemp)
 			logs.GetLogger().Error(err)
 			continue
 		}
 
-		totalSize = totalSize + bytesCopied
-
-		if srcFile.CreateAt < createdTimeMin {
-			createdTimeMin = srcFile.CreateAt
-		}
+		totalSize = totalSize + srcFile.FileSize
 
 		if maxPrice == nil {
 			maxPrice = maxPriceTemp
@@ -145,54 +139,83 @@ func createTask() (*int, error) {
 	}
 
 	if totalSize == 0 {
-		os.RemoveAll(carSrcDir)
 		logs.GetLogger().Info("0 source file to be created to car file")
 		return nil, nil
 	}
 
-	passedMilliSec := currentUtcMilliSec - createdTimeMin
-	createAnyway := false
-	if passedMilliSec >= 24*60*60*1000 {
-		createAnyway = true
-	}
-
-	if !createAnyway && totalSize < fileSizeMin {
-		os.RemoveAll(carSrcDir)
+	if !isCarCreatedAnyway(srcFiles2Merged) && totalSize < fileSizeMin {
 		err := fmt.Errorf("source file size:%d is less than min file size:%d", totalSize, fileSizeMin)
 		logs.GetLogger().Error(err)
 		return nil, err
 	}
 
-	err = libutils.CreateDir(carDestDir)
+	err = saveCarGroup(srcFiles2Merged, totalSize, *maxPrice)
 	if err != nil {
-		os.RemoveAll(carSrcDir)
-		logs.GetLogger().Error("creating dir:", carDestDir, " failed,", err)
+		logs.GetLogger().Error(err)
 		return nil, err
 	}
 
-	fileDesc, err := createTask4SrcFiles(carSrcDir, carDestDir, *maxPrice, createAnyway, fileSizeMin)
-	if err != nil {
-		os.RemoveAll(carSrcDir)
-		os.RemoveAll(carDestDir)
-		logs.GetLogger().Error(err)
-		return nil, err
+	numSrcFiles := len(srcFiles2Merged)
+	return &numSrcFiles, nil
+}
+
+// source files waiting for more than a day are created to a car file even if it is smaller than min_file_size
+func isCarCreatedAnyway(srcFiles []*models.SourceFileExt) bool {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	createdTimeMin := currentUtcMilliSec
+	for _, srcFile := range srcFiles {
+		if srcFile.CreateAt < createdTimeMin {
+			createdTimeMin = srcFile.CreateAt
+		}
 	}
 
-	err = saveCarInfo2DB(fileDesc, srcFiles2Merged, *maxPrice)
+	return currentUtcMilliSec-createdTimeMin >= 24*60*60*1000
+}
+
+func saveCarGroup(srcFiles []*models.SourceFileExt, totalSize int64, maxPrice decimal.Decimal) error {
+	db := database.GetDBTransaction()
+	currentUtcMilliSecond := utils.GetCurrentUtcMilliSecond()
+	carGroup := models.CarGroup{
+		Status:    constants.CAR_GROUP_STATUS_PLANNED,
+		FileCount: len(srcFiles),
+		TotalSize: totalSize,
+		MaxPrice:  maxPrice,
+		CreateAt:  currentUtcMilliSecond,
+		UpdateAt:  currentUtcMilliSecond,
+	}
+
+	err := database.SaveOneInTransaction(db, &carGroup)
 	if err != nil {
-		os.RemoveAll(carSrcDir)
-		os.RemoveAll(carDestDir)
+		db.Rollback()
 		logs.GetLogger().Error(err)
-		return nil, err
+		return err
 	}
 
-	err = os.RemoveAll(carSrcDir)
+	for _, srcFile := range srcFiles {
+		sql := "update source_file set car_group_id=?,update_at=? where id=? and car_group_id is null"
+
+		params := []interface{}{}
+		params = append(params, carGroup.ID)
+		params = append(params, currentUtcMilliSecond)
+		params = append(params, srcFile.ID)
+
+		err = db.Exec(sql, params...).Error
+		if err != nil {
+			db.Rollback()
+			logs.GetLogger().Error(err)
+			return err
+		}
+	}
+
+	err = db.Commit().Error
 	if err != nil {
 		logs.GetLogger().Error(err)
+		return err
 	}
 
-	numSrcFiles := len(srcFiles2Merged)
-	return &numSrcFiles, nil
+	carWorkerMetrics.addGroupEvent(constants.CAR_GROUP_STATUS_PLANNED)
+	logs.GetLogger().Info("car group:", carGroup.ID, " planned with ", len(srcFiles), " source file(s)")
+	return nil
 }
 
 func getMaxPrice(srcFile models.SourceFileExt, rate *big.Int) (*decimal.Decimal, error) {
@@ -216,29 +239,47 @@ func getMaxPrice(srcFile models.SourceFileExt, rate *big.Int) (*decimal.Decimal,
 	return &maxPrice, nil
 }
 
-func createTask4SrcFiles(srcDir, carDir string, maxPrice decimal.Decimal, createAnyway bool, fileSizeMin int64) (*libmodel.FileDesc, error) {
-	cmdIpfsCar := &command.CmdIpfsCar{
-		LotusClientApiUrl:         config.GetConfig().Lotus.ClientApiUrl,
-		LotusClientAccessToken:    config.GetConfig().Lotus.ClientAccessToken,
-		InputDir:                  srcDir,
-		OutputDir:                 carDir,
-		GenerateMd5:               false,
-		IpfsServerUploadUrlPrefix: config.GetConfig().IpfsServer.UploadUrlPrefix,
+func createCarFile(srcDir, carDir string) (*libmodel.FileDesc, error) {
+	if config.GetConfig().SwanTask.CarBuilder != constants.CAR_BUILDER_LOCAL {
+		cmdIpfsCar := &command.CmdIpfsCar{
+			LotusClientApiUrl:         config.GetConfig().Lotus.ClientApiUrl,
+			LotusClientAccessToken:    config.GetConfig().Lotus.ClientAccessToken,
+			InputDir:                  srcDir,
+			OutputDir:                 carDir,
+			GenerateMd5:               false,
+			IpfsServerUploadUrlPrefix: config.GetConfig().IpfsServer.UploadUrlPrefix,
+		}
+
+		fileDescs, err := cmdIpfsCar.CreateIpfsCarFiles()
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		return fileDescs[0], nil
 	}
 
-	fileDescs, err := cmdIpfsCar.CreateIpfsCarFiles()
+	carVersion := config.GetConfig().SwanTask.CarVersion
+	if carVersion == 0 {
+		carVersion = car.CAR_VERSION_1
+	}
+
+	fileDesc, err := car.CreateCarFile(srcDir, carDir, carVersion)
 	if err != nil {
 		logs.GetLogger().Error(err)
 		return nil, err
 	}
 
-	fileDesc := fileDescs[0]
-	if !createAnyway && fileDesc.CarFileSize < fileSizeMin {
-		err := fmt.Errorf("car file size is less than %d", fileSizeMin)
+	_, err = command.WriteFileDescsToJsonFile([]*libmodel.FileDesc{fileDesc}, carDir, command.JSON_FILE_NAME_CAR_UPLOAD)
+	if err != nil {
 		logs.GetLogger().Error(err)
 		return nil, err
 	}
 
+	return fileDesc, nil
+}
+
+func uploadCarAndCreateTask(carDir string, maxPrice decimal.Decimal, copyNumber int) (*libmodel.FileDesc, error) {
 	cmdUpload := command.CmdUpload{
 		StorageServerType:           libconstants.STORAGE_SERVER_TYPE_IPFS_SERVER,
 		IpfsServerDownloadUrlPrefix: config.GetConfig().IpfsServer.DownloadUrlPrefix,
@@ -246,17 +287,40 @@ func createTask4SrcFiles(srcDir, carDir string, maxPrice decimal.Decimal, create
 		InputDir:                    carDir,
 	}
 
-	_, err = cmdUpload.UploadCarFiles()
+	_, err := cmdUpload.UploadCarFiles()
 	if err != nil {
 		logs.GetLogger().Error(err)
 		return nil, err
 	}
 	logs.GetLogger().Info("car files uploaded")
 
+	if utils.IsCarServerEnabled() {
+		err = setCarFileUrl2CarServer(carDir)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+	}
+
+	if config.GetConfig().DealEngine == constants.DEAL_ENGINE_LOTUS {
+		fileDesc, err := createLotusTask(carDir)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		return fileDesc, nil
+	}
+
 	taskDataset := config.GetConfig().SwanTask.CuratedDataset
 	taskDescription := config.GetConfig().SwanTask.Description
 	startEpochIntervalHours := config.GetConfig().SwanTask.StartEpochHours
 
+	bidMode := libconstants.TASK_BID_MODE_AUTO
+	if config.GetConfig().MinerPolicy.SelectionMode == constants.MINER_SELECTION_MODE_MANUAL_BID {
+		bidMode = libconstants.TASK_BID_MODE_MANUAL
+	}
+
 	durationEpoch := constants.DURATION_DAYS_DEFAULT * 24 * 60 * 2
 	cmdTask := command.CmdTask{
 		SwanApiUrl:                 config.GetConfig().SwanApi.ApiUrl,
@@ -264,7 +328,7 @@ func createTask4SrcFiles(srcDir, carDir string, maxPrice decimal.Decimal, create
 		SwanApiKey:                 config.GetConfig().SwanApi.ApiKey,
 		SwanAccessToken:            config.GetConfig().SwanApi.AccessToken,
 		LotusClientApiUrl:          config.GetConfig().Lotus.ClientApiUrl,
-		BidMode:                    libconstants.TASK_BID_MODE_AUTO,
+		BidMode:                    bidMode,
 		VerifiedDeal:               config.GetConfig().SwanTask.VerifiedDeal,
 		OfflineMode:                false,
 		FastRetrieval:              config.GetConfig().SwanTask.FastRetrieval,
@@ -279,23 +343,45 @@ func createTask4SrcFiles(srcDir, carDir string, maxPrice decimal.Decimal, create
 		StartEpochHours:            startEpochIntervalHours,
 		SourceId:                   constants.SOURCE_ID_OF_PAYMENT,
 		Duration:                   durationEpoch,
-		MaxAutoBidCopyNumber:       5,
+		MaxAutoBidCopyNumber:       copyNumber,
 	}
 
-	_, fileDescs, _, err = cmdTask.CreateTask(nil)
+	_, fileDescs, _, err := cmdTask.CreateTask(nil)
 	if err != nil {
 		logs.GetLogger().Error(err)
 		return nil, err
 	}
 
-	fileDesc = fileDescs[0]
+	fileDesc := fileDescs[0]
 
 	logs.GetLogger().Info("car files created in ", carDir, "payload_cid=", fileDesc.PayloadCid)
 
 	return fileDesc, nil
 }
 
+func setCarFileUrl2CarServer(carDir string) error {
+	fileDescs, err := command.ReadFileDescsFromJsonFile(carDir, command.JSON_FILE_NAME_CAR_UPLOAD)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	for _, fileDesc := range fileDescs {
+		fileDesc.CarFileUrl = utils.GetCarUrl(fileDesc.PieceCid, "")
+	}
+
+	_, err = command.WriteFileDescsToJsonFile(fileDescs, carDir, command.JSON_FILE_NAME_CAR_UPLOAD)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
 func saveCarInfo2DB(fileDesc *libmodel.FileDesc, srcFiles []*models.SourceFileExt, maxPrice decimal.Decimal) error {
+	inclusionProofs := getInclusionProofs(fileDesc, srcFiles)
+
 	db := database.GetDBTransaction()
 	currentUtcMilliSecond := utils.GetCurrentUtcMilliSecond()
 	dealFile := models.DealFile{
@@ -311,6 +397,7 @@ func saveCarInfo2DB(fileDesc *libmodel.FileDesc, srcFiles []*models.SourceFileEx
 		LockPaymentStatus: constants.PROCESS_STATUS_TASK_CREATED,
 		MaxPrice:          maxPrice,
 		TaskUuid:          fileDesc.Uuid,
+		PinStatus:         constants.IPFS_File_PINNED_STATUS,
 	}
 
 	err := database.SaveOneInTransaction(db, &dealFile)
@@ -320,14 +407,20 @@ func saveCarInfo2DB(fileDesc *libmodel.FileDesc, srcFiles []*models.SourceFileEx
 		return err
 	}
 
-	for _, srcFile := range srcFiles {
+	fileStatusHistories := []*models.FileStatusHistory{}
+	for i, srcFile := range srcFiles {
 		filepMap := models.SourceFileDealFileMap{
 			SourceFileId: srcFile.ID,
 			DealFileId:   dealFile.ID,
-			FileIndex:    0,
+			FileIndex:    i,
 			CreateAt:     currentUtcMilliSecond,
 			UpdateAt:     currentUtcMilliSecond,
 		}
+
+		if inclusionProofs != nil {
+			setInclusionProof(&filepMap, inclusionProofs[i])
+		}
+
 		err = database.SaveOneInTransaction(db, filepMap)
 		if err != nil {
 			db.Rollback()
@@ -348,6 +441,14 @@ func saveCarInfo2DB(fileDesc *libmodel.FileDesc, srcFiles []*models.SourceFileEx
 			logs.GetLogger().Error(err)
 			return err
 		}
+
+		fileStatusHistory, err := models.TransitFileStatusInTransaction(db, srcFile.ID, constants.FILE_STATUS_AGGREGATED, "merged to car file with payload_cid:"+fileDesc.PayloadCid)
+		if err != nil {
+			db.Rollback()
+			logs.GetLogger().Error(err)
+			return err
+		}
+		fileStatusHistories = append(fileStatusHistories, fileStatusHistory)
 	}
 
 	err = db.Commit().Error
@@ -356,9 +457,54 @@ func saveCarInfo2DB(fileDesc *libmodel.FileDesc, srcFiles []*models.SourceFileEx
 		return err
 	}
 
+	for _, fileStatusHistory := range fileStatusHistories {
+		models.PublishFileStatusHistory(fileStatusHistory)
+	}
+
+	err = disk.TrackFile(constants.LOCAL_FILE_TYPE_CAR, filepath.Dir(fileDesc.CarFilePath), nil, &dealFile.ID)
+	if err != nil {
+		logs.GetLogger().Error(err)
+	}
+
 	return nil
 }
 
+// the source files are still deal-able without their positions in the car file, so failures here are only logged
+func getInclusionProofs(fileDesc *libmodel.FileDesc, srcFiles []*models.SourceFileExt) []*car.InclusionProof {
+	rootCids := []string{}
+	for _, srcFile := range srcFiles {
+		rootCids = append(rootCids, srcFile.PayloadCid)
+	}
+
+	dagPositions, err := car.GetDagPositions(fileDesc.CarFilePath, rootCids)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil
+	}
+
+	inclusionProofs, err := car.GenerateInclusionProofs(fileDesc.CarFilePath, fileDesc.PieceCid, dagPositions)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil
+	}
+
+	return inclusionProofs
+}
+
+func setInclusionProof(filepMap *models.SourceFileDealFileMap, inclusionProof *car.InclusionProof) {
+	inclusionProofJson, err := json.Marshal(inclusionProof)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return
+	}
+
+	inclusionProofStr := string(inclusionProofJson)
+	filepMap.RootCid = &inclusionProof.RootCid
+	filepMap.CarOffset = &inclusionProof.CarOffset
+	filepMap.CarLength = &inclusionProof.CarLength
+	filepMap.InclusionProof = &inclusionProofStr
+}
+
 func CheckSourceFilesPaid() error {
 	srcFiles, err := models.GetSourceFilesByStatus(constants.SOURCE_FILE_STATUS_CREATED)
 	if err != nil {
@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/models"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+)
+
+func CreateScheduler4DeliverWebhook() {
+	c := cron.New()
+	name := "deliver webhook"
+	rule := config.GetConfig().ScheduleRule.DeliverWebhookRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := DeliverWebhook()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
+
+func DeliverWebhook() error {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	webhookDeliveries, err := models.GetWebhookDeliveries2Send(currentUtcMilliSec, constants.WEBHOOK_DELIVERY_BATCH_SIZE)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	for _, webhookDelivery := range webhookDeliveries {
+		responseCode, err := postWebhookDelivery(webhookDelivery)
+
+		webhookDelivery.Attempts++
+		webhookDelivery.ResponseCode = responseCode
+		if err == nil {
+			deliveredAt := utils.GetCurrentUtcMilliSecond()
+			webhookDelivery.Status = constants.WEBHOOK_DELIVERY_STATUS_DELIVERED
+			webhookDelivery.DeliveredAt = &deliveredAt
+			webhookDelivery.Note = ""
+		} else {
+			logs.GetLogger().Error(err)
+			webhookDelivery.Note = err.Error()
+			if webhookDelivery.Attempts >= constants.WEBHOOK_DELIVERY_ATTEMPTS_MAX {
+				webhookDelivery.Status = constants.WEBHOOK_DELIVERY_STATUS_FAILED
+			} else {
+				// retry after 30s, 1m, 2m, 4m ...
+				backoffSeconds := int64(constants.WEBHOOK_RETRY_BASE_SECONDS) << uint(webhookDelivery.Attempts-1)
+				webhookDelivery.NextAttemptAt = utils.GetCurrentUtcMilliSecond() + backoffSeconds*1000
+			}
+		}
+
+		err = models.UpdateWebhookDeliveryResult(&webhookDelivery.WebhookDelivery)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// the url is checked again before each delivery, and the addresses connected are checked by the webhook http client
+func postWebhookDelivery(webhookDelivery *models.WebhookDeliveryExt) (*int, error) {
+	err := utils.CheckWebhookUrl(webhookDelivery.Url)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	body := []byte(webhookDelivery.Payload)
+	request, err := http.NewRequest(http.MethodPost, webhookDelivery.Url, bytes.NewReader(body))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(constants.WEBHOOK_HEADER_EVENT, webhookDelivery.Event)
+	request.Header.Set(constants.WEBHOOK_HEADER_DELIVERY, strconv.FormatInt(webhookDelivery.ID, 10))
+	request.Header.Set(constants.WEBHOOK_HEADER_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(constants.WEBHOOK_HEADER_SIGNATURE, utils.GetWebhookSignature(webhookDelivery.Secret, timestamp, body))
+
+	httpClient := utils.NewWebhookHttpClient(constants.WEBHOOK_TIMEOUT_SECONDS * time.Second)
+	response, err := httpClient.Do(request)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, 1024*1024))
+
+	responseCode := response.StatusCode
+	if responseCode < 200 || responseCode >= 300 {
+		err := fmt.Errorf("webhook:%d responded http status:%s", webhookDelivery.WebhookId, response.Status)
+		logs.GetLogger().Error(err)
+		return &responseCode, err
+	}
+
+	return &responseCode, nil
+}
@@ -0,0 +1,372 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+	"multi-chain-storage/on-chain/goBind"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+	"github.com/shopspring/decimal"
+)
+
+// expiredPayment is a locked payment passed its deadline on chain, with the source files locked by it
+type expiredPayment struct {
+	PayloadCid  string
+	SrcFiles    []*models.SourceFile
+	PaymentInfo goBind.IPaymentMinimalTxInfo
+}
+
+func CreateScheduler4ExpireRefund() {
+	c := cron.New()
+	name := "expire refund"
+	rule := config.GetConfig().ScheduleRule.ExpireRefundRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := ExpireRefund()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
+
+// ExpireRefund refunds the payments locked by the source files passed the deadline before any of their deals was active,
+// on behalf of the users, by refund of the payment contract in batches, instead of waiting for the users to unlock them
+func ExpireRefund() error {
+	now := time.Now().Unix()
+	srcFiles, err := models.GetSourceFilesExpiredWithoutActiveDeal(now)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if len(srcFiles) == 0 {
+		return nil
+	}
+
+	swanPaymentSession, err := client.GetSwanPaymentSession()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	// the payment is locked by payload cid, and may be shared by source files uploaded more than once
+	payloadCids := []string{}
+	srcFilesByPayloadCid := map[string][]*models.SourceFile{}
+	for _, srcFile := range srcFiles {
+		if _, ok := srcFilesByPayloadCid[srcFile.PayloadCid]; !ok {
+			payloadCids = append(payloadCids, srcFile.PayloadCid)
+		}
+		srcFilesByPayloadCid[srcFile.PayloadCid] = append(srcFilesByPayloadCid[srcFile.PayloadCid], srcFile)
+	}
+
+	expiredPayments := []*expiredPayment{}
+	for _, payloadCid := range payloadCids {
+		paymentInfo, err := swanPaymentSession.GetLockedPaymentInfo(payloadCid)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		if !paymentInfo.IsExisted {
+			for _, srcFile := range srcFilesByPayloadCid[payloadCid] {
+				err = models.TransitFileStatus(srcFile.ID, constants.FILE_STATUS_EXPIRED, "locked payment of payload_cid:"+payloadCid+" not exists after expiry")
+				if err != nil {
+					logs.GetLogger().Error(err)
+				}
+			}
+			continue
+		}
+
+		if paymentInfo.Deadline.Cmp(big.NewInt(now)) >= 0 {
+			logs.GetLogger().Info("payment of payload_cid:", payloadCid, " not expired on chain yet, deadline:", paymentInfo.Deadline.String())
+			continue
+		}
+
+		// the status of the offline deals is only as recent as the last scan, deal sent files may have a deal active on chain already
+		activeDealId, err := getActiveMarketDealId(payloadCid)
+		if err != nil {
+			logs.GetLogger().Error("deals of payload_cid:", payloadCid, " not confirmed inactive on chain, not refunded, error:", err)
+			continue
+		}
+
+		if activeDealId > 0 {
+			logs.GetLogger().Info("deal:", activeDealId, " of payload_cid:", payloadCid, " active on chain, not refunded")
+			continue
+		}
+
+		expiredPayments = append(expiredPayments, &expiredPayment{
+			PayloadCid:  payloadCid,
+			SrcFiles:    srcFilesByPayloadCid[payloadCid],
+			PaymentInfo: paymentInfo,
+		})
+	}
+
+	if len(expiredPayments) == 0 {
+		return nil
+	}
+
+	ethClient, _, err := client.GetEthClient()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	swanPaymentTransactor, err := client.GetSwanPaymentTransactor(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	batchSize := config.GetConfig().Polygon.ExpireRefundBatchSize
+	if batchSize <= 0 {
+		batchSize = constants.EXPIRE_REFUND_BATCH_SIZE_DEFAULT
+	}
+
+	for i := 0; i < len(expiredPayments); i = i + batchSize {
+		end := i + batchSize
+		if end > len(expiredPayments) {
+			end = len(expiredPayments)
+		}
+
+		err = refundExpiredPayments(ethClient, swanPaymentTransactor, expiredPayments[i:end])
+		if errors.Is(err, errTxJobPaused) {
+			return err
+		}
+
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// refundExpiredPayments sends one refund tx for the expired payments,
+// and records the locked fee transferred back to the owner of each payment as its expire payment event
+func refundExpiredPayments(ethClient *ethclient.Client, swanPaymentTransactor *goBind.SwanPaymentTransactor, expiredPayments []*expiredPayment) error {
+	err := checkTxJobAllowed(ethClient, constants.TX_JOB_REFUND)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	privateKey, publicKeyAddress, err := client.GetPrivateKeyPublicKey(constants.PRIVATE_KEY_ON_POLYGON)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	tansactOpts, err := client.GetTransactOpts(ethClient, privateKey, *publicKeyAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	payloadCids := []string{}
+	for _, expiredPayment := range expiredPayments {
+		payloadCids = append(payloadCids, expiredPayment.PayloadCid)
+
+		lockedFee := decimal.NewFromBigInt(expiredPayment.PaymentInfo.LockedFee, 0)
+		for _, srcFile := range expiredPayment.SrcFiles {
+			err = models.UpdateSourceFileRefundAmount(srcFile.ID, lockedFee)
+			if err != nil {
+				logs.GetLogger().Error(err)
+			}
+
+			err = models.TransitFileStatus(srcFile.ID, constants.FILE_STATUS_REFUNDING, "payment of payload_cid:"+expiredPayment.PayloadCid+" expired without active deal")
+			if err != nil {
+				logs.GetLogger().Error(err)
+			}
+		}
+	}
+
+	tx, err := swanPaymentTransactor.Refund(tansactOpts, payloadCids)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		setExpiredPaymentsRefundFailed(expiredPayments, "")
+		return err
+	}
+
+	txHash := tx.Hash().Hex()
+	logs.GetLogger().Info("refund expired payments:", payloadCids, " tx hash:", txHash)
+
+	txReceipt, err := client.CheckTx(ethClient, tx)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		setExpiredPaymentsRefundFailed(expiredPayments, txHash)
+		return err
+	}
+
+	saveGasSpend(constants.TX_JOB_REFUND, tx, txReceipt)
+
+	if txReceipt.Status != uint64(1) {
+		err := fmt.Errorf("refund expired payments failed, tx hash:%s", txHash)
+		logs.GetLogger().Error(err)
+		setExpiredPaymentsRefundFailed(expiredPayments, txHash)
+		return err
+	}
+
+	blockTime := ""
+	header, err := ethClient.HeaderByNumber(context.Background(), txReceipt.BlockNumber)
+	if err != nil {
+		logs.GetLogger().Error(err)
+	} else {
+		blockTime = strconv.FormatUint(header.Time, 10)
+	}
+
+	refundedPayments := []*refundedPayment{}
+	for _, expiredPayment := range expiredPayments {
+		refundedPayments = append(refundedPayments, &refundedPayment{
+			Owner:     expiredPayment.PaymentInfo.Owner,
+			LockedFee: expiredPayment.PaymentInfo.LockedFee,
+		})
+	}
+	refundTransfers := matchRefundTransfers(refundedPayments, client.GetRefundTransfers(txReceipt))
+
+	for i, expiredPayment := range expiredPayments {
+		expireUserAmount := big.NewInt(0)
+		tokenAddress := expiredPayment.PaymentInfo.Token
+		if refundTransfers[i] != nil {
+			expireUserAmount = refundTransfers[i].Amount
+			tokenAddress = refundTransfers[i].TokenAddress
+		} else if expiredPayment.PaymentInfo.LockedFee.Sign() > 0 {
+			logs.GetLogger().Error("refund transfer of payload_cid:", expiredPayment.PayloadCid, " not found in tx:", txHash)
+		}
+
+		eventExpirePayment := &models.EventExpirePayment{
+			TxHash:           txHash,
+			PayloadCid:       expiredPayment.PayloadCid,
+			BlockNo:          txReceipt.BlockNumber.String(),
+			TokenAddress:     tokenAddress.Hex(),
+			ContractAddress:  config.GetConfig().Polygon.PaymentContractAddress,
+			UserAddress:      expiredPayment.PaymentInfo.Owner.Hex(),
+			ExpireUserAmount: expireUserAmount.String(),
+			BlockTime:        blockTime,
+			CreateAt:         strconv.FormatInt(utils.GetCurrentUtcMilliSecond(), 10),
+		}
+
+		coin, err := models.FindCoinByCoinAddress(tokenAddress.Hex())
+		if err != nil {
+			logs.GetLogger().Error(err)
+		} else {
+			eventExpirePayment.CoinId = coin.ID
+			eventExpirePayment.NetworkId = coin.NetworkId
+		}
+
+		err = database.SaveOne(eventExpirePayment)
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+
+		for _, srcFile := range expiredPayment.SrcFiles {
+			err = models.UpdateSourceFileRefundStatus(srcFile.ID, constants.PROCESS_STATUS_EXPIRE_REFUNDED, txHash)
+			if err != nil {
+				logs.GetLogger().Error(err)
+				continue
+			}
+
+			err = models.TransitFileStatus(srcFile.ID, constants.FILE_STATUS_EXPIRED, "expired payment of payload_cid:"+expiredPayment.PayloadCid+" refunded, tx hash:"+txHash)
+			if err != nil {
+				logs.GetLogger().Error(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// setExpiredPaymentsRefundFailed keeps the source files to be refunded again by the next run
+func setExpiredPaymentsRefundFailed(expiredPayments []*expiredPayment, txHash string) {
+	for _, expiredPayment := range expiredPayments {
+		for _, srcFile := range expiredPayment.SrcFiles {
+			err := models.UpdateSourceFileRefundStatus(srcFile.ID, constants.PROCESS_STATUS_EXPIRE_REFUNDFAILED, txHash)
+			if err != nil {
+				logs.GetLogger().Error(err)
+			}
+		}
+	}
+}
+
+// getActiveMarketDealId returns the first deal of the payload cid active on chain, a deal is active once its sector started,
+// a deal not in the market state was never activated before its start epoch, it returns 0 when none is active
+func getActiveMarketDealId(payloadCid string) (int64, error) {
+	offlineDeals, err := models.GetOfflineDealsByPayloadCid(payloadCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return 0, err
+	}
+
+	for _, offlineDeal := range offlineDeals {
+		marketDeal, err := utils.GetMarketDeal(offlineDeal.DealId)
+		if errors.Is(err, utils.ErrMarketDealNotFound) {
+			continue
+		}
+
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return 0, err
+		}
+
+		if marketDeal.State.SectorStartEpoch > 0 {
+			return offlineDeal.DealId, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// refundedPayment is a payment in the cid list of a refund tx, with its owner and locked fee before the refund
+type refundedPayment struct {
+	Owner     common.Address
+	LockedFee *big.Int
+}
+
+// matchRefundTransfers pairs the transfers of a refund tx with the payments in the order of its cid list,
+// the payments with no locked fee are skipped since nothing is transferred for them,
+// the transfer of a payment is nil when it has no locked fee or its transfer is not found
+func matchRefundTransfers(refundedPayments []*refundedPayment, refundTransfers []*client.RefundTransfer) []*client.RefundTransfer {
+	matchedTransfers := make([]*client.RefundTransfer, len(refundedPayments))
+	transferIndex := 0
+	for i, refundedPayment := range refundedPayments {
+		if refundedPayment.LockedFee == nil || refundedPayment.LockedFee.Sign() <= 0 {
+			continue
+		}
+
+		if transferIndex >= len(refundTransfers) || refundTransfers[transferIndex].Owner != refundedPayment.Owner {
+			continue
+		}
+
+		matchedTransfers[i] = refundTransfers[transferIndex]
+		transferIndex++
+	}
+
+	return matchedTransfers
+}
@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"math/big"
+	"multi-chain-storage/on-chain/client"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestMatchRefundTransfers(t *testing.T) {
+	owner1 := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	owner2 := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	newRefundedPayment := func(owner common.Address, lockedFee int64) *refundedPayment {
+		return &refundedPayment{Owner: owner, LockedFee: big.NewInt(lockedFee)}
+	}
+	newRefundTransfer := func(owner common.Address, amount int64) *client.RefundTransfer {
+		return &client.RefundTransfer{Owner: owner, Amount: big.NewInt(amount)}
+	}
+
+	tests := []struct {
+		name             string
+		refundedPayments []*refundedPayment
+		refundTransfers  []*client.RefundTransfer
+		amounts          []int64
+	}{
+		{
+			"transfers in order",
+			[]*refundedPayment{newRefundedPayment(owner1, 100), newRefundedPayment(owner2, 200)},
+			[]*client.RefundTransfer{newRefundTransfer(owner1, 100), newRefundTransfer(owner2, 200)},
+			[]int64{100, 200},
+		},
+		{
+			"payment with no locked fee skipped",
+			[]*refundedPayment{newRefundedPayment(owner1, 0), newRefundedPayment(owner2, 200)},
+			[]*client.RefundTransfer{newRefundTransfer(owner2, 200)},
+			[]int64{-1, 200},
+		},
+		{
+			"same owner of more payments",
+			[]*refundedPayment{newRefundedPayment(owner1, 100), newRefundedPayment(owner1, 0), newRefundedPayment(owner1, 300)},
+			[]*client.RefundTransfer{newRefundTransfer(owner1, 100), newRefundTransfer(owner1, 300)},
+			[]int64{100, -1, 300},
+		},
+		{
+			"transfer of another owner",
+			[]*refundedPayment{newRefundedPayment(owner1, 100), newRefundedPayment(owner2, 200)},
+			[]*client.RefundTransfer{newRefundTransfer(owner2, 200)},
+			[]int64{-1, 200},
+		},
+		{
+			"fewer transfers than payments",
+			[]*refundedPayment{newRefundedPayment(owner1, 100), newRefundedPayment(owner2, 200)},
+			[]*client.RefundTransfer{newRefundTransfer(owner1, 100)},
+			[]int64{100, -1},
+		},
+		{
+			"no transfer",
+			[]*refundedPayment{newRefundedPayment(owner1, 100)},
+			nil,
+			[]int64{-1},
+		},
+	}
+
+	for _, test := range tests {
+		matchedTransfers := matchRefundTransfers(test.refundedPayments, test.refundTransfers)
+		if len(matchedTransfers) != len(test.refundedPayments) {
+			t.Errorf("%s: %d transfers matched for %d payments", test.name, len(matchedTransfers), len(test.refundedPayments))
+			continue
+		}
+
+		// -1 is for the payments with no transfer matched
+		for i, amount := range test.amounts {
+			if amount < 0 {
+				if matchedTransfers[i] != nil {
+					t.Errorf("%s: payment %d matched a transfer of %s", test.name, i, matchedTransfers[i].Amount.String())
+				}
+				continue
+			}
+
+			if matchedTransfers[i] == nil || matchedTransfers[i].Amount.Int64() != amount {
+				t.Errorf("%s: payment %d not matched to the transfer of %d", test.name, i, amount)
+			}
+		}
+	}
+}
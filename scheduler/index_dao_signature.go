@@ -0,0 +1,318 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+	"multi-chain-storage/on-chain/goBind"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+)
+
+const FILSWAN_ORACLE_METHOD_SIGN_CAR_TRANSACTION = "signCarTransaction"
+
+func CreateScheduler4IndexDaoSignature() {
+	c := cron.New()
+	name := "index dao signature"
+	rule := config.GetConfig().ScheduleRule.IndexDaoSignatureRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := IndexDaoSignatures()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
+
+// daoSignatureIndexer reads dao signatures from the dao contract instead of trusting the tx hashes reported by dao members
+type daoSignatureIndexer struct {
+	ethClient       *ethclient.Client
+	oracleSession   *goBind.FilswanOracleSession
+	oracleAbi       abi.ABI
+	oracleAddress   common.Address
+	recipient       common.Address
+	threshold       uint8
+	daoAddresses    map[string]bool
+	chainId         *big.Int
+	filecoinNetwork string
+}
+
+func newDaoSignatureIndexer(ethClient *ethclient.Client) (*daoSignatureIndexer, error) {
+	oracleSession, err := client.GetFilswanOracleSession(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	oracleAbi, err := abi.JSON(strings.NewReader(goBind.FilswanOracleABI))
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	threshold, err := oracleSession.GetThreshold()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	chainId, err := ethClient.ChainID(context.Background())
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	daoInfos, err := models.GetDaoInfos()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	daoAddresses := map[string]bool{}
+	for _, daoInfo := range daoInfos {
+		daoAddresses[strings.ToLower(daoInfo.DaoAddress)] = true
+	}
+
+	indexer := &daoSignatureIndexer{
+		ethClient:       ethClient,
+		oracleSession:   oracleSession,
+		oracleAbi:       oracleAbi,
+		oracleAddress:   common.HexToAddress(config.GetConfig().Polygon.DaoContractAddress),
+		recipient:       common.HexToAddress(config.GetConfig().Polygon.McsPaymentReceiverAddress),
+		threshold:       threshold,
+		daoAddresses:    daoAddresses,
+		chainId:         chainId,
+		filecoinNetwork: config.GetConfig().FilecoinNetwork,
+	}
+
+	return indexer, nil
+}
+
+func IndexDaoSignatures() error {
+	deals, err := models.GetDealsToIndexDaoSignatures()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if len(deals) == 0 {
+		logs.GetLogger().Info("no deal waiting for dao signatures")
+		return nil
+	}
+
+	ethClient, _, err := client.GetEthClient()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+	defer ethClient.Close()
+
+	indexer, err := newDaoSignatureIndexer(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	for _, deal := range deals {
+		_, _, err := indexer.indexDeal(deal.DealId, deal.PayloadCid)
+		if err != nil {
+			logs.GetLogger().Error("deal id:", deal.DealId, ", ", err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// IndexDaoSignaturesOfDeal indexes the dao signatures of one deal at once,
+// it returns whether the votes reached the threshold of the dao contract and the number of valid signatures
+func IndexDaoSignaturesOfDeal(dealId int64, payloadCid string) (bool, int, error) {
+	ethClient, _, err := client.GetEthClient()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, 0, err
+	}
+	defer ethClient.Close()
+
+	indexer, err := newDaoSignatureIndexer(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, 0, err
+	}
+
+	return indexer.indexDeal(dealId, payloadCid)
+}
+
+func (indexer *daoSignatureIndexer) indexDeal(dealId int64, payloadCid string) (bool, int, error) {
+	dealIdStr := strconv.FormatInt(dealId, 10)
+	daoSignatures, err := indexer.oracleSession.GetSignatureList(dealIdStr, indexer.filecoinNetwork)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, 0, err
+	}
+
+	validCount := 0
+	for _, daoSignature := range daoSignatures {
+		if !daoSignature.Flag {
+			continue
+		}
+
+		valid, err := indexer.saveDaoSignature(dealId, payloadCid, daoSignature)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return false, 0, err
+		}
+
+		if valid {
+			validCount++
+		}
+	}
+
+	votes, err := indexer.oracleSession.GetCarPaymentVotes(dealIdStr, indexer.filecoinNetwork, indexer.recipient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, 0, err
+	}
+
+	logs.GetLogger().Info("deal id:", dealId, ", valid dao signatures:", validCount, ", votes:", votes, ", threshold:", indexer.threshold)
+	if votes < indexer.threshold {
+		return false, validCount, nil
+	}
+
+	daoFetchedDeal, err := models.GetDaoFetchedDealByDealId(dealId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, 0, err
+	}
+
+	if daoFetchedDeal == nil {
+		daoFetchedDeal = &models.DaoFetchedDeal{
+			DealId:   dealId,
+			CreateAt: utils.GetCurrentUtcMilliSecond(),
+		}
+		err = database.SaveOne(daoFetchedDeal)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return false, 0, err
+		}
+	}
+
+	return true, validCount, nil
+}
+
+// signatures by addresses not in dao_info are saved as failed, so that they are not counted when unlocking
+func (indexer *daoSignatureIndexer) saveDaoSignature(dealId int64, payloadCid string, daoSignature goBind.FilswanOracleTxOracleInfo) (bool, error) {
+	eventDaoSignature, err := models.GetEventDaoSignatureByDealIdDaoAddress(dealId, daoSignature.Signer.Hex())
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	if eventDaoSignature == nil {
+		eventDaoSignature = &models.EventDaoSignature{
+			DealId:     dealId,
+			DaoAddress: daoSignature.Signer.Hex(),
+		}
+	}
+
+	valid := indexer.daoAddresses[strings.ToLower(daoSignature.Signer.Hex())]
+	if !valid {
+		logs.GetLogger().Error("deal id:", dealId, ", signer:", daoSignature.Signer.Hex(), " is not in dao_info")
+	}
+
+	eventDaoSignature.PayloadCid = payloadCid
+	eventDaoSignature.Recipient = daoSignature.Recipient.Hex()
+	eventDaoSignature.BlockNo = daoSignature.BlockNumber.Uint64()
+	eventDaoSignature.BlockTime = daoSignature.Timestamp.String()
+	eventDaoSignature.DaoPassTime = daoSignature.Timestamp.String()
+	eventDaoSignature.Status = true
+	eventDaoSignature.SignatureUnlockStatus = constants.SIGNATURE_FAILED_VALUE
+	if valid {
+		eventDaoSignature.SignatureUnlockStatus = constants.SIGNATURE_SUCCESS_VALUE
+	}
+
+	if eventDaoSignature.TxHash == "" {
+		txHash, err := indexer.findSignTxHash(dealId, daoSignature)
+		if err != nil {
+			logs.GetLogger().Error(err)
+		} else {
+			eventDaoSignature.TxHash = txHash
+		}
+	}
+
+	err = database.SaveOne(eventDaoSignature)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	return valid, nil
+}
+
+// the dao contract records the block of each signature but emits no event, so the transaction is looked up in that block
+func (indexer *daoSignatureIndexer) findSignTxHash(dealId int64, daoSignature goBind.FilswanOracleTxOracleInfo) (string, error) {
+	block, err := indexer.ethClient.BlockByNumber(context.Background(), daoSignature.BlockNumber)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return "", err
+	}
+
+	method := indexer.oracleAbi.Methods[FILSWAN_ORACLE_METHOD_SIGN_CAR_TRANSACTION]
+	signer := types.LatestSignerForChainID(indexer.chainId)
+	dealIdStr := strconv.FormatInt(dealId, 10)
+	for _, tx := range block.Transactions() {
+		if tx.To() == nil || *tx.To() != indexer.oracleAddress {
+			continue
+		}
+
+		data := tx.Data()
+		if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+			continue
+		}
+
+		from, err := types.Sender(signer, tx)
+		if err != nil || from != daoSignature.Signer {
+			continue
+		}
+
+		args, err := method.Inputs.UnpackValues(data[4:])
+		if err != nil || len(args) < 2 {
+			continue
+		}
+
+		if txDealId, ok := args[1].(string); ok && txDealId == dealIdStr {
+			return tx.Hash().Hex(), nil
+		}
+	}
+
+	err = fmt.Errorf("no %s tx of deal:%d from %s found in block:%s", FILSWAN_ORACLE_METHOD_SIGN_CAR_TRANSACTION, dealId, daoSignature.Signer.Hex(), daoSignature.BlockNumber.String())
+	return "", err
+}
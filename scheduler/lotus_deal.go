@@ -0,0 +1,175 @@
+package scheduler
+
+import (
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+
+	"github.com/filswan/go-swan-client/command"
+	"github.com/filswan/go-swan-lib/client/lotus"
+	libconstants "github.com/filswan/go-swan-lib/constants"
+	"github.com/filswan/go-swan-lib/logs"
+	libmodel "github.com/filswan/go-swan-lib/model"
+	libutils "github.com/filswan/go-swan-lib/utils"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// without swan platform, a task is only a local uuid to identify the car file in the following steps
+func createLotusTask(carDir string) (*libmodel.FileDesc, error) {
+	fileDescs, err := command.ReadFileDescsFromJsonFile(carDir, command.JSON_FILE_NAME_CAR_UPLOAD)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(fileDescs) == 0 {
+		err := fmt.Errorf("no car file read from:%s", carDir)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	fileDesc := fileDescs[0]
+	fileDesc.Uuid = uuid.NewString()
+
+	_, err = command.WriteFileDescsToJsonFile(fileDescs, carDir, command.JSON_FILE_NAME_CAR_UPLOAD)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	logs.GetLogger().Info("lotus task:", fileDesc.Uuid, " created in ", carDir, ", payload_cid=", fileDesc.PayloadCid)
+
+	return fileDesc, nil
+}
+
+func sendLotusDeals(dealFile *models.DealFile) ([]*libmodel.FileDesc, error) {
+	lotusClient, err := lotus.LotusGetClient(config.GetConfig().Lotus.ClientApiUrl, config.GetConfig().Lotus.ClientAccessToken)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	currentEpoch, err := lotusClient.LotusGetCurrentEpoch()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	dealConfig := libmodel.DealConfig{
+		VerifiedDeal:     config.GetConfig().SwanTask.VerifiedDeal,
+		FastRetrieval:    config.GetConfig().SwanTask.FastRetrieval,
+		SkipConfirmation: true,
+		MaxPrice:         dealFile.MaxPrice,
+		StartEpoch:       *currentEpoch + int64((config.GetConfig().SwanTask.StartEpochHours+1)*libconstants.EPOCH_PER_HOUR),
+		SenderWallet:     config.GetConfig().SwanPlatformFilWallet,
+		Duration:         constants.DURATION_DAYS_DEFAULT * constants.EPOCH_PER_DAY,
+		TransferType:     libconstants.LOTUS_TRANSFER_TYPE_MANUAL,
+		PayloadCid:       dealFile.PayloadCid,
+		PieceCid:         dealFile.PieceCid,
+		FileSize:         dealFile.CarFileSize,
+	}
+
+	pieceSize, _ := libutils.CalculatePieceSize(dealFile.CarFileSize)
+	copyNumber := config.GetConfig().SwanTask.MaxAutoBidCopyNumber
+	deals := []*libmodel.DealInfo{}
+	for _, minerFid := range config.GetConfig().Lotus.MinerFids {
+		if len(deals) >= copyNumber {
+			break
+		}
+
+		if !isMinerAllowed(minerFid) {
+			logs.GetLogger().Info("miner:", minerFid, " is not allowed by miner policy")
+			continue
+		}
+
+		dealProposal := &models.DealProposal{
+			DealFileId: dealFile.ID,
+			MinerFid:   minerFid,
+			StartEpoch: dealConfig.StartEpoch,
+		}
+
+		minerConfig, err := lotusClient.LotusClientQueryAsk(minerFid)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			saveDealProposal(dealProposal, constants.DEAL_PROPOSAL_STATUS_FAILED, err.Error())
+			continue
+		}
+
+		dealProposal.AskPrice = minerConfig.Price
+		if dealConfig.VerifiedDeal {
+			dealProposal.AskPrice = minerConfig.VerifiedPrice
+		}
+		dealProposal.AskPrice = dealProposal.AskPrice.Div(decimal.NewFromFloat(constants.LOTUS_PRICE_MULTIPLE_1E18))
+
+		if dealProposal.AskPrice.Cmp(dealFile.MaxPrice) > 0 {
+			note := fmt.Sprintf("miner price:%s > deal max price:%s", dealProposal.AskPrice.String(), dealFile.MaxPrice.String())
+			logs.GetLogger().Info(note)
+			saveDealProposal(dealProposal, constants.DEAL_PROPOSAL_STATUS_REJECTED, note)
+			continue
+		}
+
+		if pieceSize < minerConfig.MinPieceSize || pieceSize > minerConfig.MaxPieceSize {
+			note := fmt.Sprintf("piece size:%d out of miner range:[%d,%d]", pieceSize, minerConfig.MinPieceSize, minerConfig.MaxPieceSize)
+			logs.GetLogger().Info(note)
+			saveDealProposal(dealProposal, constants.DEAL_PROPOSAL_STATUS_REJECTED, note)
+			continue
+		}
+
+		setDealProposalTransfer(dealProposal, dealFile)
+
+		dealConfig.MinerFid = minerFid
+		dealCid, err := lotusClient.LotusClientStartDeal(&dealConfig)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			saveDealProposal(dealProposal, constants.DEAL_PROPOSAL_STATUS_FAILED, err.Error())
+			continue
+		}
+
+		dealProposal.DealCid = *dealCid
+		saveDealProposal(dealProposal, constants.DEAL_PROPOSAL_STATUS_PROPOSED, "")
+
+		deals = append(deals, &libmodel.DealInfo{
+			MinerFid:   minerFid,
+			DealCid:    *dealCid,
+			StartEpoch: int(dealConfig.StartEpoch),
+		})
+		logs.GetLogger().Info("deal sent, task:", dealFile.TaskUuid, ", deal CID:", *dealCid, ", miner:", minerFid)
+	}
+
+	if len(deals) == 0 {
+		err := fmt.Errorf("no deal has been accepted for task:%s by miners:%v", dealFile.TaskUuid, config.GetConfig().Lotus.MinerFids)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	fileDescs := []*libmodel.FileDesc{
+		{
+			Uuid:        dealFile.TaskUuid,
+			CarFileName: dealFile.CarFileName,
+			CarFilePath: dealFile.CarFilePath,
+			CarFileSize: dealFile.CarFileSize,
+			PayloadCid:  dealFile.PayloadCid,
+			PieceCid:    dealFile.PieceCid,
+			Deals:       deals,
+		},
+	}
+
+	return fileDescs, nil
+}
+
+func saveDealProposal(dealProposal *models.DealProposal, status, note string) {
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	dealProposal.Status = status
+	dealProposal.Note = note
+	dealProposal.CreateAt = currentUtcMilliSec
+	dealProposal.UpdateAt = currentUtcMilliSec
+
+	err := database.SaveOne(dealProposal)
+	if err != nil {
+		logs.GetLogger().Error(err)
+	}
+}
@@ -0,0 +1,252 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+	"multi-chain-storage/on-chain/goBind"
+	"strings"
+	"sync"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+	"github.com/shopspring/decimal"
+)
+
+func CreateScheduler4ReconcileLedger() {
+	c := cron.New()
+	name := "reconcile ledger"
+	rule := config.GetConfig().ScheduleRule.ReconcileLedgerRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := ReconcileLedger()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
+
+// ReconcileLedger compares the lock payments in database with the payment contract for the source files not refunded or expired,
+// the fields not the same are saved as discrepancies to be reviewed, and the counts of the run are saved as its summary
+func ReconcileLedger() error {
+	reconciliationRun := &models.ReconciliationRun{
+		StartAt: utils.GetCurrentUtcMilliSecond(),
+	}
+
+	srcFiles, err := models.GetSourceFilesToReconcile()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	swanPaymentSession, err := client.GetSwanPaymentSession()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	fieldCounts := map[string]int64{}
+	for _, srcFile := range srcFiles {
+		ledgerDiscrepancies, err := reconcileSourceFile(swanPaymentSession, srcFile)
+		if err != nil {
+			logs.GetLogger().Error("source file id:", srcFile.ID, ", ", err)
+			reconciliationRun.FailedCount++
+			continue
+		}
+		reconciliationRun.CheckedCount++
+
+		openFields := []string{}
+		for _, ledgerDiscrepancy := range ledgerDiscrepancies {
+			isNew, err := models.SaveLedgerDiscrepancy(ledgerDiscrepancy)
+			if err != nil {
+				logs.GetLogger().Error(err)
+				continue
+			}
+
+			if isNew {
+				reconciliationRun.NewDiscrepancyCount++
+			}
+			reconciliationRun.DiscrepancyCount++
+			fieldCounts[ledgerDiscrepancy.Field]++
+			openFields = append(openFields, ledgerDiscrepancy.Field)
+		}
+
+		resolvedCount, err := models.ResolveLedgerDiscrepancies(srcFile.ID, openFields)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+		reconciliationRun.ResolvedCount = reconciliationRun.ResolvedCount + resolvedCount
+	}
+
+	summary, err := json.Marshal(fieldCounts)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	reconciliationRun.Summary = string(summary)
+	reconciliationRun.EndAt = utils.GetCurrentUtcMilliSecond()
+	err = database.SaveOne(reconciliationRun)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	logs.GetLogger().Info("reconciled ", reconciliationRun.CheckedCount, " source files, failed:", reconciliationRun.FailedCount,
+		", discrepancies:", reconciliationRun.DiscrepancyCount, ", new:", reconciliationRun.NewDiscrepancyCount,
+		", resolved:", reconciliationRun.ResolvedCount, ", by field:", reconciliationRun.Summary)
+	return nil
+}
+
+// reconcileSourceFile returns the fields of the source file in database not the same as the payment contract,
+// the locked fee is compared with the one expected on chain after the unlocks and refunds of the payment
+func reconcileSourceFile(swanPaymentSession *goBind.SwanPaymentSession, srcFile *models.SourceFile) ([]*models.LedgerDiscrepancy, error) {
+	paymentInfo, err := swanPaymentSession.GetLockedPaymentInfo(srcFile.PayloadCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	lockPayments, err := models.GetEventLockPaymentByPayloadCid(srcFile.PayloadCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	ledgerDiscrepancies := []*models.LedgerDiscrepancy{}
+	addDiscrepancy := func(field string, recordId int64, dbValue, chainValue string) {
+		ledgerDiscrepancies = append(ledgerDiscrepancies, &models.LedgerDiscrepancy{
+			SourceFileId: srcFile.ID,
+			PayloadCid:   srcFile.PayloadCid,
+			Field:        field,
+			RecordId:     recordId,
+			DbValue:      dbValue,
+			ChainValue:   chainValue,
+		})
+	}
+
+	if !paymentInfo.IsExisted {
+		if len(lockPayments) > 0 {
+			addDiscrepancy(constants.LEDGER_FIELD_LOCK_PAYMENT, lockPayments[0].ID, constants.LEDGER_VALUE_EXISTS, constants.LEDGER_VALUE_NOT_EXISTS)
+		}
+		return ledgerDiscrepancies, nil
+	}
+
+	if len(lockPayments) == 0 {
+		addDiscrepancy(constants.LEDGER_FIELD_LOCK_PAYMENT, 0, constants.LEDGER_VALUE_NOT_EXISTS, constants.LEDGER_VALUE_EXISTS)
+		return ledgerDiscrepancies, nil
+	}
+
+	lockPayment := lockPayments[0]
+	if !strings.EqualFold(lockPayment.AddressFrom, paymentInfo.Owner.Hex()) {
+		addDiscrepancy(constants.LEDGER_FIELD_OWNER, lockPayment.ID, lockPayment.AddressFrom, paymentInfo.Owner.Hex())
+	}
+
+	if !strings.EqualFold(lockPayment.AddressTo, paymentInfo.Recipient.Hex()) {
+		addDiscrepancy(constants.LEDGER_FIELD_RECIPIENT, lockPayment.ID, lockPayment.AddressTo, paymentInfo.Recipient.Hex())
+	}
+
+	if !strings.EqualFold(lockPayment.TokenAddress, paymentInfo.Token.Hex()) {
+		addDiscrepancy(constants.LEDGER_FIELD_TOKEN_ADDRESS, lockPayment.ID, lockPayment.TokenAddress, paymentInfo.Token.Hex())
+	}
+
+	if lockPayment.Deadline != paymentInfo.Deadline.String() {
+		addDiscrepancy(constants.LEDGER_FIELD_DEADLINE, lockPayment.ID, lockPayment.Deadline, paymentInfo.Deadline.String())
+	}
+
+	unlockPayment, err := models.GetLatestUnlockedEventUnlockPaymentByPayloadCid(srcFile.PayloadCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	ledgerBalance, err := models.GetLatestLedgerBalanceByPayloadCid(srcFile.PayloadCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	isRefunded, err := models.IsPaymentRefundedByPayloadCid(srcFile.PayloadCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	expectedLockedFee := getExpectedLockedFee(lockPayment, unlockPayment, ledgerBalance, isRefunded)
+	lockedFee := decimal.NewFromBigInt(paymentInfo.LockedFee, 0)
+	if !expectedLockedFee.Equal(lockedFee) {
+		addDiscrepancy(constants.LEDGER_FIELD_LOCKED_FEE, lockPayment.ID, expectedLockedFee.String(), lockedFee.String())
+	}
+
+	if srcFile.Status == constants.SOURCE_FILE_STATUS_CREATED {
+		addDiscrepancy(constants.LEDGER_FIELD_SOURCE_FILE_STATUS, srcFile.ID, srcFile.Status, constants.SOURCE_FILE_STATUS_PAID)
+	}
+
+	return ledgerDiscrepancies, nil
+}
+
+// getExpectedLockedFee returns the locked fee expected on chain, none is left once the payment is refunded or refunded after expired,
+// otherwise it is the later one of the locked fee after the last unlock and the one set by an operator, or the locked fee when there is neither
+func getExpectedLockedFee(lockPayment *models.EventLockPayment, unlockPayment *models.EventUnlockPayment, ledgerBalance *models.LedgerBalance, isRefunded bool) decimal.Decimal {
+	if isRefunded {
+		return decimal.Zero
+	}
+
+	if ledgerBalance != nil && (unlockPayment == nil || ledgerBalance.CreateAt >= unlockPayment.UpdateAt) {
+		return ledgerBalance.LockedFee
+	}
+
+	if unlockPayment != nil {
+		return unlockPayment.LockedFeeAfterUnlock
+	}
+
+	return lockPayment.LockedFee
+}
+
+// IsLedgerDiscrepancyOpen checks the source file against the payment contract again,
+// it returns whether the discrepancy is still there with the same value on chain
+func IsLedgerDiscrepancyOpen(ledgerDiscrepancy *models.LedgerDiscrepancy) (bool, error) {
+	srcFile, err := models.GetSourceFileById(ledgerDiscrepancy.SourceFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	swanPaymentSession, err := client.GetSwanPaymentSession()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	ledgerDiscrepancies, err := reconcileSourceFile(swanPaymentSession, srcFile)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return false, err
+	}
+
+	for _, discrepancy := range ledgerDiscrepancies {
+		if discrepancy.Field == ledgerDiscrepancy.Field && discrepancy.RecordId == ledgerDiscrepancy.RecordId && discrepancy.ChainValue == ledgerDiscrepancy.ChainValue {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
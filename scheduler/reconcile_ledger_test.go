@@ -0,0 +1,38 @@
+package scheduler
+
+import (
+	"multi-chain-storage/models"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGetExpectedLockedFee(t *testing.T) {
+	lockPayment := &models.EventLockPayment{LockedFee: decimal.NewFromInt(1000)}
+	unlockPayment := &models.EventUnlockPayment{LockedFeeAfterUnlock: decimal.NewFromInt(600), UpdateAt: 2000}
+	balanceBeforeUnlock := &models.LedgerBalance{LockedFee: decimal.NewFromInt(900), CreateAt: 1000}
+	balanceAfterUnlock := &models.LedgerBalance{LockedFee: decimal.NewFromInt(500), CreateAt: 3000}
+
+	tests := []struct {
+		name          string
+		unlockPayment *models.EventUnlockPayment
+		ledgerBalance *models.LedgerBalance
+		isRefunded    bool
+		lockedFee     int64
+	}{
+		{"locked", nil, nil, false, 1000},
+		{"unlocked", unlockPayment, nil, false, 600},
+		{"balance set", nil, balanceBeforeUnlock, false, 900},
+		{"unlocked after balance set", unlockPayment, balanceBeforeUnlock, false, 600},
+		{"balance set after unlocked", unlockPayment, balanceAfterUnlock, false, 500},
+		{"refunded", nil, nil, true, 0},
+		{"refunded after unlocked and balance set", unlockPayment, balanceAfterUnlock, true, 0},
+	}
+
+	for _, test := range tests {
+		lockedFee := getExpectedLockedFee(lockPayment, test.unlockPayment, test.ledgerBalance, test.isRefunded)
+		if !lockedFee.Equal(decimal.NewFromInt(test.lockedFee)) {
+			t.Errorf("%s: locked fee is %s, expected %d", test.name, lockedFee, test.lockedFee)
+		}
+	}
+}
@@ -1,17 +1,23 @@
 package scheduler
 
 import (
+	"errors"
 	"fmt"
 	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
 	"multi-chain-storage/config"
+	"multi-chain-storage/database"
 	"multi-chain-storage/models"
 	"multi-chain-storage/on-chain/client"
 	"multi-chain-storage/on-chain/goBind"
+	"strconv"
 	"sync"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/filswan/go-swan-lib/logs"
 	"github.com/robfig/cron"
+	"github.com/shopspring/decimal"
 )
 
 func CreateScheduler4Refund() {
@@ -62,7 +68,11 @@ func Refund() error {
 	}
 
 	for _, dealFile := range dealFiles {
-		err = refund(ethClient, dealFile.ID, swanPaymentTransactor)
+		err = refund(ethClient, dealFile, swanPaymentTransactor)
+		if errors.Is(err, errTxJobPaused) {
+			return err
+		}
+
 		if err != nil {
 			logs.GetLogger().Error(err)
 			continue
@@ -72,15 +82,39 @@ func Refund() error {
 	return nil
 }
 
-func refund(ethClient *ethclient.Client, dealFileId int64, swanPaymentTransactor *goBind.SwanPaymentTransactor) error {
-	offlineDealsNotUnlocked, err := models.GetOfflineDealsNotUnlockedByDealFileId(dealFileId)
+// refund returns the payment remaining of the source files in the deal file once all its active deals are unlocked,
+// deals not active are waited for until the deal sending window and expire days passed, then they are settled and not unlocked any more
+func refund(ethClient *ethclient.Client, dealFile *models.DealFile, swanPaymentTransactor *goBind.SwanPaymentTransactor) error {
+	dealFileId := dealFile.ID
+	offlineDeals, err := models.GetOfflineDealsByDealFileId(dealFileId)
 	if err != nil {
 		logs.GetLogger().Error(err.Error())
 		return err
 	}
 
-	if len(offlineDealsNotUnlocked) > 0 {
-		msg := fmt.Sprintf("%d deals not unlocked or unlock failed, cannot refund for the deal file", len(offlineDealsNotUnlocked))
+	activeReplicas := 0
+	offlineDealsNotActive := []*models.OfflineDeal{}
+	for _, offlineDeal := range offlineDeals {
+		isActive := offlineDeal.Status == constants.DEAL_STATUS_ACTIVE || offlineDeal.ActiveAt != nil
+		if isActive {
+			activeReplicas++
+		}
+
+		if offlineDeal.UnlockStatus != constants.OFFLINE_DEAL_UNLOCK_STATUS_NOT_UNLOCKED && offlineDeal.UnlockStatus != constants.OFFLINE_DEAL_UNLOCK_STATUS_UNLOCK_FAILED {
+			continue
+		}
+
+		if isActive {
+			msg := fmt.Sprintf("active deal:%d not unlocked or unlock failed, cannot refund for the deal file:%d", offlineDeal.DealId, dealFileId)
+			logs.GetLogger().Info(msg)
+			return nil
+		}
+
+		offlineDealsNotActive = append(offlineDealsNotActive, offlineDeal)
+	}
+
+	if len(offlineDealsNotActive) > 0 && !isDealSendWindowSettled(dealFile) {
+		msg := fmt.Sprintf("%d deals not active yet, cannot refund for the deal file:%d", len(offlineDealsNotActive), dealFileId)
 		logs.GetLogger().Info(msg)
 		return nil
 	}
@@ -92,6 +126,8 @@ func refund(ethClient *ethclient.Client, dealFileId int64, swanPaymentTransactor
 	}
 
 	var srcFilePayloadCids []string
+	lockedPayments := map[string]*client.LockedPayment{}
+	refundSettlements := []*models.RefundSettlement{}
 	for _, srcFile := range srcFiles {
 		lockedPayment, err := client.GetLockedPaymentInfo(srcFile.PayloadCid)
 		if err != nil {
@@ -99,13 +135,24 @@ func refund(ethClient *ethclient.Client, dealFileId int64, swanPaymentTransactor
 			return err
 		}
 
-		err = models.UpdateSourceFileRefundAmount(srcFile.ID, lockedPayment.LockedFee)
+		refundSettlement, err := getRefundSettlement(srcFile, dealFileId, lockedPayment, activeReplicas)
 		if err != nil {
 			logs.GetLogger().Error(err.Error())
 			return err
 		}
 
-		srcFilePayloadCids = append(srcFilePayloadCids, srcFile.PayloadCid)
+		// a payload cid is refunded once, its locked fee is transferred back on the first of its source files
+		if _, ok := lockedPayments[srcFile.PayloadCid]; !ok {
+			srcFilePayloadCids = append(srcFilePayloadCids, srcFile.PayloadCid)
+			lockedPayments[srcFile.PayloadCid] = lockedPayment
+		}
+		refundSettlements = append(refundSettlements, refundSettlement)
+	}
+
+	err = checkTxJobAllowed(ethClient, constants.TX_JOB_REFUND)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
 	}
 
 	privateKey, publicKeyAddress, err := client.GetPrivateKeyPublicKey(constants.PRIVATE_KEY_ON_POLYGON)
@@ -120,29 +167,106 @@ func refund(ethClient *ethclient.Client, dealFileId int64, swanPaymentTransactor
 		return err
 	}
 
-	refundStatus := constants.PROCESS_STATUS_UNLOCK_REFUNDED
+	reason := "all deals of deal file:" + strconv.FormatInt(dealFileId, 10) + " unlocked"
+	if len(offlineDealsNotActive) > 0 {
+		reason = fmt.Sprintf("%d active deals of deal file:%d unlocked, %d deals not active are settled", activeReplicas, dealFileId, len(offlineDealsNotActive))
+	}
+
+	for _, srcFile := range srcFiles {
+		err = models.TransitFileStatus(srcFile.ID, constants.FILE_STATUS_REFUNDING, reason)
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+	}
+
 	tx, err := swanPaymentTransactor.Refund(tansactOpts, srcFilePayloadCids)
 	if err != nil {
-		refundStatus = constants.PROCESS_STATUS_UNLOCK_REFUNDFAILED
 		logs.GetLogger().Error(err.Error())
+		setRefundFailed(dealFileId, refundSettlements, "")
+		return err
 	}
 
-	for _, srcFile := range srcFiles {
-		txHash := ""
-		if tx != nil {
-			txHash = tx.Hash().Hex()
+	txHash := tx.Hash().Hex()
+	logs.GetLogger().Info("refund deal file:", dealFileId, " payload cids:", srcFilePayloadCids, " tx hash:", txHash)
+
+	txReceipt, err := client.CheckTx(ethClient, tx)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		setRefundFailed(dealFileId, refundSettlements, txHash)
+		return err
+	}
+
+	saveGasSpend(constants.TX_JOB_REFUND, tx, txReceipt)
+
+	if txReceipt.Status != uint64(1) {
+		err := fmt.Errorf("refund deal file:%d failed, tx hash:%s", dealFileId, txHash)
+		logs.GetLogger().Error(err)
+		setRefundFailed(dealFileId, refundSettlements, txHash)
+		return err
+	}
+
+	refundedPayments := []*refundedPayment{}
+	for _, payloadCid := range srcFilePayloadCids {
+		lockedPayment := lockedPayments[payloadCid]
+		refundedPayments = append(refundedPayments, &refundedPayment{
+			Owner:     common.HexToAddress(lockedPayment.AddressFrom),
+			LockedFee: lockedPayment.LockedFee.BigInt(),
+		})
+	}
+	refundTransfers := matchRefundTransfers(refundedPayments, client.GetRefundTransfers(txReceipt))
+
+	refundAmounts := map[string]decimal.Decimal{}
+	for i, payloadCid := range srcFilePayloadCids {
+		refundAmounts[payloadCid] = decimal.Zero
+		if refundTransfers[i] != nil {
+			refundAmounts[payloadCid] = decimal.NewFromBigInt(refundTransfers[i].Amount, 0)
+		} else if lockedPayments[payloadCid].LockedFee.IsPositive() {
+			logs.GetLogger().Error("refund transfer of payload_cid:", payloadCid, " not found in tx:", txHash)
+		}
+	}
+
+	for _, refundSettlement := range refundSettlements {
+		refundAmount, ok := refundAmounts[refundSettlement.PayloadCid]
+		if !ok {
+			refundAmount = decimal.Zero
+		}
+		// only the first source file of a payload cid gets its refund
+		delete(refundAmounts, refundSettlement.PayloadCid)
+
+		refundSettlement.RefundAmount = refundAmount
+		refundSettlement.RefundStatus = constants.PROCESS_STATUS_UNLOCK_REFUNDED
+		refundSettlement.TxHash = txHash
+		err = database.SaveOne(refundSettlement)
+		if err != nil {
+			logs.GetLogger().Error(err.Error())
 		}
 
-		logs.GetLogger().Info("refund stats:", refundStatus, " tx hash:", txHash)
+		err = models.UpdateSourceFileRefundAmount(refundSettlement.SourceFileId, refundAmount)
+		if err != nil {
+			logs.GetLogger().Error(err.Error())
+		}
 
-		err = models.UpdateSourceFileRefundStatus(srcFile.ID, refundStatus, txHash)
+		err = models.UpdateSourceFileRefundStatus(refundSettlement.SourceFileId, constants.PROCESS_STATUS_UNLOCK_REFUNDED, txHash)
 		if err != nil {
 			logs.GetLogger().Error(err.Error())
 			continue
 		}
+
+		err = models.TransitFileStatus(refundSettlement.SourceFileId, constants.FILE_STATUS_REFUNDED, "remaining payment refunded, tx hash:"+txHash)
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
 	}
 
-	err = models.UpdateDealFileStatus(dealFileId, refundStatus)
+	// the payments are refunded, the deals not active can not be unlocked any more
+	for _, offlineDeal := range offlineDealsNotActive {
+		err = models.UpdateOfflineDealUnlockStatus(offlineDeal.Id, constants.OFFLINE_DEAL_UNLOCK_STATUS_SETTLED, "not active when payment refunded, txHash="+txHash)
+		if err != nil {
+			logs.GetLogger().Error(err.Error())
+		}
+	}
+
+	err = models.UpdateDealFileStatus(dealFileId, constants.PROCESS_STATUS_UNLOCK_REFUNDED)
 	if err != nil {
 		logs.GetLogger().Error(err.Error())
 		return err
@@ -150,3 +274,71 @@ func refund(ethClient *ethclient.Client, dealFileId int64, swanPaymentTransactor
 
 	return nil
 }
+
+// setRefundFailed records the refund of the deal file failed with nothing refunded
+func setRefundFailed(dealFileId int64, refundSettlements []*models.RefundSettlement, txHash string) {
+	for _, refundSettlement := range refundSettlements {
+		refundSettlement.RefundAmount = decimal.Zero
+		refundSettlement.RefundStatus = constants.PROCESS_STATUS_UNLOCK_REFUNDFAILED
+		refundSettlement.TxHash = txHash
+		err := database.SaveOne(refundSettlement)
+		if err != nil {
+			logs.GetLogger().Error(err.Error())
+		}
+
+		err = models.UpdateSourceFileRefundStatus(refundSettlement.SourceFileId, constants.PROCESS_STATUS_UNLOCK_REFUNDFAILED, txHash)
+		if err != nil {
+			logs.GetLogger().Error(err.Error())
+		}
+	}
+
+	err := models.UpdateDealFileStatus(dealFileId, constants.PROCESS_STATUS_UNLOCK_REFUNDFAILED)
+	if err != nil {
+		logs.GetLogger().Error(err.Error())
+	}
+}
+
+// isDealSendWindowSettled returns whether the deals of the deal file can not be active any more,
+// deals are sent within deal_send_window_days since the deal file is created, and sealed within expire_days
+func isDealSendWindowSettled(dealFile *models.DealFile) bool {
+	dealSendWindowDays := config.GetConfig().SwanTask.DealSendWindowDays
+	if dealSendWindowDays <= 0 {
+		dealSendWindowDays = constants.DEAL_SEND_WINDOW_DAYS_DEFAULT
+	}
+
+	settleDays := int64(dealSendWindowDays + config.GetConfig().SwanTask.ExpireDays)
+	return utils.GetCurrentUtcMilliSecond()-dealFile.CreateAt > settleDays*24*60*60*1000
+}
+
+// getRefundSettlement records the replicas paid for and active and the fee locked and unlocked of the source file,
+// the refund amount is set from the Transfer of the refund tx, which returns all the locked fee remaining on chain
+func getRefundSettlement(srcFile *models.SourceFile, dealFileId int64, lockedPayment *client.LockedPayment, activeReplicas int) (*models.RefundSettlement, error) {
+	paidReplicas := lockedPayment.CopyLimit
+	if paidReplicas <= 0 {
+		paidReplicas = config.GetConfig().SwanTask.MaxAutoBidCopyNumber
+	}
+
+	lockedFee := lockedPayment.LockedFee
+	eventLockPayments, err := models.GetEventLockPaymentByPayloadCid(srcFile.PayloadCid)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	if len(eventLockPayments) > 0 {
+		lockedFee = eventLockPayments[0].LockedFee
+	}
+
+	refundSettlement := &models.RefundSettlement{
+		SourceFileId:   srcFile.ID,
+		DealFileId:     dealFileId,
+		PayloadCid:     srcFile.PayloadCid,
+		PaidReplicas:   paidReplicas,
+		ActiveReplicas: activeReplicas,
+		LockedFee:      lockedFee,
+		UnlockedFee:    lockedFee.Sub(lockedPayment.LockedFee),
+		CreateAt:       utils.GetCurrentUtcMilliSecond(),
+	}
+
+	return refundSettlement, nil
+}
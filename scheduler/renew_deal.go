@@ -0,0 +1,393 @@
+package scheduler
+
+import (
+	"fmt"
+	"math/big"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/disk"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/filswan/go-swan-client/command"
+	"github.com/filswan/go-swan-lib/client/ipfs"
+	"github.com/filswan/go-swan-lib/client/lotus"
+	"github.com/filswan/go-swan-lib/logs"
+	libmodel "github.com/filswan/go-swan-lib/model"
+	libutils "github.com/filswan/go-swan-lib/utils"
+	"github.com/jinzhu/gorm"
+	"github.com/robfig/cron"
+	"github.com/shopspring/decimal"
+)
+
+func CreateScheduler4RenewDeal() {
+	c := cron.New()
+	name := "renew deal"
+	rule := config.GetConfig().ScheduleRule.RenewDealRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := RenewDeal()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
+
+func RenewDeal() error {
+	lotusClient, err := lotus.LotusGetClient(config.GetConfig().Lotus.ClientApiUrl, config.GetConfig().Lotus.ClientAccessToken)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	currentEpoch, err := lotusClient.LotusGetCurrentEpoch()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	endEpochMax := *currentEpoch + int64(config.GetConfig().SwanTask.RenewDaysBeforeExpiry*constants.EPOCH_PER_DAY)
+	offlineDeals, err := models.GetOfflineDeals2BeRenewed(endEpochMax)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if len(offlineDeals) == 0 {
+		logs.GetLogger().Info("0 deal to be renewed")
+		return nil
+	}
+
+	offlineDealsByDealFile := map[int64][]*models.OfflineDeal{}
+	for _, offlineDeal := range offlineDeals {
+		offlineDealsByDealFile[offlineDeal.DealFileId] = append(offlineDealsByDealFile[offlineDeal.DealFileId], offlineDeal)
+	}
+
+	rate, err := client.GetWfilPriceFromSushiPrice("1")
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	for dealFileId, offlineDeals2Renew := range offlineDealsByDealFile {
+		renewalFundings, err := getRenewalFundings(dealFileId, len(offlineDeals2Renew), rate)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		if !isDealFileRenewable(renewalFundings, time.Now().Unix()) {
+			logs.GetLogger().Info("deal file:", dealFileId, " has no live lock with funds unreserved to cover the renewal, skip renewal")
+			continue
+		}
+
+		err = renewDealFile(dealFileId, offlineDeals2Renew, renewalFundings)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			err = saveDealRenewals(nil, offlineDeals2Renew, nil, constants.DEAL_RENEWAL_STATUS_FAILED, err.Error())
+			if err != nil {
+				logs.GetLogger().Error(err)
+			}
+			continue
+		}
+	}
+
+	return nil
+}
+
+type renewalFunding struct {
+	SrcFile       *models.SourceFile
+	LockedPayment *client.LockedPayment // nil when the payment of the source file is no longer locked on chain
+	ReservedFee   decimal.Decimal
+	RenewalPrice  decimal.Decimal
+}
+
+// getRenewalFundings returns the on chain lock, the fee reserved by former renewals and the renewal price
+// of the replicas at the max price of the deal file, for each source file in the deal file
+func getRenewalFundings(dealFileId int64, replicas int, rate *big.Int) ([]*renewalFunding, error) {
+	dealFile, err := models.GetDealFileById(dealFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	srcFiles, err := models.GetSourceFilesByDealFileId(dealFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	renewalFundings := []*renewalFunding{}
+	for _, srcFile := range srcFiles {
+		renewalFunding := &renewalFunding{
+			SrcFile:      srcFile,
+			RenewalPrice: utils.GetPaymentByMaxPrice(srcFile.FileSize, dealFile.MaxPrice, rate).Mul(decimal.NewFromInt(int64(replicas))),
+		}
+
+		isExisted, err := client.IsLockedPaymentExists(srcFile.PayloadCid)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		if *isExisted {
+			renewalFunding.LockedPayment, err = client.GetLockedPaymentInfo(srcFile.PayloadCid)
+			if err != nil {
+				logs.GetLogger().Error(err)
+				return nil, err
+			}
+		}
+
+		renewalFunding.ReservedFee, err = models.GetReservedRenewalFee(srcFile.PayloadCid)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return nil, err
+		}
+
+		renewalFundings = append(renewalFundings, renewalFunding)
+	}
+
+	return renewalFundings, nil
+}
+
+// isDealFileRenewable returns whether each source file in the deal file still has its payment locked on chain until after now,
+// with the locked fee not reserved by former renewals covering the renewal price, auto renew does not waive the lock,
+// since the renewed deals are unlocked from it
+func isDealFileRenewable(renewalFundings []*renewalFunding, now int64) bool {
+	if len(renewalFundings) == 0 {
+		return false
+	}
+
+	for _, renewalFunding := range renewalFundings {
+		payloadCid := renewalFunding.SrcFile.PayloadCid
+		lockedPayment := renewalFunding.LockedPayment
+		if lockedPayment == nil {
+			logs.GetLogger().Info("payload cid:", payloadCid, " has no payment locked on chain")
+			return false
+		}
+
+		deadline, err := strconv.ParseInt(lockedPayment.Deadline, 10, 64)
+		if err != nil || deadline <= now {
+			logs.GetLogger().Info("payload cid:", payloadCid, " payment locked until:", lockedPayment.Deadline, " is not live")
+			return false
+		}
+
+		unreservedFee := lockedPayment.LockedFee.Sub(renewalFunding.ReservedFee)
+		if unreservedFee.LessThan(renewalFunding.RenewalPrice) {
+			logs.GetLogger().Info("payload cid:", payloadCid, " locked fee:", lockedPayment.LockedFee, " reserved:", renewalFunding.ReservedFee,
+				" does not cover renewal price:", renewalFunding.RenewalPrice)
+			return false
+		}
+	}
+
+	return true
+}
+
+func renewDealFile(dealFileId int64, offlineDeals []*models.OfflineDeal, renewalFundings []*renewalFunding) error {
+	dealFile, err := models.GetDealFileById(dealFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = disk.CheckFreeSize(carDir, dealFile.CarFileSize)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	currentTimeStr := time.Now().Format("2006-01-02T15:04:05")
+	renewDir := filepath.Join(carDir, "renew_"+currentTimeStr)
+	err = libutils.CreateDir(renewDir)
+	if err != nil {
+		logs.GetLogger().Error("creating dir:", renewDir, " failed,", err)
+		return err
+	}
+
+	carFilePath := filepath.Join(renewDir, dealFile.CarFileName)
+	if libutils.IsFileExistsFullPath(dealFile.CarFilePath) {
+		_, err = libutils.CopyFile(dealFile.CarFilePath, carFilePath)
+	} else {
+		logs.GetLogger().Info("car file:", dealFile.CarFilePath, " not exists, exporting it from ipfs")
+		err = ipfs.Export2CarFile(config.GetConfig().IpfsServer.UploadUrlPrefix, dealFile.PayloadCid, carFilePath)
+	}
+	if err != nil {
+		os.RemoveAll(renewDir)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	fileDescs := []*libmodel.FileDesc{
+		{
+			CarFileName: dealFile.CarFileName,
+			CarFilePath: carFilePath,
+			CarFileMd5:  dealFile.CarMd5,
+			CarFileSize: libutils.GetFileSize(carFilePath),
+			PayloadCid:  dealFile.PayloadCid,
+			PieceCid:    dealFile.PieceCid,
+		},
+	}
+
+	_, err = command.WriteFileDescsToJsonFile(fileDescs, renewDir, command.JSON_FILE_NAME_CAR_UPLOAD)
+	if err != nil {
+		os.RemoveAll(renewDir)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	fileDesc, err := uploadCarAndCreateTask(renewDir, dealFile.MaxPrice, len(offlineDeals))
+	if err != nil {
+		os.RemoveAll(renewDir)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = saveRenewedDealFile(dealFile, fileDesc, offlineDeals, renewalFundings)
+	if err != nil {
+		os.RemoveAll(renewDir)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	logs.GetLogger().Info(len(offlineDeals), " deal(s) of deal file:", dealFileId, " renewed by task:", fileDesc.Uuid)
+	return nil
+}
+
+func saveRenewedDealFile(dealFile *models.DealFile, fileDesc *libmodel.FileDesc, offlineDeals []*models.OfflineDeal, renewalFundings []*renewalFunding) error {
+	filepMaps, err := models.GetSourceFileDealFileMapsByDealFileId(dealFile.ID)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	db := database.GetDBTransaction()
+	currentUtcMilliSecond := utils.GetCurrentUtcMilliSecond()
+	renewedDealFile := models.DealFile{
+		CarFileName:       dealFile.CarFileName,
+		CarFilePath:       fileDesc.CarFilePath,
+		CarFileSize:       fileDesc.CarFileSize,
+		CarMd5:            dealFile.CarMd5,
+		PayloadCid:        dealFile.PayloadCid,
+		PieceCid:          dealFile.PieceCid,
+		CreateAt:          currentUtcMilliSecond,
+		UpdateAt:          currentUtcMilliSecond,
+		Duration:          constants.DURATION_DAYS_DEFAULT,
+		LockPaymentStatus: constants.PROCESS_STATUS_TASK_CREATED,
+		MaxPrice:          dealFile.MaxPrice,
+		TaskUuid:          fileDesc.Uuid,
+		PinStatus:         constants.IPFS_File_PINNED_STATUS,
+	}
+
+	err = database.SaveOneInTransaction(db, &renewedDealFile)
+	if err != nil {
+		db.Rollback()
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	// the renewed car file has the same content, so the positions and proofs of the source files stay valid
+	for _, filepMap := range filepMaps {
+		filepMap.DealFileId = renewedDealFile.ID
+		filepMap.CreateAt = currentUtcMilliSecond
+		filepMap.UpdateAt = currentUtcMilliSecond
+		err = database.SaveOneInTransaction(db, filepMap)
+		if err != nil {
+			db.Rollback()
+			logs.GetLogger().Error(err)
+			return err
+		}
+	}
+
+	// reserve the renewal price from the locked fee, so that the same funds are not counted for the next renewal
+	for _, renewalFunding := range renewalFundings {
+		renewalReservation := models.RenewalReservation{
+			SourceFileId:      renewalFunding.SrcFile.ID,
+			PayloadCid:        renewalFunding.SrcFile.PayloadCid,
+			RenewedDealFileId: renewedDealFile.ID,
+			Amount:            renewalFunding.RenewalPrice,
+			CreateAt:          currentUtcMilliSecond,
+		}
+		err = database.SaveOneInTransaction(db, &renewalReservation)
+		if err != nil {
+			db.Rollback()
+			logs.GetLogger().Error(err)
+			return err
+		}
+	}
+
+	err = saveDealRenewals(db, offlineDeals, &renewedDealFile.ID, constants.DEAL_RENEWAL_STATUS_TASK_CREATED, "")
+	if err != nil {
+		db.Rollback()
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = db.Commit().Error
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	err = disk.TrackFile(constants.LOCAL_FILE_TYPE_RENEW, filepath.Dir(fileDesc.CarFilePath), nil, &renewedDealFile.ID)
+	if err != nil {
+		logs.GetLogger().Error(err)
+	}
+
+	return nil
+}
+
+func saveDealRenewals(db *gorm.DB, offlineDeals []*models.OfflineDeal, renewedDealFileId *int64, status, note string) error {
+	dealFile, err := models.GetDealFileById(offlineDeals[0].DealFileId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	currentUtcMilliSecond := utils.GetCurrentUtcMilliSecond()
+	for _, offlineDeal := range offlineDeals {
+		dealRenewal := models.DealRenewal{
+			DealFileId:        offlineDeal.DealFileId,
+			OfflineDealId:     offlineDeal.Id,
+			DealId:            offlineDeal.DealId,
+			MinerFid:          offlineDeal.MinerFid,
+			EndEpoch:          int64(offlineDeal.StartEpoch + dealFile.Duration*constants.EPOCH_PER_DAY),
+			RenewedDealFileId: renewedDealFileId,
+			Status:            status,
+			Note:              note,
+			CreateAt:          currentUtcMilliSecond,
+			UpdateAt:          currentUtcMilliSecond,
+		}
+
+		if db == nil {
+			err = database.SaveOne(&dealRenewal)
+		} else {
+			err = database.SaveOneInTransaction(db, &dealRenewal)
+		}
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return fmt.Errorf("failed to save renewal of deal:%d, %s", offlineDeal.DealId, err.Error())
+		}
+	}
+
+	return nil
+}
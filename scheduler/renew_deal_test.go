@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+	"strconv"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestIsDealFileRenewable(t *testing.T) {
+	now := int64(1700000000)
+	newRenewalFunding := func(lockedFee, deadline, reservedFee, renewalPrice int64, autoRenew bool) *renewalFunding {
+		return &renewalFunding{
+			SrcFile: &models.SourceFile{PayloadCid: "bafy", AutoRenew: autoRenew},
+			LockedPayment: &client.LockedPayment{
+				LockedFee: decimal.NewFromInt(lockedFee),
+				Deadline:  strconv.FormatInt(deadline, 10),
+			},
+			ReservedFee:  decimal.NewFromInt(reservedFee),
+			RenewalPrice: decimal.NewFromInt(renewalPrice),
+		}
+	}
+	refunded := newRenewalFunding(0, 0, 0, 100, true)
+	refunded.LockedPayment = nil
+
+	tests := []struct {
+		name            string
+		renewalFundings []*renewalFunding
+		renewable       bool
+	}{
+		{"no source file", nil, false},
+		{"funds locked", []*renewalFunding{newRenewalFunding(300, now+1, 0, 100, false)}, true},
+		{"funds locked for exactly the renewal", []*renewalFunding{newRenewalFunding(100, now+1, 0, 100, false)}, true},
+		{"funds locked less than the renewal", []*renewalFunding{newRenewalFunding(99, now+1, 0, 100, false)}, false},
+		{"funds reserved by a former renewal", []*renewalFunding{newRenewalFunding(300, now+1, 250, 100, false)}, false},
+		{"funds left after a former renewal", []*renewalFunding{newRenewalFunding(300, now+1, 200, 100, false)}, true},
+		{"lock expired", []*renewalFunding{newRenewalFunding(300, now, 0, 100, false)}, false},
+		{"auto renew refunded", []*renewalFunding{refunded}, false},
+		{"auto renew funds locked less than the renewal", []*renewalFunding{newRenewalFunding(99, now+1, 0, 100, true)}, false},
+		{"auto renew funds locked", []*renewalFunding{newRenewalFunding(100, now+1, 0, 100, true)}, true},
+		{"one of the source files refunded", []*renewalFunding{newRenewalFunding(300, now+1, 0, 100, true), refunded}, false},
+	}
+
+	for _, test := range tests {
+		renewable := isDealFileRenewable(test.renewalFundings, now)
+		if renewable != test.renewable {
+			t.Errorf("%s: renewable is %t, expected %t", test.name, renewable, test.renewable)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"multi-chain-storage/config"
+	"multi-chain-storage/retrieval"
+	"path/filepath"
+	"sync"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+)
+
+func CreateScheduler4RetrieveFile() {
+	c := cron.New()
+	name := "retrieve file"
+	rule := config.GetConfig().ScheduleRule.RetrieveFileRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := RetrieveFiles()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
+
+// RetrieveFiles runs the retrievals queued by the owners of the source files
+func RetrieveFiles() error {
+	return retrieval.RetrieveQueuedSourceFiles(filepath.Join(carDir, "retrieve"))
+}
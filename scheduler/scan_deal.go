@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"fmt"
 	"multi-chain-storage/common/constants"
 	"multi-chain-storage/common/utils"
 	"multi-chain-storage/config"
@@ -66,11 +67,18 @@ func ScanDeal() error {
 			deal.Status = dealInfo.Status
 			deal.DealId = dealInfo.DealId
 			deal.UpdateAt = utils.GetCurrentUtcMilliSecond()
+			if deal.Status == constants.DEAL_STATUS_ACTIVE && deal.ActiveAt == nil {
+				deal.ActiveAt = &deal.UpdateAt
+			}
 			err = database.SaveOne(deal)
 			if err != nil {
 				logs.GetLogger().Error(err)
 				return err
 			}
+
+			if deal.Status == constants.DEAL_STATUS_ACTIVE {
+				models.TransitFileStatusByDealFileId(deal.DealFileId, constants.FILE_STATUS_ACTIVE, fmt.Sprintf("deal:%d active on miner:%s", deal.DealId, deal.MinerFid))
+			}
 		}
 	}
 
@@ -101,6 +109,8 @@ func GetExpiredDealInfoAndUpdateInfoToDB() error {
 				if err != nil {
 					logs.GetLogger().Error(err)
 				}
+
+				models.TransitFileStatusByDealFileId(v.DealFileId, constants.FILE_STATUS_EXPIRED, "locked payment of payload_cid:"+v.PayloadCid+" not exists after expiry")
 			}
 			continue
 		}
@@ -128,6 +138,12 @@ func GetExpiredDealInfoAndUpdateInfoToDB() error {
 			return err
 		}
 
+		if paymentStatus == constants.PROCESS_STATUS_EXPIRE_REFUNDED {
+			models.TransitFileStatusByDealFileId(_dealFileId, constants.FILE_STATUS_EXPIRED, "expired payment of payload_cid:"+v.PayloadCid+" refunded")
+		} else {
+			models.TransitFileStatusByDealFileId(_dealFileId, constants.FILE_STATUS_REFUNDING, "payment of payload_cid:"+v.PayloadCid+" expired")
+		}
+
 	}
 	return nil
 }
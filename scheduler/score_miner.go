@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/models"
+	"sync"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+)
+
+func CreateScheduler4ScoreMiner() {
+	c := cron.New()
+	name := "score miner"
+	rule := config.GetConfig().ScheduleRule.ScoreMinerRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := ScoreMiner()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
+
+func ScoreMiner() error {
+	minerReputations, err := models.GetMinerDealStatistics()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	for _, minerReputation := range minerReputations {
+		minerReputation.Score = getMinerScore(minerReputation)
+		minerReputation.CreateAt = currentUtcMilliSec
+		minerReputation.UpdateAt = currentUtcMilliSec
+
+		err = models.SaveMinerReputation(minerReputation)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+	}
+
+	logs.GetLogger().Info(len(minerReputations), " miner(s) scored")
+	return nil
+}
+
+// score is in range [0,100], deducted by error rate, slashing rate, unlock failure rate
+// and time taken to become active compared with the expected start epoch hours
+func getMinerScore(minerReputation *models.MinerReputation) float64 {
+	if minerReputation.DealCount == 0 {
+		return 0
+	}
+
+	dealCount := float64(minerReputation.DealCount)
+	errorRate := float64(minerReputation.ErrorCount) / dealCount
+	slashedRate := float64(minerReputation.SlashedCount) / dealCount
+
+	unlockFailRate := float64(0)
+	unlockCount := minerReputation.UnlockSuccessCount + minerReputation.UnlockFailCount
+	if unlockCount > 0 {
+		unlockFailRate = float64(minerReputation.UnlockFailCount) / float64(unlockCount)
+	}
+
+	activeDelayRate := float64(0)
+	startEpochHours := config.GetConfig().SwanTask.StartEpochHours
+	if startEpochHours > 0 {
+		activeDelayRate = math.Min(float64(minerReputation.AvgActiveMinutes)/60/float64(startEpochHours), 1)
+	}
+
+	score := 100 - 40*errorRate - 30*slashedRate - 20*unlockFailRate - 10*activeDelayRate
+	return math.Max(score, 0)
+}
+
+func isMinerAllowed(minerFid string) bool {
+	minerPolicy := config.GetConfig().MinerPolicy
+	for _, deniedMinerFid := range minerPolicy.DenyList {
+		if deniedMinerFid == minerFid {
+			return false
+		}
+	}
+
+	if len(minerPolicy.AllowList) == 0 {
+		return true
+	}
+
+	for _, allowedMinerFid := range minerPolicy.AllowList {
+		if allowedMinerFid == minerFid {
+			return true
+		}
+	}
+
+	return false
+}
+
+func selectMiners(copyNumber int) ([]string, error) {
+	minerReputations, err := models.GetMinerReputations()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return pickMiners(minerReputations, copyNumber)
+}
+
+// pickMiners picks copyNumber allowed miners in the order of minerReputations whose scores are not below min_score,
+// then the allowed miners without any deal history yet
+func pickMiners(minerReputations []*models.MinerReputation, copyNumber int) ([]string, error) {
+	minerFids := []string{}
+	minerFidsScored := map[string]bool{}
+	for _, minerReputation := range minerReputations {
+		minerFidsScored[minerReputation.MinerFid] = true
+		if len(minerFids) >= copyNumber || minerReputation.Score < config.GetConfig().MinerPolicy.MinScore {
+			continue
+		}
+
+		if isMinerAllowed(minerReputation.MinerFid) {
+			minerFids = append(minerFids, minerReputation.MinerFid)
+		}
+	}
+
+	// allowed miners without any deal history yet
+	for _, minerFid := range config.GetConfig().MinerPolicy.AllowList {
+		if len(minerFids) >= copyNumber {
+			break
+		}
+
+		if !minerFidsScored[minerFid] && isMinerAllowed(minerFid) {
+			minerFids = append(minerFids, minerFid)
+			minerFidsScored[minerFid] = true
+		}
+	}
+
+	if len(minerFids) == 0 {
+		err := fmt.Errorf("no miner is available, selection mode:%s", constants.MINER_SELECTION_MODE_MANUAL_BID)
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return minerFids, nil
+}
@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"multi-chain-storage/config"
+	"multi-chain-storage/models"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	libconstants "github.com/filswan/go-swan-lib/constants"
+	"github.com/shopspring/decimal"
+)
+
+func setMinerPolicy(allowList, denyList []string, minScore float64) {
+	configuration := config.Configuration{}
+	configuration.SwanTask.StartEpochHours = 96
+	configuration.MinerPolicy.AllowList = allowList
+	configuration.MinerPolicy.DenyList = denyList
+	configuration.MinerPolicy.MinScore = minScore
+	config.SetConfig(configuration)
+}
+
+func TestGetMinerScore(t *testing.T) {
+	setMinerPolicy(nil, nil, 0)
+
+	tests := []struct {
+		name            string
+		minerReputation models.MinerReputation
+		score           float64
+	}{
+		{"no deal", models.MinerReputation{}, 0},
+		{"all active", models.MinerReputation{DealCount: 10, ActiveCount: 10, UnlockSuccessCount: 10}, 100},
+		{"half error", models.MinerReputation{DealCount: 10, ErrorCount: 5}, 80},
+		{"all slashed", models.MinerReputation{DealCount: 4, SlashedCount: 4}, 70},
+		{"quarter unlock failed", models.MinerReputation{DealCount: 4, UnlockSuccessCount: 3, UnlockFailCount: 1}, 95},
+		{"active after half start epoch hours", models.MinerReputation{DealCount: 1, AvgActiveMinutes: 48 * 60}, 95},
+		{"active delay capped", models.MinerReputation{DealCount: 1, AvgActiveMinutes: 1000 * 60}, 90},
+		{"all failed", models.MinerReputation{DealCount: 2, ErrorCount: 2, SlashedCount: 2, UnlockFailCount: 2, AvgActiveMinutes: 1000 * 60}, 0},
+	}
+
+	for _, test := range tests {
+		minerReputation := test.minerReputation
+		score := getMinerScore(&minerReputation)
+		if score != test.score {
+			t.Errorf("%s: score is %v, expected %v", test.name, score, test.score)
+		}
+	}
+}
+
+func TestIsMinerAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowList []string
+		denyList  []string
+		minerFid  string
+		allowed   bool
+	}{
+		{"no list", nil, nil, "f01", true},
+		{"denied", nil, []string{"f01"}, "f01", false},
+		{"allowed", []string{"f01"}, nil, "f01", true},
+		{"not in allow list", []string{"f01"}, nil, "f02", false},
+		{"denied over allowed", []string{"f01"}, []string{"f01"}, "f01", false},
+	}
+
+	for _, test := range tests {
+		setMinerPolicy(test.allowList, test.denyList, 0)
+		allowed := isMinerAllowed(test.minerFid)
+		if allowed != test.allowed {
+			t.Errorf("%s: allowed is %t, expected %t", test.name, allowed, test.allowed)
+		}
+	}
+}
+
+func TestPickMiners(t *testing.T) {
+	minerReputations := []*models.MinerReputation{
+		{MinerFid: "f01", Score: 95},
+		{MinerFid: "f02", Score: 90},
+		{MinerFid: "f03", Score: 60},
+		{MinerFid: "f04", Score: 30},
+	}
+
+	tests := []struct {
+		name       string
+		allowList  []string
+		denyList   []string
+		minScore   float64
+		copyNumber int
+		minerFids  []string
+	}{
+		{"by score", nil, nil, 0, 2, []string{"f01", "f02"}},
+		{"all when copy number is greater", nil, nil, 0, 10, []string{"f01", "f02", "f03", "f04"}},
+		{"min score", nil, nil, 50, 10, []string{"f01", "f02", "f03"}},
+		{"denied skipped", nil, []string{"f01"}, 0, 2, []string{"f02", "f03"}},
+		{"allowed only", []string{"f03", "f04"}, nil, 0, 2, []string{"f03", "f04"}},
+		{"allowed without history", []string{"f02", "f09"}, nil, 0, 3, []string{"f02", "f09"}},
+		{"allowed without history after scored", []string{"f09", "f01"}, nil, 0, 1, []string{"f01"}},
+	}
+
+	for _, test := range tests {
+		setMinerPolicy(test.allowList, test.denyList, test.minScore)
+		minerFids, err := pickMiners(minerReputations, test.copyNumber)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+			continue
+		}
+
+		if !reflect.DeepEqual(minerFids, test.minerFids) {
+			t.Errorf("%s: miners are %v, expected %v", test.name, minerFids, test.minerFids)
+		}
+	}
+
+	setMinerPolicy(nil, nil, 100)
+	_, err := pickMiners(minerReputations, 2)
+	if err == nil {
+		t.Errorf("no miner above min score should fail")
+	}
+}
+
+func setSwanStubConfig(swanStub *swanStub) {
+	configuration := config.Configuration{}
+	configuration.SwanPlatformFilWallet = "f1wallet"
+	configuration.SwanApi.ApiUrl = swanStub.URL
+	configuration.SwanApi.ApiKey = "api-key"
+	configuration.SwanApi.AccessToken = "access-token"
+	configuration.Lotus.ClientApiUrl = swanStub.URL + SWAN_STUB_RPC_PATH
+	configuration.SwanTask.StartEpochHours = 96
+	config.SetConfig(configuration)
+}
+
+func newSwanStubDealFile(t *testing.T) *models.DealFile {
+	return &models.DealFile{
+		CarFileName: "test.car",
+		CarFilePath: filepath.Join(t.TempDir(), "test.car"),
+		CarFileSize: 1024,
+		PayloadCid:  "payload-cid",
+		PieceCid:    "piece-cid",
+		TaskUuid:    "task-uuid",
+		MaxPrice:    decimal.NewFromFloat(0.0005),
+	}
+}
+
+func TestSendDeals2Miners(t *testing.T) {
+	swanStub := newSwanStub(t, libconstants.TASK_BID_MODE_MANUAL)
+	setSwanStubConfig(swanStub)
+
+	minerFids := []string{"f01", "f02"}
+	fileDescs, err := sendDeals2Miners(newSwanStubDealFile(t), minerFids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(swanStub.getStartDealMiners(), minerFids) {
+		t.Errorf("deals sent to %v, expected %v", swanStub.getStartDealMiners(), minerFids)
+	}
+
+	if len(fileDescs) != 1 || len(fileDescs[0].Deals) != len(minerFids) {
+		t.Fatalf("deals returned:%v, expected %d", fileDescs, len(minerFids))
+	}
+
+	for i, deal := range fileDescs[0].Deals {
+		if deal.MinerFid != minerFids[i] || deal.DealCid != "deal-cid-"+minerFids[i] {
+			t.Errorf("deal %d is %+v", i, deal)
+		}
+	}
+
+	offlineDeals := swanStub.getOfflineDeals()
+	if len(offlineDeals) != 1 || offlineDeals[0].Uuid != "task-uuid" || len(offlineDeals[0].Deals) != len(minerFids) {
+		t.Errorf("offline deals created on swan:%v", offlineDeals)
+	}
+}
+
+func TestSendDeals2MinersRefusesAutoBidTask(t *testing.T) {
+	swanStub := newSwanStub(t, libconstants.TASK_BID_MODE_AUTO)
+	setSwanStubConfig(swanStub)
+
+	_, err := sendDeals2Miners(newSwanStubDealFile(t), []string{"f01"})
+	if err == nil {
+		t.Fatal("deals of auto bid task should not be sent to miners")
+	}
+
+	if len(swanStub.getStartDealMiners()) != 0 || len(swanStub.getOfflineDeals()) != 0 {
+		t.Errorf("no deal should be sent for auto bid task")
+	}
+}
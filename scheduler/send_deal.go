@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/filswan/go-swan-client/command"
@@ -18,6 +19,7 @@ import (
 	"github.com/filswan/go-swan-lib/logs"
 
 	libconstants "github.com/filswan/go-swan-lib/constants"
+	libmodel "github.com/filswan/go-swan-lib/model"
 )
 
 func CreateScheduler4SendDeal() {
@@ -69,9 +71,14 @@ func SendDeal() error {
 		return err
 	}
 
+	dealSendWindowDays := config.GetConfig().SwanTask.DealSendWindowDays
+	if dealSendWindowDays <= 0 {
+		dealSendWindowDays = constants.DEAL_SEND_WINDOW_DAYS_DEFAULT
+	}
+
 	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
 	for _, dealFile := range dealFiles {
-		if currentUtcMilliSec-dealFile.CreateAt > 3*24*60*60*1000 {
+		if currentUtcMilliSec-dealFile.CreateAt > int64(dealSendWindowDays)*24*60*60*1000 {
 			dealFile.LockPaymentStatus = constants.PROCESS_STATUS_DEAL_SEND_CANCELLED
 			err = database.SaveOne(dealFile)
 			if err != nil {
@@ -84,11 +91,19 @@ func SendDeal() error {
 		logs.GetLogger().Info("start to send deal for task:", dealFile.TaskUuid)
 		cmdAutoBidDeal.OutputDir = filepath.Dir(dealFile.CarFilePath)
 
-		_, fileDescs, err := cmdAutoBidDeal.SendAutoBidDealsByTaskUuid(dealFile.TaskUuid)
+		var fileDescs []*libmodel.FileDesc
+		if config.GetConfig().DealEngine == constants.DEAL_ENGINE_LOTUS {
+			fileDescs, err = sendLotusDeals(dealFile)
+		} else if config.GetConfig().MinerPolicy.SelectionMode == constants.MINER_SELECTION_MODE_MANUAL_BID {
+			fileDescs, err = sendManualBidDeals(dealFile)
+		} else {
+			_, fileDescs, err = cmdAutoBidDeal.SendAutoBidDealsByTaskUuid(dealFile.TaskUuid)
+		}
 		if err != nil {
 			logs.GetLogger().Error(err)
 			dealFile.LockPaymentStatus = constants.PROCESS_STATUS_DEAL_SENT_FAILED
 			dealFile.ClientWalletAddress = cmdAutoBidDeal.SenderWallet
+			dealFile.UpdateAt = utils.GetCurrentUtcMilliSecond()
 			err = database.SaveOne(dealFile)
 			if err != nil {
 				logs.GetLogger().Error(err)
@@ -148,7 +163,68 @@ func SendDeal() error {
 			logs.GetLogger().Error(err)
 			return err
 		}
+
+		models.TransitFileStatusByDealFileId(dealFile.ID, constants.FILE_STATUS_DEAL_SENT, fmt.Sprintf("%d deal(s) sent for task:%s", len(fileDescs[0].Deals), dealFile.TaskUuid))
 	}
 
 	return nil
 }
+
+func sendManualBidDeals(dealFile *models.DealFile) ([]*libmodel.FileDesc, error) {
+	minerFids, err := selectMiners(config.GetConfig().SwanTask.MaxAutoBidCopyNumber)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return sendDeals2Miners(dealFile, minerFids)
+}
+
+// sendDeals2Miners sends the deals of the manual bid task of the deal file to the miners by swan client
+func sendDeals2Miners(dealFile *models.DealFile, minerFids []string) ([]*libmodel.FileDesc, error) {
+	outputDir := filepath.Dir(dealFile.CarFilePath)
+	fileDescs := []*libmodel.FileDesc{
+		{
+			Uuid:        dealFile.TaskUuid,
+			CarFileName: dealFile.CarFileName,
+			CarFilePath: dealFile.CarFilePath,
+			CarFileMd5:  dealFile.CarMd5,
+			CarFileSize: dealFile.CarFileSize,
+			PayloadCid:  dealFile.PayloadCid,
+			PieceCid:    dealFile.PieceCid,
+		},
+	}
+
+	metadataJsonPath, err := command.WriteFileDescsToJsonFile(fileDescs, outputDir, dealFile.TaskUuid+"-manual-bid.json")
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	cmdDeal := &command.CmdDeal{
+		SwanApiUrl:             config.GetConfig().SwanApi.ApiUrl,
+		SwanApiKey:             config.GetConfig().SwanApi.ApiKey,
+		SwanAccessToken:        config.GetConfig().SwanApi.AccessToken,
+		LotusClientApiUrl:      config.GetConfig().Lotus.ClientApiUrl,
+		LotusClientAccessToken: config.GetConfig().Lotus.ClientAccessToken,
+		SenderWallet:           config.GetConfig().SwanPlatformFilWallet,
+		MaxPrice:               dealFile.MaxPrice,
+		VerifiedDeal:           config.GetConfig().SwanTask.VerifiedDeal,
+		FastRetrieval:          config.GetConfig().SwanTask.FastRetrieval,
+		SkipConfirmation:       true,
+		Duration:               constants.DURATION_DAYS_DEFAULT * constants.EPOCH_PER_DAY,
+		StartEpochHours:        config.GetConfig().SwanTask.StartEpochHours,
+		OutputDir:              outputDir,
+		MinerFids:              minerFids,
+		MetadataJsonPath:       *metadataJsonPath,
+	}
+
+	logs.GetLogger().Info("sending deals for task:", dealFile.TaskUuid, " to miners:", minerFids)
+	fileDescs, err = cmdDeal.SendDeals()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return fileDescs, nil
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+	"multi-chain-storage/on-chain/client"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/shopspring/decimal"
+)
+
+// errTxJobPaused stops the unlock and refund jobs instead of sending transactions bound to fail
+var errTxJobPaused = errors.New("paused")
+
+type WalletStatus struct {
+	SignerAddress        string          `json:"signer_address"`
+	SignerBalance        decimal.Decimal `json:"signer_balance"`
+	SignerMinBalance     decimal.Decimal `json:"signer_min_balance"`
+	ReceiverAddress      string          `json:"receiver_address"`
+	ReceiverBalance      decimal.Decimal `json:"receiver_balance"`
+	UnlockGasSpentToday  decimal.Decimal `json:"unlock_gas_spent_today"`
+	UnlockDailyGasBudget decimal.Decimal `json:"unlock_daily_gas_budget"`
+	UnlockPaused         bool            `json:"unlock_paused"`
+	UnlockPausedReason   string          `json:"unlock_paused_reason"`
+	RefundPaused         bool            `json:"refund_paused"`
+	RefundPausedReason   string          `json:"refund_paused_reason"`
+	CheckedAt            int64           `json:"checked_at"`
+}
+
+// GetWalletStatus returns the MATIC balances of the signer and mcs_payment_receiver_address,
+// and whether the unlock and refund jobs are paused by [gas_policy]
+func GetWalletStatus(ethClient *ethclient.Client) (*WalletStatus, error) {
+	_, signerAddress, err := client.GetPrivateKeyPublicKey(constants.PRIVATE_KEY_ON_POLYGON)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	signerBalance, err := client.GetBalance(ethClient, *signerAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	receiverAddress := common.HexToAddress(config.GetConfig().Polygon.McsPaymentReceiverAddress)
+	receiverBalance, err := client.GetBalance(ethClient, receiverAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	unlockGasSpentToday, err := models.GetGasSpent(constants.TX_JOB_UNLOCK, getUtcDayStartMilliSec())
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	gasPolicy := config.GetConfig().GasPolicy
+	walletStatus := &WalletStatus{
+		SignerAddress:        signerAddress.Hex(),
+		SignerBalance:        signerBalance,
+		SignerMinBalance:     gasPolicy.SignerMinBalance,
+		ReceiverAddress:      receiverAddress.Hex(),
+		ReceiverBalance:      receiverBalance,
+		UnlockGasSpentToday:  unlockGasSpentToday,
+		UnlockDailyGasBudget: gasPolicy.UnlockDailyGasBudget,
+		CheckedAt:            utils.GetCurrentUtcMilliSecond(),
+	}
+
+	if gasPolicy.SignerMinBalance.IsPositive() && signerBalance.LessThan(gasPolicy.SignerMinBalance) {
+		reason := fmt.Sprintf("signer %s balance %s MATIC is below signer_min_balance %s MATIC", walletStatus.SignerAddress, signerBalance.String(), gasPolicy.SignerMinBalance.String())
+		walletStatus.UnlockPaused, walletStatus.UnlockPausedReason = true, reason
+		walletStatus.RefundPaused, walletStatus.RefundPausedReason = true, reason
+	}
+
+	if !walletStatus.UnlockPaused && gasPolicy.UnlockDailyGasBudget.IsPositive() && !unlockGasSpentToday.LessThan(gasPolicy.UnlockDailyGasBudget) {
+		walletStatus.UnlockPaused = true
+		walletStatus.UnlockPausedReason = fmt.Sprintf("unlock paid %s MATIC for gas today, reaching unlock_daily_gas_budget %s MATIC", unlockGasSpentToday.String(), gasPolicy.UnlockDailyGasBudget.String())
+	}
+
+	return walletStatus, nil
+}
+
+// checkTxJobAllowed returns errTxJobPaused when the job should not send transactions now
+func checkTxJobAllowed(ethClient *ethclient.Client, txJob string) error {
+	walletStatus, err := GetWalletStatus(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	paused, reason := walletStatus.UnlockPaused, walletStatus.UnlockPausedReason
+	if txJob == constants.TX_JOB_REFUND {
+		paused, reason = walletStatus.RefundPaused, walletStatus.RefundPausedReason
+	}
+
+	if paused {
+		err := fmt.Errorf("%s %w: %s", txJob, errTxJobPaused, reason)
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// saveGasSpend records the gas paid by a mined transaction, the daily gas budget is checked against these records
+func saveGasSpend(txJob string, tx *types.Transaction, txReceipt *types.Receipt) {
+	gasSpend := models.GasSpend{
+		TxJob:    txJob,
+		TxHash:   tx.Hash().Hex(),
+		TxStatus: txReceipt.Status,
+		GasUsed:  txReceipt.GasUsed,
+		GasPrice: tx.GasPrice().String(),
+		Fee:      client.GetTxFee(tx, txReceipt),
+		CreateAt: utils.GetCurrentUtcMilliSecond(),
+	}
+
+	err := database.SaveOne(&gasSpend)
+	if err != nil {
+		logs.GetLogger().Error(err)
+	}
+}
+
+func getUtcDayStartMilliSec() int64 {
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return dayStart.UnixNano() / int64(time.Millisecond)
+}
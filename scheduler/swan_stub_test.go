@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	libmodel "github.com/filswan/go-swan-lib/model"
+)
+
+const (
+	SWAN_STUB_JWT_TOKEN = "swan-stub-jwt-token"
+	SWAN_STUB_TASK_NAME = "swan-stub-task"
+	SWAN_STUB_RPC_PATH  = "/rpc/v0"
+)
+
+// swanStub is a local stand-in for the swan api, and the lotus json rpc api under SWAN_STUB_RPC_PATH,
+// it answers the calls made to send manual bid deals and records the deals sent
+type swanStub struct {
+	*httptest.Server
+	bidMode int
+
+	mutex           sync.Mutex
+	startDealMiners []string
+	offlineDeals    []*libmodel.FileDesc
+}
+
+type swanStubRpcRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+func newSwanStub(t *testing.T, bidMode int) *swanStub {
+	swanStub := &swanStub{bidMode: bidMode}
+	swanStub.Server = httptest.NewServer(http.HandlerFunc(swanStub.serveHTTP))
+	t.Cleanup(swanStub.Close)
+	return swanStub
+}
+
+func (swanStub *swanStub) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	swanStub.mutex.Lock()
+	defer swanStub.mutex.Unlock()
+
+	switch {
+	case r.URL.Path == "/user/login_by_apikey":
+		writeSwanStubJson(w, map[string]interface{}{"status": "success", "data": map[string]string{"jwt_token": SWAN_STUB_JWT_TOKEN}})
+	case strings.HasPrefix(r.URL.Path, "/tasks/"):
+		if r.Header.Get("Authorization") != "Bearer "+SWAN_STUB_JWT_TOKEN {
+			writeSwanStubJson(w, map[string]interface{}{"status": "fail", "message": "unauthorized"})
+			return
+		}
+
+		task := map[string]interface{}{
+			"task_name": SWAN_STUB_TASK_NAME,
+			"uuid":      strings.TrimPrefix(r.URL.Path, "/tasks/"),
+			"bid_mode":  swanStub.bidMode,
+		}
+		writeSwanStubJson(w, map[string]interface{}{"status": "success", "data": map[string]interface{}{"task": task}})
+	case r.URL.Path == "/offline_deals/create_offline_deals":
+		var fileDescs []*libmodel.FileDesc
+		err := json.NewDecoder(r.Body).Decode(&fileDescs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		swanStub.offlineDeals = append(swanStub.offlineDeals, fileDescs...)
+		writeSwanStubJson(w, map[string]interface{}{"status": "success", "message": ""})
+	case r.URL.Path == SWAN_STUB_RPC_PATH:
+		swanStub.serveLotusRpc(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (swanStub *swanStub) serveLotusRpc(w http.ResponseWriter, r *http.Request) {
+	var rpcRequest swanStubRpcRequest
+	err := json.NewDecoder(r.Body).Decode(&rpcRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result interface{}
+	switch rpcRequest.Method {
+	case "Filecoin.ChainHead":
+		result = map[string]interface{}{"Height": 1000}
+	case "Filecoin.ClientMinerQueryOffer":
+		result = map[string]interface{}{"MinerPeer": map[string]string{"ID": "peer-id"}}
+	case "Filecoin.ClientQueryAsk":
+		result = map[string]interface{}{"Price": "0", "VerifiedPrice": "0"}
+	case "Filecoin.ClientStartDeal":
+		var startDealParam struct {
+			Miner string
+		}
+		if len(rpcRequest.Params) > 0 {
+			json.Unmarshal(rpcRequest.Params[0], &startDealParam)
+		}
+
+		swanStub.startDealMiners = append(swanStub.startDealMiners, startDealParam.Miner)
+		result = map[string]string{"/": "deal-cid-" + startDealParam.Miner}
+	default:
+		writeSwanStubJson(w, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "error": map[string]interface{}{"code": 1, "message": "method not found:" + rpcRequest.Method}})
+		return
+	}
+
+	writeSwanStubJson(w, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+}
+
+func (swanStub *swanStub) getStartDealMiners() []string {
+	swanStub.mutex.Lock()
+	defer swanStub.mutex.Unlock()
+	return append([]string{}, swanStub.startDealMiners...)
+}
+
+func (swanStub *swanStub) getOfflineDeals() []*libmodel.FileDesc {
+	swanStub.mutex.Lock()
+	defer swanStub.mutex.Unlock()
+	return append([]*libmodel.FileDesc{}, swanStub.offlineDeals...)
+}
+
+func writeSwanStubJson(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"multi-chain-storage/common/constants"
 	"multi-chain-storage/common/utils"
 	"multi-chain-storage/config"
@@ -11,13 +12,16 @@ import (
 	"multi-chain-storage/on-chain/client"
 	"multi-chain-storage/on-chain/goBind"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/robfig/cron"
+	"github.com/shopspring/decimal"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/filswan/go-swan-lib/logs"
 )
@@ -61,7 +65,7 @@ func UnlockPayment() error {
 		return nil
 	}
 
-	ethClient, rpcClient, err := client.GetEthClient()
+	ethClient, _, err := client.GetEthClient()
 	if err != nil {
 		logs.GetLogger().Error(err)
 		return err
@@ -84,6 +88,9 @@ func UnlockPayment() error {
 	unlockInterval := config.GetConfig().Polygon.UnlockIntervalMinute * time.Minute
 	logs.GetLogger().Info("unlock interval is ", unlockInterval)
 
+	unlockBatchSize := config.GetConfig().Polygon.UnlockBatchSize
+	offlineDeals2Unlock := []*models.OfflineDeal{}
+
 	unlockCnt := 0
 	for _, offlineDeal := range offlineDeals {
 		isUnlockable, err := checkUnlockable(ethClient, offlineDeal, filswanOracleSession, mcsPaymentReceiverAddress)
@@ -97,11 +104,22 @@ func UnlockPayment() error {
 			continue
 		}
 
+		if unlockBatchSize > 1 {
+			offlineDeals2Unlock = append(offlineDeals2Unlock, offlineDeal)
+			continue
+		}
+
 		if unlockCnt > 0 {
 			logs.GetLogger().Info(getLog(offlineDeal, "sleeping "+unlockInterval.String()+" before unlock"))
 			time.Sleep(unlockInterval)
 		}
 
+		err = checkTxJobAllowed(ethClient, constants.TX_JOB_UNLOCK)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+
 		logs.GetLogger().Info(getLog(offlineDeal, "start to unlock"))
 
 		err = setUnlockPayment(offlineDeal)
@@ -111,22 +129,182 @@ func UnlockPayment() error {
 		}
 
 		unlockCnt = unlockCnt + 1
-		txHash, err := doUnlockDeal(offlineDeal, ethClient, swanPaymentTransactor, mcsPaymentReceiverAddress)
+		txReceipt, err := doUnlockDeal(offlineDeal, ethClient, swanPaymentTransactor, mcsPaymentReceiverAddress)
 		if err != nil {
 			logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
 			continue
 		}
 
-		if txHash == nil {
-			logs.GetLogger().Info(getLog(offlineDeal, " no tx hash returned"))
+		if txReceipt == nil {
+			logs.GetLogger().Info(getLog(offlineDeal, " no tx receipt returned"))
 			continue
 		}
 
-		err = updateUnlockPayment(offlineDeal, *txHash, rpcClient)
+		lockedFees := map[string]decimal.Decimal{}
+		dealIds := []string{strconv.FormatInt(offlineDeal.DealId, 10)}
+		err = updateUnlockPayment(ethClient, offlineDeal, txReceipt, dealIds, mcsPaymentReceiverAddress, lockedFees)
+		if err != nil {
+			logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+			continue
+		}
+
+		checkUnlockPayments(txReceipt.TxHash.Hex(), lockedFees)
+	}
+
+	for i := 0; i < len(offlineDeals2Unlock); i = i + unlockBatchSize {
+		if i > 0 {
+			logs.GetLogger().Info("sleeping ", unlockInterval.String(), " before next unlock batch")
+			time.Sleep(unlockInterval)
+		}
+
+		end := i + unlockBatchSize
+		if end > len(offlineDeals2Unlock) {
+			end = len(offlineDeals2Unlock)
+		}
+
+		err = unlockDealsInBatch(offlineDeals2Unlock[i:end], ethClient, swanPaymentTransactor, mcsPaymentReceiverAddress)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unlockDealsInBatch unlocks the deals by one unlockCarPayments transaction, the contract unlocks each deal on its own,
+// so the result of each deal is read from the UnlockCarPayment and UnlockCarPaymentFailed events of the transaction
+func unlockDealsInBatch(offlineDeals []*models.OfflineDeal, ethClient *ethclient.Client, swanPaymentTransactor *goBind.SwanPaymentTransactor, mcsPaymentReceiverAddress common.Address) error {
+	err := checkTxJobAllowed(ethClient, constants.TX_JOB_UNLOCK)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	offlineDealMap := map[string]*models.OfflineDeal{}
+	dealIds := []string{}
+	for _, offlineDeal := range offlineDeals {
+		err = setUnlockPayment(offlineDeal)
 		if err != nil {
 			logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+			continue
+		}
+
+		dealIdStr := strconv.FormatInt(offlineDeal.DealId, 10)
+		offlineDealMap[dealIdStr] = offlineDeal
+		dealIds = append(dealIds, dealIdStr)
+	}
+
+	if len(dealIds) == 0 {
+		return nil
+	}
+
+	privateKey, publicKeyAddress, err := client.GetPrivateKeyPublicKey(constants.PRIVATE_KEY_ON_POLYGON)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	tansactOpts, err := client.GetTransactOpts(ethClient, privateKey, *publicKeyAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	logs.GetLogger().Info("start to unlock deals:", strings.Join(dealIds, ","))
+	filecoinNetwork := config.GetConfig().FilecoinNetwork
+	tx, err := swanPaymentTransactor.UnlockCarPayments(tansactOpts, dealIds, filecoinNetwork, mcsPaymentReceiverAddress)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		setDealsUnlockFailed(offlineDealMap, "", err.Error())
+		return nil
+	}
+
+	txHash := tx.Hash().Hex()
+	txReceipt, err := client.CheckTx(ethClient, tx)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		setDealsUnlockFailed(offlineDealMap, txHash, err.Error())
+		return nil
+	}
+
+	saveGasSpend(constants.TX_JOB_UNLOCK, tx, txReceipt)
+
+	if txReceipt.Status != uint64(1) {
+		err := fmt.Errorf("unlock failed! txHash=%s", txHash)
+		logs.GetLogger().Error(err)
+		setDealsUnlockFailed(offlineDealMap, txHash, err.Error())
+		return nil
+	}
+
+	swanPaymentFilterer, err := client.GetSwanPaymentFilterer()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	// the deals of a car share its source files, so the locked fees are carried from one deal to the next in the order they were unlocked
+	lockedFees := map[string]decimal.Decimal{}
+	for _, vLog := range txReceipt.Logs {
+		if unlocked, err := swanPaymentFilterer.ParseUnlockCarPayment(*vLog); err == nil {
+			offlineDeal, ok := offlineDealMap[unlocked.DealId]
+			if !ok {
+				continue
+			}
+			delete(offlineDealMap, unlocked.DealId)
+
+			logs.GetLogger().Info(getLog(offlineDeal, "unlock success", "txHash="+txHash))
+			err = setDealUnlocked(offlineDeal, txHash)
+			if err != nil {
+				logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+				continue
+			}
+
+			err = updateUnlockPayment(ethClient, offlineDeal, txReceipt, dealIds, mcsPaymentReceiverAddress, lockedFees)
+			if err != nil {
+				logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+			}
+			continue
+		}
+
+		if unlockFailed, err := swanPaymentFilterer.ParseUnlockCarPaymentFailed(*vLog); err == nil {
+			offlineDeal, ok := offlineDealMap[unlockFailed.DealId]
+			if !ok {
+				continue
+			}
+			delete(offlineDealMap, unlockFailed.DealId)
+
+			logs.GetLogger().Error(getLog(offlineDeal, "unlock failed", unlockFailed.Reason))
+			err = models.UpdateOfflineDealUnlockStatus(offlineDeal.Id, constants.OFFLINE_DEAL_UNLOCK_STATUS_UNLOCK_FAILED, "txHash="+txHash, unlockFailed.Reason)
+			if err != nil {
+				logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+			}
 		}
 	}
+
+	setDealsUnlockFailed(offlineDealMap, txHash, "no unlock event of the deal in the transaction")
+	checkUnlockPayments(txHash, lockedFees)
+	return nil
+}
+
+func setDealsUnlockFailed(offlineDealMap map[string]*models.OfflineDeal, txHash, reason string) {
+	for _, offlineDeal := range offlineDealMap {
+		logs.GetLogger().Error(getLog(offlineDeal, "unlock failed", reason))
+		err := models.UpdateOfflineDealUnlockStatus(offlineDeal.Id, constants.OFFLINE_DEAL_UNLOCK_STATUS_UNLOCK_FAILED, "txHash="+txHash, reason)
+		if err != nil {
+			logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+		}
+	}
+}
+
+func setDealUnlocked(offlineDeal *models.OfflineDeal, txHash string) error {
+	err := models.UpdateOfflineDealUnlockStatus(offlineDeal.Id, constants.OFFLINE_DEAL_UNLOCK_STATUS_UNLOCKED)
+	if err != nil {
+		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+		return err
+	}
+
+	models.TransitFileStatusByDealFileId(offlineDeal.DealFileId, constants.FILE_STATUS_UNLOCKED, fmt.Sprintf("payment of deal:%d unlocked, tx hash:%s", offlineDeal.DealId, txHash))
 	return nil
 }
 
@@ -216,33 +394,103 @@ func checkUnlockable(ethClient *ethclient.Client, offlineDeal *models.OfflineDea
 	return true, nil
 }
 
-func updateUnlockPayment(offlineDeal *models.OfflineDeal, txHash string, rpcClient *rpc.Client) error {
+// updateUnlockPayment stores the amount unlocked from each source file of the deal, it is split from the amount transferred to the recipient
+// by size the same way as unlockCarPayment, lockedFees carries the locked fee of each source file between the deals unlocked in the same tx,
+// dealIds are all the deals unlocked by the tx
+func updateUnlockPayment(ethClient *ethclient.Client, offlineDeal *models.OfflineDeal, txReceipt *types.Receipt, dealIds []string, mcsPaymentReceiverAddress common.Address, lockedFees map[string]decimal.Decimal) error {
+	unlockTransfers, err := client.GetUnlockCarPaymentTransfers(txReceipt, dealIds, mcsPaymentReceiverAddress)
+	if err != nil {
+		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+		return err
+	}
+
+	txHash := txReceipt.TxHash.Hex()
+	dealIdStr := strconv.FormatInt(offlineDeal.DealId, 10)
+	unlockTransfer, ok := unlockTransfers[dealIdStr]
+	if !ok {
+		err := fmt.Errorf("no UnlockCarPayment event or transfer to the recipient of the deal in tx:%s", txHash)
+		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+		return err
+	}
+
+	if unlockTransfer.TransferredAmount.Cmp(unlockTransfer.TokenAmount) != 0 {
+		logs.GetLogger().Error(getLog(offlineDeal, "token amount:"+unlockTransfer.TokenAmount.String()+" in UnlockCarPayment is not the amount transferred:"+unlockTransfer.TransferredAmount.String(), "txHash="+txHash))
+	}
+
 	srcFiles, err := models.GetSourceFilesByDealFileId(offlineDeal.DealFileId)
 	if err != nil {
 		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
 		return err
 	}
 
-	var rpcTransaction *models.RpcTransaction
-	err = rpcClient.CallContext(context.Background(), &rpcTransaction, "eth_getTransactionByHash", common.HexToHash(txHash))
+	unlockPayments, err := models.GetEventUnlockPaymentsByDealId(offlineDeal.DealId)
 	if err != nil {
-		logs.GetLogger().Error(err)
+		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
 		return err
 	}
 
-	for _, srcFile := range srcFiles {
-		lockedPayment, err := client.GetLockedPaymentInfo(srcFile.PayloadCid)
+	lockedFeesBeforeTx := map[int64]decimal.Decimal{}
+	for _, unlockPayment := range unlockPayments {
+		if unlockPayment.SourceFileId != nil {
+			lockedFeesBeforeTx[*unlockPayment.SourceFileId] = unlockPayment.LockedFeeBeforeUnlock
+		}
+	}
+
+	filswanOracleSession, err := client.GetFilswanOracleSession(ethClient)
+	if err != nil {
+		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+		return err
+	}
+
+	cidList, err := filswanOracleSession.GetCidList(dealIdStr, config.GetConfig().FilecoinNetwork)
+	if err != nil {
+		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+		return err
+	}
+
+	swanPaymentSession, err := client.GetSwanPaymentSession()
+	if err != nil {
+		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
+		return err
+	}
+
+	sizes := map[string]*big.Int{}
+	totalSize := big.NewInt(0)
+	for _, cid := range cidList {
+		paymentInfo, err := swanPaymentSession.GetLockedPaymentInfo(cid)
 		if err != nil {
 			logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
-			continue
+			return err
 		}
 
-		blockNo := ""
-		if rpcTransaction.BlockNumber != nil {
-			blockNo = *rpcTransaction.BlockNumber
+		sizes[cid] = paymentInfo.Size
+		if paymentInfo.IsExisted {
+			totalSize.Add(totalSize, paymentInfo.Size)
 		}
+	}
 
-		err = models.UpdateUnlockAmount(srcFile.ID, offlineDeal.DealId, txHash, blockNo, lockedPayment.LockedFee)
+	unitPrice := big.NewInt(0)
+	if totalSize.Sign() > 0 {
+		unitPrice.Div(unlockTransfer.TransferredAmount, totalSize)
+	}
+
+	blockNo := hexutil.EncodeBig(txReceipt.BlockNumber)
+	paymentContractAddress := config.GetConfig().Polygon.PaymentContractAddress
+	for _, srcFile := range srcFiles {
+		lockedFeeBeforeUnlock, ok := lockedFees[srcFile.PayloadCid]
+		if !ok {
+			lockedFeeBeforeUnlock = lockedFeesBeforeTx[srcFile.ID]
+		}
+
+		unlockedFee := decimal.Zero
+		if size, ok := sizes[srcFile.PayloadCid]; ok {
+			unlockedFee = decimal.NewFromBigInt(new(big.Int).Mul(unitPrice, size), 0)
+		}
+
+		lockedFeeAfterUnlock := lockedFeeBeforeUnlock.Sub(unlockedFee)
+		lockedFees[srcFile.PayloadCid] = lockedFeeAfterUnlock
+
+		err = models.UpdateUnlockAmount(srcFile.ID, offlineDeal.DealId, txHash, blockNo, paymentContractAddress, unlockTransfer.Recipient.Hex(), lockedFeeBeforeUnlock, unlockedFee, lockedFeeAfterUnlock)
 		if err != nil {
 			logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
 			continue
@@ -252,6 +500,45 @@ func updateUnlockPayment(offlineDeal *models.OfflineDeal, txHash string, rpcClie
 	return nil
 }
 
+// checkUnlockPayments compares the locked fees after the unlock tx computed from it with the ones on chain,
+// the ones on chain are stored as the refundable amounts
+func checkUnlockPayments(txHash string, lockedFees map[string]decimal.Decimal) {
+	if len(lockedFees) == 0 {
+		return
+	}
+
+	swanPaymentSession, err := client.GetSwanPaymentSession()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return
+	}
+
+	for payloadCid, lockedFee := range lockedFees {
+		paymentInfo, err := swanPaymentSession.GetLockedPaymentInfo(payloadCid)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		refundableAmount := decimal.Zero
+		if paymentInfo.IsExisted {
+			refundableAmount = decimal.NewFromBigInt(paymentInfo.LockedFee, 0)
+		}
+
+		unlockCheckStatus := constants.UNLOCK_CHECK_STATUS_CONSISTENT
+		if !refundableAmount.Equal(lockedFee) {
+			unlockCheckStatus = constants.UNLOCK_CHECK_STATUS_INCONSISTENT
+			logs.GetLogger().Error("payload cid:", payloadCid, ", locked fee after unlock tx:", txHash, " is ", lockedFee.String(), ", but ", refundableAmount.String(), " on chain")
+		}
+
+		err = models.UpdateUnlockCheck(payloadCid, txHash, refundableAmount, unlockCheckStatus)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+	}
+}
+
 func setUnlockPayment(offlineDeal *models.OfflineDeal) error {
 	srcFiles, err := models.GetSourceFilesByDealFileId(offlineDeal.DealFileId)
 	if err != nil {
@@ -309,7 +596,7 @@ func getLog(offlineDeal *models.OfflineDeal, messages ...string) string {
 	return text
 }
 
-func doUnlockDeal(offlineDeal *models.OfflineDeal, ethClient *ethclient.Client, swanPaymentTransactor *goBind.SwanPaymentTransactor, mcsPaymentReceiverAddress common.Address) (*string, error) {
+func doUnlockDeal(offlineDeal *models.OfflineDeal, ethClient *ethclient.Client, swanPaymentTransactor *goBind.SwanPaymentTransactor, mcsPaymentReceiverAddress common.Address) (*types.Receipt, error) {
 	privateKey, publicKeyAddress, err := client.GetPrivateKeyPublicKey(constants.PRIVATE_KEY_ON_POLYGON)
 	if err != nil {
 		logs.GetLogger().Error(err)
@@ -366,6 +653,8 @@ func doUnlockDeal(offlineDeal *models.OfflineDeal, ethClient *ethclient.Client,
 		return nil, err
 	}
 
+	saveGasSpend(constants.TX_JOB_UNLOCK, tx, txReceipt)
+
 	if txReceipt.Status != uint64(1) {
 		err := fmt.Errorf("unlock failed! txHash=%s", tx.Hash().Hex())
 		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
@@ -382,13 +671,12 @@ func doUnlockDeal(offlineDeal *models.OfflineDeal, ethClient *ethclient.Client,
 	logs.GetLogger().Info(getLog(offlineDeal, "unlock success", "txHash="+tx.Hash().Hex()))
 	logs.GetLogger().Info(getLog(offlineDeal, "unlock success", "recipient.Hex()="+mcsPaymentReceiverAddress.Hex()))
 
-	unlockStatusUnlocked := constants.OFFLINE_DEAL_UNLOCK_STATUS_UNLOCKED
-	err = models.UpdateOfflineDealUnlockStatus(offlineDeal.Id, unlockStatusUnlocked)
+	err = setDealUnlocked(offlineDeal, txHash)
 	if err != nil {
 		logs.GetLogger().Error(getLog(offlineDeal, err.Error()))
 		return nil, err
 	}
 
 	logs.GetLogger().Info(getLog(offlineDeal, "unlock successfully"))
-	return &txHash, nil
+	return txReceipt, nil
 }
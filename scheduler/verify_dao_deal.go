@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"multi-chain-storage/common/constants"
+	"multi-chain-storage/common/utils"
+	"multi-chain-storage/config"
+	"multi-chain-storage/database"
+	"multi-chain-storage/models"
+	"strings"
+	"sync"
+
+	"github.com/filswan/go-swan-lib/logs"
+	"github.com/robfig/cron"
+)
+
+func CreateScheduler4VerifyDaoDeal() {
+	c := cron.New()
+	name := "verify dao deal"
+	rule := config.GetConfig().ScheduleRule.VerifyDaoDealRule
+	mutex := &sync.Mutex{}
+
+	err := c.AddFunc(rule, func() {
+		logs.GetLogger().Info(name, " start")
+
+		mutex.Lock()
+		logs.GetLogger().Info(name, " running")
+		err := VerifyDaoDeals()
+		if err != nil {
+			logs.GetLogger().Error(err)
+		}
+		mutex.Unlock()
+		logs.GetLogger().Info(name, " end")
+	})
+
+	if err != nil {
+		logs.GetLogger().Fatal(err)
+	}
+
+	c.Start()
+}
+
+// VerifyDaoDeals checks the deals to be signed by dao members against filecoin chain,
+// only the verified ones are served to dao members, with the facts read from chain
+func VerifyDaoDeals() error {
+	deals, err := models.GetDealsToVerifyForDao()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	if len(deals) == 0 {
+		logs.GetLogger().Info("no deal to verify for dao")
+		return nil
+	}
+
+	currentEpoch, err := utils.GetCurrentEpoch()
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return err
+	}
+
+	clientIds := map[string]string{}
+	for _, deal := range deals {
+		verification, err := verifyDaoDeal(deal, currentEpoch, clientIds)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			continue
+		}
+
+		logs.GetLogger().Info("deal id:", deal.DealId, ", ", verification.Status, " ", verification.Reason)
+	}
+
+	return nil
+}
+
+func verifyDaoDeal(deal *models.DealToVerifyForDao, currentEpoch int64, clientIds map[string]string) (*models.DaoDealVerification, error) {
+	verification, err := models.GetDaoDealVerificationByDealId(deal.DealId)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	currentUtcMilliSec := utils.GetCurrentUtcMilliSecond()
+	if verification == nil {
+		verification = &models.DaoDealVerification{
+			DealId:   deal.DealId,
+			CreateAt: currentUtcMilliSec,
+		}
+	}
+	verification.UpdateAt = currentUtcMilliSec
+
+	marketDeal, err := utils.GetMarketDeal(deal.DealId)
+	if err != nil {
+		verification.Status = constants.DAO_DEAL_VERIFICATION_STATUS_FAILED
+		verification.Reason = err.Error()
+	} else {
+		verification.Provider = marketDeal.Proposal.Provider
+		verification.Client = marketDeal.Proposal.Client
+		verification.PieceCid = marketDeal.Proposal.PieceCID.Cid
+		verification.VerifiedDeal = marketDeal.Proposal.VerifiedDeal
+		verification.StartEpoch = marketDeal.Proposal.StartEpoch
+		verification.EndEpoch = marketDeal.Proposal.EndEpoch
+		verification.StoragePricePerEpoch = marketDeal.Proposal.StoragePricePerEpoch
+		verification.SectorStartEpoch = marketDeal.State.SectorStartEpoch
+		verification.SlashEpoch = marketDeal.State.SlashEpoch
+
+		verification.Status = constants.DAO_DEAL_VERIFICATION_STATUS_VERIFIED
+		verification.Reason = ""
+		err = checkDaoDeal(deal, marketDeal, currentEpoch, clientIds)
+		if err != nil {
+			verification.Status = constants.DAO_DEAL_VERIFICATION_STATUS_MISMATCHED
+			verification.Reason = err.Error()
+		}
+	}
+
+	err = database.SaveOne(verification)
+	if err != nil {
+		logs.GetLogger().Error(err)
+		return nil, err
+	}
+
+	return verification, nil
+}
+
+// deals sent before sender_wallet was recorded are from swan platform fil wallet
+func checkDaoDeal(deal *models.DealToVerifyForDao, marketDeal *utils.MarketDeal, currentEpoch int64, clientIds map[string]string) error {
+	clientWallet := deal.SenderWallet
+	if strings.Trim(clientWallet, " ") == "" {
+		clientWallet = config.GetConfig().SwanPlatformFilWallet
+	}
+
+	clientId, ok := clientIds[clientWallet]
+	if !ok {
+		var err error
+		clientId, err = utils.GetIdAddress(clientWallet)
+		if err != nil {
+			logs.GetLogger().Error(err)
+			return err
+		}
+		clientIds[clientWallet] = clientId
+	}
+
+	return utils.CheckMarketDeal(marketDeal, deal.PieceCid, clientId, deal.MinerFid, currentEpoch)
+}
@@ -113,3 +113,433 @@ alter table source_file drop column file_name;
 
 alter table source_file drop column wallet_address;
 
+
+
+alter table source_file add auto_renew tinyint(1) not null default 0;
+
+create table deal_renewal (
+    id                   bigint       not null auto_increment,
+    deal_file_id         bigint       not null,
+    offline_deal_id      bigint       not null,
+    deal_id              bigint       not null,
+    miner_fid            varchar(45)  not null,
+    end_epoch            bigint       not null,
+    renewed_deal_file_id bigint,
+    status               varchar(45)  not null,
+    note                 text,
+    create_at            bigint       not null,
+    update_at            bigint       not null,
+    primary key pk_deal_renewal(id),
+    constraint fk_deal_renewal_deal_file_id foreign key (deal_file_id) references deal_file (id),
+    constraint fk_deal_renewal_offline_deal_id foreign key (offline_deal_id) references offline_deal (id),
+    constraint fk_deal_renewal_renewed_deal_file_id foreign key (renewed_deal_file_id) references deal_file (id)
+);
+
+create index ind_deal_renewal_offline_deal_id on deal_renewal(offline_deal_id);
+
+
+alter table offline_deal add active_at bigint;
+
+create table miner_reputation (
+    id                   bigint       not null auto_increment,
+    miner_fid            varchar(45)  not null,
+    deal_count           int          not null,
+    active_count         int          not null,
+    error_count          int          not null,
+    slashed_count        int          not null,
+    unlock_success_count int          not null,
+    unlock_fail_count    int          not null,
+    avg_active_minutes   bigint       not null,
+    score                double       not null,
+    create_at            bigint       not null,
+    update_at            bigint       not null,
+    primary key pk_miner_reputation(id),
+    constraint un_miner_reputation_miner_fid unique (miner_fid)
+);
+
+
+create table deal_proposal (
+    id            bigint         not null auto_increment,
+    deal_file_id  bigint         not null,
+    miner_fid     varchar(45)    not null,
+    ask_price     decimal(30,18) not null,
+    start_epoch   bigint         not null,
+    deal_cid      varchar(100),
+    status        varchar(45)    not null,
+    note          text,
+    create_at     bigint         not null,
+    update_at     bigint         not null,
+    primary key pk_deal_proposal(id),
+    constraint fk_deal_proposal_deal_file_id foreign key (deal_file_id) references deal_file (id)
+);
+
+create index ind_deal_proposal_deal_file_id on deal_proposal(deal_file_id);
+
+
+create table car_transfer (
+    id                bigint       not null auto_increment,
+    deal_file_id      bigint       not null,
+    piece_cid         varchar(200) not null,
+    miner_fid         varchar(45)  not null,
+    car_file_size     bigint       not null,
+    bytes_transferred bigint       not null,
+    request_count     int          not null,
+    status            varchar(45)  not null,
+    create_at         bigint       not null,
+    update_at         bigint       not null,
+    primary key pk_car_transfer(id),
+    constraint un_car_transfer_deal_file_id_miner_fid unique (deal_file_id,miner_fid),
+    constraint fk_car_transfer_deal_file_id foreign key (deal_file_id) references deal_file (id)
+);
+
+create index ind_car_transfer_piece_cid on car_transfer(piece_cid);
+
+alter table deal_proposal add transfer_url text;
+alter table deal_proposal add boost_deal_params text;
+
+
+create table retrieval_history (
+    id                bigint       not null auto_increment,
+    source_file_id    bigint       not null,
+    deal_file_id      bigint       not null,
+    miner_fid         varchar(45)  not null,
+    status            varchar(45)  not null,
+    note              text,
+    elapsed_milli_sec bigint       not null,
+    create_at         bigint       not null,
+    update_at         bigint       not null,
+    primary key pk_retrieval_history(id),
+    constraint fk_retrieval_history_source_file_id foreign key (source_file_id) references source_file (id),
+    constraint fk_retrieval_history_deal_file_id foreign key (deal_file_id) references deal_file (id)
+);
+
+create index ind_retrieval_history_miner_fid on retrieval_history(miner_fid);
+
+
+alter table source_file_deal_file_map add root_cid varchar(100);
+alter table source_file_deal_file_map add car_offset bigint;
+alter table source_file_deal_file_map add car_length bigint;
+alter table source_file_deal_file_map add inclusion_proof longtext;
+
+
+create table car_group (
+    id         bigint         not null auto_increment,
+    status     varchar(45)    not null,
+    file_count int            not null,
+    total_size bigint         not null,
+    max_price  decimal(20,10) not null,
+    claimed_by varchar(200),
+    claimed_at bigint,
+    attempts   int            not null default 0,
+    note       text,
+    create_at  bigint         not null,
+    update_at  bigint         not null,
+    primary key pk_car_group(id)
+);
+
+create index ind_car_group_status on car_group(status);
+
+alter table source_file add car_group_id bigint;
+alter table source_file add constraint fk_source_file_car_group_id foreign key (car_group_id) references car_group (id);
+
+
+create table local_file (
+    id             bigint        not null auto_increment,
+    file_type      varchar(45)   not null,
+    file_path      varchar(1000) not null,
+    file_size      bigint        not null,
+    source_file_id bigint,
+    deal_file_id   bigint,
+    status         varchar(45)   not null,
+    note           text,
+    create_at      bigint        not null,
+    update_at      bigint        not null,
+    primary key pk_local_file(id),
+    constraint fk_local_file_source_file_id foreign key (source_file_id) references source_file (id),
+    constraint fk_local_file_deal_file_id foreign key (deal_file_id) references deal_file (id)
+);
+
+create index ind_local_file_status_file_type on local_file(status,file_type);
+
+-- car files of tasks already created were uploaded and pinned to ipfs server
+update deal_file set pin_status='Pinned' where task_uuid is not null and task_uuid<>'';
+
+
+alter table source_file add file_status varchar(45) not null default '';
+
+create table file_status_history (
+    id             bigint      not null auto_increment,
+    source_file_id bigint      not null,
+    from_status    varchar(45) not null,
+    to_status      varchar(45) not null,
+    reason         text,
+    create_at      bigint      not null,
+    primary key pk_file_status_history(id),
+    constraint fk_file_status_history_source_file_id foreign key (source_file_id) references source_file (id)
+);
+
+create index ind_file_status_history_source_file_id on file_status_history(source_file_id);
+
+#--the file status is backfilled from the earliest state to the latest one, so each later update overrides the earlier ones
+update source_file set file_status='Uploaded' where status='Created';
+update source_file set file_status='Paid' where status='Paid';
+update source_file set file_status='Aggregated' where status='TaskCreated';
+update source_file s, source_file_deal_file_map m, deal_file d set s.file_status='DealSent'
+ where m.source_file_id=s.id and d.id=m.deal_file_id and d.lock_payment_status='DealSent';
+update source_file s, source_file_deal_file_map m, offline_deal o set s.file_status='Active'
+ where m.source_file_id=s.id and o.deal_file_id=m.deal_file_id and o.status='StorageDealActive';
+update source_file s, source_file_deal_file_map m, offline_deal o set s.file_status='Unlocked'
+ where m.source_file_id=s.id and o.deal_file_id=m.deal_file_id and o.unlock_status='Unlocked';
+update source_file s, source_file_deal_file_map m, deal_file d set s.file_status='Refunding'
+ where m.source_file_id=s.id and d.id=m.deal_file_id and d.lock_payment_status='Refunding';
+update source_file set file_status='Refunding' where refund_status in ('UnlockRefundFailed','RefundFailed');
+update source_file set file_status='Refunded' where refund_status='UnlockRefundSucceeded';
+update source_file s, source_file_deal_file_map m, deal_file d set s.file_status='Expired'
+ where m.source_file_id=s.id and d.id=m.deal_file_id and d.lock_payment_status='Refunded';
+update source_file set file_status='Expired' where refund_status='Refunded';
+
+
+create table webhook (
+    id             bigint       not null auto_increment,
+    wallet_address varchar(200) not null,
+    url            varchar(1000) not null,
+    secret         varchar(100) not null,
+    events         varchar(1000) not null default '',
+    status         varchar(45)  not null,
+    create_at      bigint       not null,
+    update_at      bigint       not null,
+    primary key pk_webhook(id)
+);
+
+create index ind_webhook_wallet_address on webhook(wallet_address);
+
+create table webhook_delivery (
+    id              bigint      not null auto_increment,
+    webhook_id      bigint      not null,
+    event           varchar(45) not null,
+    source_file_id  bigint      not null,
+    payload         text        not null,
+    status          varchar(45) not null,
+    attempts        int         not null default 0,
+    next_attempt_at bigint      not null,
+    response_code   int,
+    note            text,
+    delivered_at    bigint,
+    replay_of_id    bigint,
+    create_at       bigint      not null,
+    update_at       bigint      not null,
+    primary key pk_webhook_delivery(id),
+    constraint fk_webhook_delivery_webhook_id foreign key (webhook_id) references webhook (id),
+    constraint fk_webhook_delivery_source_file_id foreign key (source_file_id) references source_file (id)
+);
+
+create index ind_webhook_delivery_status_next_attempt_at on webhook_delivery(status,next_attempt_at);
+
+
+create table alert (
+    id          bigint      not null auto_increment,
+    rule_name   varchar(200) not null,
+    metric      varchar(45) not null,
+    status      varchar(45) not null,
+    value       double      not null,
+    threshold   double      not null,
+    message     text,
+    fire_at     bigint      not null,
+    notified_at bigint      not null,
+    resolved_at bigint,
+    create_at   bigint      not null,
+    update_at   bigint      not null,
+    primary key pk_alert(id)
+);
+
+create index ind_alert_rule_name_status on alert(rule_name,status);
+
+
+create table gas_spend (
+    id        bigint         not null auto_increment,
+    tx_job    varchar(45)    not null,
+    tx_hash   varchar(100)   not null,
+    tx_status bigint         not null,
+    gas_used  bigint         not null,
+    gas_price varchar(100)   not null,
+    fee       decimal(30,18) not null,
+    create_at bigint         not null,
+    primary key pk_gas_spend(id)
+);
+
+create index ind_gas_spend_tx_job_create_at on gas_spend(tx_job,create_at);
+
+
+create table dao_deal_verification (
+    id                      bigint        not null auto_increment,
+    deal_id                 bigint        not null,
+    status                  varchar(45)   not null,
+    reason                  text,
+    provider                varchar(45),
+    client                  varchar(200),
+    piece_cid               varchar(1000),
+    verified_deal           boolean       not null default false,
+    start_epoch             bigint        not null default 0,
+    end_epoch               bigint        not null default 0,
+    storage_price_per_epoch varchar(100),
+    sector_start_epoch      bigint        not null default 0,
+    slash_epoch             bigint        not null default 0,
+    create_at               bigint        not null,
+    update_at               bigint        not null,
+    primary key pk_dao_deal_verification(id),
+    constraint un_dao_deal_verification_deal_id unique (deal_id)
+);
+
+create index ind_dao_deal_verification_status on dao_deal_verification(status);
+
+
+create table dao_proposal (
+    id          bigint        not null auto_increment,
+    action      varchar(45)   not null,
+    params      text          not null,
+    status      varchar(45)   not null,
+    proposed_by varchar(100)  not null,
+    reviewed_by varchar(100),
+    tx_hash     varchar(100),
+    note        text,
+    create_at   bigint        not null,
+    update_at   bigint        not null,
+    primary key pk_dao_proposal(id)
+);
+
+create index ind_dao_proposal_status on dao_proposal(status);
+
+
+alter table event_unlock_payment add refundable_amount decimal(20,0);
+alter table event_unlock_payment add unlock_check_status varchar(45);
+
+
+create table ledger_discrepancy (
+    id             bigint        not null auto_increment,
+    source_file_id bigint        not null,
+    payload_cid    varchar(1000) not null,
+    field          varchar(100)  not null,
+    record_id      bigint        not null default 0,
+    db_value       varchar(200),
+    chain_value    varchar(200),
+    status         varchar(45)   not null,
+    reviewed_by    varchar(100),
+    create_at      bigint        not null,
+    update_at      bigint        not null,
+    primary key pk_ledger_discrepancy(id)
+);
+
+create index ind_ledger_discrepancy_source_file_field on ledger_discrepancy(source_file_id,field,status);
+create index ind_ledger_discrepancy_status on ledger_discrepancy(status);
+
+
+create table reconciliation_run (
+    id                    bigint        not null auto_increment,
+    checked_count         bigint        not null default 0,
+    failed_count          bigint        not null default 0,
+    discrepancy_count     bigint        not null default 0,
+    new_discrepancy_count bigint        not null default 0,
+    resolved_count        bigint        not null default 0,
+    summary               text,
+    start_at              bigint        not null,
+    end_at                bigint        not null,
+    primary key pk_reconciliation_run(id)
+);
+
+
+create table refund_settlement (
+    id              bigint        not null auto_increment,
+    source_file_id  bigint        not null,
+    deal_file_id    bigint        not null,
+    payload_cid     varchar(1000) not null,
+    paid_replicas   int           not null default 0,
+    active_replicas int           not null default 0,
+    locked_fee      decimal(20,0),
+    unlocked_fee    decimal(20,0),
+    unused_share    decimal(20,0),
+    refund_amount   decimal(20,0),
+    refund_status   varchar(45),
+    tx_hash         varchar(100),
+    create_at       bigint        not null,
+    primary key pk_refund_settlement(id)
+);
+
+create index ind_refund_settlement_source_file_id on refund_settlement(source_file_id);
+
+
+create table retrieval_job (
+    id             bigint       not null auto_increment,
+    source_file_id bigint       not null,
+    wallet_address varchar(100) not null,
+    status         varchar(45)  not null,
+    file_path      varchar(1000),
+    note           text,
+    create_at      bigint       not null,
+    update_at      bigint       not null,
+    primary key pk_retrieval_job(id),
+    constraint fk_retrieval_job_source_file_id foreign key (source_file_id) references source_file (id)
+);
+
+create index ind_retrieval_job_status on retrieval_job(status);
+
+
+update source_file set car_group_id=null where car_group_id in (select id from car_group where status='Failed');
+
+
+update event_dao_signature a, offline_deal b, deal_file c set a.payload_cid=c.payload_cid
+where a.deal_id=b.deal_id and b.deal_file_id=c.id and (a.payload_cid is null or a.payload_cid<>c.payload_cid);
+
+
+alter table dao_proposal modify tx_hash text;
+
+
+create table ledger_balance (
+    id                    bigint        not null auto_increment,
+    payload_cid           varchar(1000) not null,
+    locked_fee            decimal(20,0) not null,
+    ledger_discrepancy_id bigint        not null,
+    reviewed_by           varchar(100),
+    create_at             bigint        not null,
+    primary key pk_ledger_balance(id)
+);
+
+create index ind_ledger_balance_payload_cid on ledger_balance(payload_cid(100));
+
+alter table refund_settlement drop column unused_share;
+
+alter table event_lock_payment add quote_signature varchar(100);
+
+
+create table renewal_reservation (
+    id                   bigint        not null auto_increment,
+    source_file_id       bigint        not null,
+    payload_cid          varchar(1000) not null,
+    renewed_deal_file_id bigint        not null,
+    amount               decimal(20,0) not null,
+    create_at            bigint        not null,
+    primary key pk_renewal_reservation(id),
+    constraint fk_renewal_reservation_renewed_deal_file_id foreign key (renewed_deal_file_id) references deal_file (id)
+);
+
+create index ind_renewal_reservation_payload_cid on renewal_reservation(payload_cid(100));
+
+alter table event_lock_payment add quote_status varchar(50);
+alter table event_lock_payment add quote_note   varchar(1000);
+
+
+create table api_key (
+    id             bigint       not null auto_increment,
+    wallet_address varchar(200) not null,
+    key_hash       varchar(100) not null,
+    key_prefix     varchar(45)  not null,
+    status         varchar(45)  not null,
+    create_at      bigint       not null,
+    update_at      bigint       not null,
+    primary key pk_api_key(id),
+    constraint un_api_key_key_hash unique (key_hash)
+);
+
+create index ind_api_key_wallet_address on api_key(wallet_address);
+
+alter table webhook add api_key_id bigint;
+alter table webhook add constraint fk_webhook_api_key_id foreign key (api_key_id) references api_key (id);